@@ -1,42 +1,169 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/k1nky/gophkeeper/internal/adapter/gophkeeper"
+	"github.com/k1nky/gophkeeper/internal/adapter/store"
+	"github.com/k1nky/gophkeeper/internal/breach"
 	"github.com/k1nky/gophkeeper/internal/crypto"
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
 	"github.com/k1nky/gophkeeper/internal/logger"
+	"github.com/k1nky/gophkeeper/internal/passgen"
 	"github.com/k1nky/gophkeeper/internal/service/keeper"
+	"github.com/k1nky/gophkeeper/internal/service/sshagent"
 	"github.com/k1nky/gophkeeper/internal/service/sync"
 )
 
 type Context struct {
 	keeper *keeper.Service
+	store  *store.Adapter
 	ctx    context.Context
 	sync   *sync.Service
 	client *gophkeeper.Adapter
 	log    *logger.Logger
 	secret string
+	// путь к ключевому файлу
+	keyfile string
+	kdf     string
+	key     *crypto.Key
+}
+
+// Key возвращает ключ шифрования хранилища. Ключ формируется из секрета при первом обращении.
+func (ctx *Context) Key() (*crypto.Key, error) {
+	if ctx.key != nil {
+		return ctx.key, nil
+	}
+	if ctx.secret == defaultSecret && !cli.AllowDefault {
+		return nil, errDefaultSecret
+	}
+	params, err := crypto.NewKDFParams(ctx.kdf)
+	if err != nil {
+		return nil, err
+	}
+	cred, err := credentials(ctx.secret, ctx.keyfile)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.key, err = ctx.keeper.UnlockVault(ctx.ctx, cred, params); err != nil {
+		return nil, err
+	}
+	return ctx.key, nil
+}
+
+// credentials возвращает учетные данные хранилища из пароля secret и ключевого файла по пути keyfile.
+func credentials(secret string, keyfile string) (vault.Credentials, error) {
+	cred := vault.Credentials{Secret: secret}
+	if len(keyfile) == 0 {
+		return cred, nil
+	}
+	f, err := os.Open(keyfile)
+	if err != nil {
+		return cred, err
+	}
+	defer f.Close()
+	cred.Keyfile, err = crypto.ReadKeyfile(f)
+	return cred, err
+}
+
+// unlockSync передает службе синхронизации ключ хранилища для шифрования мета-данных секретов.
+// Ключ получается из заголовка, общего для всех устройств пользователя, поэтому заголовок согласуется
+// с удаленным хранилищем до получения ключа и еще раз после него, если хранилище было только что создано.
+func (ctx *Context) unlockSync() error {
+	if err := ctx.sync.SyncHeader(ctx.ctx); err != nil {
+		return err
+	}
+	key, err := ctx.Key()
+	if err != nil {
+		return err
+	}
+	h, err := ctx.keeper.GetVaultHeader(ctx.ctx)
+	if err != nil {
+		return err
+	}
+	if h != nil && !h.Shared {
+		if err := ctx.sync.SyncHeader(ctx.ctx); err != nil {
+			return err
+		}
+	}
+	ctx.sync.SetMetaKey(key, cli.SealMeta)
+	return nil
 }
 
 type LsCmd struct {
-	Remote bool `optional:"" name:"remote" help:"List secrets from remote storage."`
+	// с --seal-meta метки и папка зашифрованы, и сервер отбирает секреты только по типу
+	Remote bool     `optional:"" name:"remote" help:"List secrets from remote storage. Filters are applied by the server, but with --seal-meta tags and folder are sealed, so the server filters only by type and the rest is filtered locally."`
+	Output string   `optional:"" name:"output" short:"o" enum:"table,json,yaml,raw" default:"table" help:"Output format: table, json, yaml or raw."`
+	Tags   []string `optional:"" name:"tag" help:"List only secrets with all of the tags. Not filtered by the server with --seal-meta."`
+	Folder string   `optional:"" name:"folder" help:"List only secrets from the folder and its subfolders. Not filtered by the server with --seal-meta."`
+	Types  []string `optional:"" name:"type" help:"List only secrets of the types: ${secret_types}."`
+	Tree   bool     `optional:"" name:"tree" help:"Show secrets as a folder tree."`
+	// срок действия проверяется по времени клиента, а не по метке, которую ставит сервер
+	Expiring bool     `optional:"" name:"expiring" help:"List only secrets that expire soon or have already expired."`
+	Within   duration `optional:"" name:"within" default:"30d" help:"Period to look ahead for --expiring, e.g. 30d or 12h."`
 }
 
 type PutCmd struct {
-	Type  string `required:"" name:"type" enum:"text,file,login,card" default:"text"`
-	Value string `arg:"" name:"value" help:""`
+	Type  string `required:"" name:"type" enum:"${secret_types}" default:"text" help:"Secret type: ${secret_types}."`
+	Value string `arg:"" optional:"" name:"value" help:"Secret value, its meaning depends on the secret type."`
 	Alias string `optional:"" name:"alias" help:"Secret entry alias."`
 	Id    string `optional:"" name:"id" help:"Secret entry ID to show."`
+	// сжатие выбирается по типу секрета, флаг позволяет его отключить
+	NoCompress bool `optional:"" name:"no-compress" help:"Do not compress secret data before encryption."`
+	// формирование данных секрета вместо ввода, поддерживается не всеми типами
+	Generate bool   `optional:"" name:"generate" help:"Generate secret data instead of reading it."`
+	KeyType  string `optional:"" name:"key-type" enum:"ed25519,rsa" default:"ed25519" help:"Type of generated SSH key."`
+	KeyBits  int    `optional:"" name:"key-bits" help:"Size of generated RSA key."`
+	Comment  string `optional:"" name:"comment" help:"Comment of generated key."`
+	Policy   string `optional:"" name:"policy" help:"Named password policy for a generated password, see generate policy."`
+	// пользовательские поля хранятся в зашифрованных данных секрета любого типа
+	Fields       []string `optional:"" name:"field" help:"Custom field in form name[:type]=value, type is one of text,hidden,url,email,date."`
+	ExposeFields bool     `optional:"" name:"expose-fields" help:"Store names of non-hidden custom fields in secret meta."`
+	// метки и папка обновляемого секрета сохраняются, если не указаны
+	Tags   []string `optional:"" name:"tag" help:"Secret tag, can be repeated. Replaces tags of an updated secret."`
+	Folder string   `optional:"" name:"folder" help:"Secret folder path, e.g. work/banks. Use / for the root folder."`
+	// срок действия обновляемого секрета сохраняется, если не указан
+	Expires string `optional:"" name:"expires" help:"Expiration or rotation date: 2006-01-02, RFC 3339 time, period from now like 90d, or never."`
 }
 
 type ShCmd struct {
-	Id    string `optional:"" name:"id" help:"Secret entry ID to show."`
-	Alias string `optional:"" name:"alias" help:"Secret entry alias to show."`
+	Id     string `optional:"" name:"id" help:"Secret entry ID to show."`
+	Alias  string `optional:"" name:"alias" help:"Secret entry alias to show."`
+	Reveal bool   `optional:"" name:"reveal" help:"Show sensitive secret data, e.g. SSH private key or full card number."`
+	Out    string `optional:"" name:"out" type:"path" help:"Write secret data to a new file with 0600 permissions."`
+	Field  string `optional:"" name:"field" help:"Print only the value of the secret field or custom field."`
+	// в json и yaml чувствительные поля скрыты так же, как в таблице, полностью их выводят --reveal и --field
+	Output string `optional:"" name:"output" short:"o" enum:"table,json,yaml,raw" default:"table" help:"Output format: table, json, yaml or raw secret data."`
+}
+
+type HistoryCmd struct {
+	Id     string `optional:"" name:"id" help:"Secret entry ID."`
+	Alias  string `optional:"" name:"alias" help:"Secret entry alias."`
+	Remote bool   `optional:"" name:"remote" help:"Show history from remote storage."`
+	Output string `optional:"" name:"output" short:"o" enum:"table,json,yaml,raw" default:"table" help:"Output format: table, json, yaml or raw."`
+}
+
+type RestoreCmd struct {
+	Id       string `optional:"" name:"id" help:"Secret entry ID to restore."`
+	Alias    string `optional:"" name:"alias" help:"Secret entry alias to restore."`
+	Revision int64  `required:"" name:"revision" help:"Revision to restore, see the history command."`
+}
+
+type OtpCmd struct {
+	Id    string `optional:"" name:"id" help:"TOTP secret entry ID."`
+	Alias string `optional:"" name:"alias" help:"TOTP secret entry alias."`
 }
 
 type PushCmd struct {
@@ -48,12 +175,166 @@ type PushCmd struct {
 
 type PullCmd struct {
 	Id    string `optional:"" name:"id" help:"Secret entry ID to pull."`
+	Alias string `optional:"" name:"alias" help:"Secret entry alias to pull."`
 	All   bool   `optional:"" name:"all" help:"Pull all secrets from remote storage."`
 	Force bool   `optional:"" name:"force" help:"Push all secrets from local storage."`
 }
 
+type RekeyCmd struct {
+	Old           string `required:"" name:"old-secret" env:"VAULT_OLD_SECRET" help:"Current vault secret."`
+	New           string `required:"" name:"new-secret" env:"VAULT_NEW_SECRET" help:"New vault secret."`
+	NewKeyfile    string `optional:"" name:"new-keyfile" type:"existingfile" help:"New keyfile. By default the current keyfile is kept."`
+	RemoveKeyfile bool   `optional:"" name:"remove-keyfile" xor:"new-keyfile" help:"Do not use a keyfile with the new secret."`
+}
+
+type SSHAgentCmd struct {
+	Socket      string        `required:"" name:"socket" type:"path" help:"Path to the agent unix socket."`
+	Allow       []string      `optional:"" name:"allow" help:"Aliases of SSH key secrets served by the agent. By default all keys are served."`
+	Confirm     bool          `optional:"" name:"confirm" help:"Ask for confirmation on the terminal before each key usage."`
+	IdleTimeout time.Duration `optional:"" name:"idle-timeout" default:"15m" help:"Lock the agent after this period of inactivity, 0 disables locking."`
+}
+
+type CertsCmd struct {
+	Expiring CertsExpiringCmd `cmd:"" help:"List certificates that expire soon or have already expired."`
+}
+
+type CertsExpiringCmd struct {
+	Within duration `optional:"" name:"within" default:"30d" help:"Period to look ahead, e.g. 30d or 12h."`
+}
+
+type CardsCmd struct {
+	Expiring CardsExpiringCmd `cmd:"" help:"List credit cards that expire soon or have already expired."`
+}
+
+type CardsExpiringCmd struct {
+	Within duration `optional:"" name:"within" default:"30d" help:"Period to look ahead, e.g. 30d or 12h."`
+}
+
+type GenerateCmd struct {
+	Password GeneratePasswordCmd `cmd:"" default:"withargs" help:"Generate random passwords or diceware passphrases."`
+	Policy   PolicyCmd           `cmd:"" help:"Manage named password policies stored in the vault."`
+}
+
+// PolicyFlags правила формирования пароля, заданные в командной строке.
+type PolicyFlags struct {
+	Length           int    `optional:"" name:"length" default:"20" help:"Password length."`
+	NoLower          bool   `optional:"" name:"no-lower" help:"Do not use lowercase letters."`
+	NoUpper          bool   `optional:"" name:"no-upper" help:"Do not use uppercase letters."`
+	NoDigits         bool   `optional:"" name:"no-digits" help:"Do not use digits."`
+	NoSymbols        bool   `optional:"" name:"no-symbols" help:"Do not use symbols."`
+	ExcludeAmbiguous bool   `optional:"" name:"exclude-ambiguous" help:"Do not use characters that are easy to confuse, e.g. l, 1, O and 0."`
+	Words            int    `optional:"" name:"words" help:"Generate a diceware passphrase of this many words instead of a password."`
+	Separator        string `optional:"" name:"separator" default:"-" help:"Passphrase words separator."`
+}
+
+func (f PolicyFlags) policy() passgen.Policy {
+	if f.Words > 0 {
+		return passgen.Policy{Words: f.Words, Separator: f.Separator}
+	}
+	return passgen.Policy{
+		Length:           f.Length,
+		Lower:            !f.NoLower,
+		Upper:            !f.NoUpper,
+		Digits:           !f.NoDigits,
+		Symbols:          !f.NoSymbols,
+		ExcludeAmbiguous: f.ExcludeAmbiguous,
+	}
+}
+
+type GeneratePasswordCmd struct {
+	PolicyFlags `embed:""`
+	// именованные правила заменяют правила из командной строки целиком
+	Policy string `optional:"" name:"policy" help:"Use the named policy instead of the flags."`
+	Count  int    `optional:"" name:"count" short:"n" default:"1" help:"Number of passwords to generate."`
+}
+
+type PolicyCmd struct {
+	Save PolicySaveCmd `cmd:"" help:"Save a named password policy, an existing policy is replaced."`
+	Ls   PolicyLsCmd   `cmd:"" help:"List named password policies."`
+}
+
+type PolicySaveCmd struct {
+	Name        string `arg:"" name:"name" help:"Policy name, e.g. bank."`
+	PolicyFlags `embed:""`
+}
+
+type PolicyLsCmd struct{}
+
+type AuditCmd struct {
+	FailOnIssues bool             `optional:"" name:"fail-on-issues" help:"Exit with code 6 if any issue is found, e.g. in CI."`
+	Report       AuditReportCmd   `cmd:"" default:"withargs" help:"Report weak, reused and stale passwords of login secrets."`
+	Breached     AuditBreachedCmd `cmd:"" help:"Check passwords of login secrets against a local list of breached passwords."`
+}
+
+type AuditReportCmd struct {
+	MinEntropy float64  `optional:"" name:"min-entropy" default:"50" help:"Passwords with estimated entropy below this number of bits are weak."`
+	MaxAge     duration `optional:"" name:"max-age" default:"365d" help:"Passwords not changed for longer than this period are stale, 0 disables the check."`
+	Output     string   `optional:"" name:"output" short:"o" enum:"table,json,yaml" default:"table" help:"Output format: table, json or yaml."`
+}
+
+type AuditBreachedCmd struct {
+	// список скачивается заранее, при проверке сеть не используется
+	Hashes string `required:"" name:"hashes" type:"path" help:"SHA-1 list of breached passwords ordered by hash or a directory of hash prefix files."`
+	Output string `optional:"" name:"output" short:"o" enum:"table,json,yaml" default:"table" help:"Output format: table, json or yaml."`
+}
+
+type KeyfileCmd struct {
+	Generate KeyfileGenerateCmd `cmd:"" help:"Generate a new random keyfile."`
+}
+
+type KeyfileGenerateCmd struct {
+	Path string `arg:"" name:"path" type:"path" help:"Path to the new keyfile."`
+}
+
 type remoteVaultFlag string
 
+// duration длительность в формате time.ParseDuration, дополнительно поддерживаются дни, например 30d.
+type duration time.Duration
+
+func (d *duration) UnmarshalText(b []byte) error {
+	s := string(b)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return fmt.Errorf("invalid duration %s", s)
+		}
+		*d = duration(time.Duration(n) * 24 * time.Hour)
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	*d = duration(v)
+	return err
+}
+
+// parseExpires разбирает срок действия секрета: дату, время в формате RFC 3339, период от момента now
+// или never для отмены срока.
+func parseExpires(s string, now time.Time) (time.Time, error) {
+	if s == "never" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	var d duration
+	if err := d.UnmarshalText([]byte(s)); err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiration %s, use 2006-01-02, RFC 3339 time, period like 90d or never", s)
+	}
+	return now.Add(time.Duration(d)), nil
+}
+
+// defaultSecret секрет хранилища по умолчанию. Использовать его можно только явно указав --allow-default-secret.
+const defaultSecret = "secret"
+
+var (
+	errDefaultSecret = errors.New("refusing to use the built-in default secret, set --secret or --allow-default-secret")
+	errNotTOTP       = errors.New("secret is not a TOTP secret")
+	errNotGenerated  = errors.New("secret data can not be generated for type")
+	errAuditIssues   = errors.New("audit found issues")
+)
+
 // TODO: delete secret
 var cli struct {
 	Debug          bool            `optional:"" name:"debug" env:"DEBUG" help:"Enable debug mode."`
@@ -64,14 +345,32 @@ var cli struct {
 	Password       string          `optional:"" name:"remote-password" env:"REMOTE_VAULT_PASSWORD"`
 	Token          string          `optional:"" name:"remote-token" env:"REMOTE_VAULT_TOKEN"`
 	Secret         string          `optional:"" name:"secret" env:"VAULT_SECRET" default:"secret"`
+	AllowDefault   bool            `optional:"" name:"allow-default-secret" env:"ALLOW_DEFAULT_SECRET" help:"Allow to use the built-in default vault secret."`
+	KDF            string          `optional:"" name:"kdf" env:"VAULT_KDF" enum:"argon2id,scrypt" default:"argon2id" help:"Key derivation function for a new vault."`
+	Keyfile        string          `optional:"" name:"keyfile" env:"VAULT_KEYFILE" type:"existingfile" help:"Keyfile used together with the secret to unlock the vault."`
+	SealMeta       bool            `optional:"" name:"seal-meta" env:"VAULT_SEAL_META" help:"Encrypt secret alias and extra before pushing to remote storage."`
+	Retention      int             `optional:"" name:"history-retention" env:"HISTORY_RETENTION" default:"10" help:"Number of previous revisions kept for each secret, 0 disables history."`
 	Ls             LsCmd           `cmd:"" help:"List secrects from local or remote storage."`
 	Put            PutCmd          `cmd:"" help:"Put secrect to local storage."`
 	Push           PushCmd         `cmd:"" help:"Push secrect to remote storage."`
 	Sh             ShCmd           `cmd:"" help:"Show secrect from local storage."`
+	Otp            OtpCmd          `cmd:"" help:"Show current TOTP code from local storage."`
+	History        HistoryCmd      `cmd:"" help:"List revisions of a secret from local or remote storage."`
+	Restore        RestoreCmd      `cmd:"" help:"Restore a previous revision of a secret in local storage."`
 	Pull           PullCmd         `cmd:"" help:"Pull secrect from remote storage."`
+	Rekey          RekeyCmd        `cmd:"" help:"Change vault secret and re-encrypt secrets. Run push --all afterwards to update the remote storage, its older revisions keep the old secret."`
+	KeyfileCmd     KeyfileCmd      `cmd:"" name:"keyfile" help:"Manage vault keyfiles."`
+	Generate       GenerateCmd     `cmd:"" help:"Generate passwords and manage password policies."`
+	Audit          AuditCmd        `cmd:"" help:"Audit passwords from local storage."`
+	Certs          CertsCmd        `cmd:"" help:"Manage certificates from local storage."`
+	Cards          CardsCmd        `cmd:"" help:"Manage credit cards from local storage."`
+	SSHAgent       SSHAgentCmd     `cmd:"" name:"ssh-agent" help:"Serve SSH keys from local storage over the ssh-agent protocol."`
 }
 
 func (c *PushCmd) Run(ctx *Context) error {
+	if err := ctx.unlockSync(); err != nil {
+		return err
+	}
 	if c.All {
 		return ctx.sync.PushAll(ctx.ctx, c.Force)
 	}
@@ -79,6 +378,9 @@ func (c *PushCmd) Run(ctx *Context) error {
 	if err != nil {
 		return err
 	}
+	if meta == nil {
+		return vault.ErrMetaNotExists
+	}
 	_, err = ctx.sync.Push(ctx.ctx, *meta, c.Force)
 	return err
 }
@@ -88,13 +390,38 @@ func (c *LsCmd) Run(ctx *Context) error {
 		list vault.List
 		err  error
 	)
-	if c.Remote {
-		list, err = ctx.client.ListSecrets(ctx.ctx)
-	} else {
+	filter := vault.Filter{Tags: c.Tags, Folder: c.Folder}
+	for _, name := range c.Types {
+		t, err := vault.ParseSecretType(name)
+		if err != nil {
+			return err
+		}
+		filter.Types = append(filter.Types, t)
+	}
+	switch {
+	case c.Remote && c.Expiring:
+		if err := ctx.unlockSync(); err != nil {
+			return err
+		}
+		list, err = ctx.sync.ListExpiring(ctx.ctx, time.Duration(c.Within))
+	case c.Remote:
+		if err := ctx.unlockSync(); err != nil {
+			return err
+		}
+		list, err = ctx.sync.ListSecrets(ctx.ctx, filter)
+	case c.Expiring:
+		list, err = ctx.keeper.ListExpiringSecrets(ctx.ctx, time.Duration(c.Within))
+	default:
 		list, err = ctx.keeper.ListSecretsByUser(ctx.ctx)
 	}
-	fmt.Println(list.String())
-	return err
+	if err != nil {
+		return err
+	}
+	list = list.Filter(filter)
+	if c.Tree {
+		return writeTree(os.Stdout, list)
+	}
+	return writeList(os.Stdout, c.Output, list)
 }
 
 func getMeta(ctx *Context, id vault.MetaID, alias string) (*vault.Meta, error) {
@@ -114,11 +441,9 @@ func getMeta(ctx *Context, id vault.MetaID, alias string) (*vault.Meta, error) {
 }
 
 func (c *PutCmd) Run(ctx *Context) error {
-	var value io.ReadCloser
 	m := vault.Meta{
-		ID:       vault.NewMetaID(),
-		Alias:    c.Alias,
-		Revision: vault.NewRevision(),
+		ID:    vault.NewMetaID(),
+		Alias: c.Alias,
 	}
 
 	if mm, _ := getMeta(ctx, vault.MetaID(c.Id), c.Alias); mm != nil {
@@ -126,80 +451,758 @@ func (c *PutCmd) Run(ctx *Context) error {
 		if len(m.Alias) == 0 {
 			m.Alias = mm.Alias
 		}
+		m.Tags, m.Folder, m.ExpiresAt = mm.Tags, mm.Folder, mm.ExpiresAt
 	}
-
-	switch c.Type {
-	case "text":
-		value = vault.NewBytesBuffer([]byte(c.Value))
-		m.Type = vault.TypeText
-	case "login":
-		lp := &vault.LoginPassword{}
-		lp.Prompt()
-		b, err := lp.Bytes()
+	if len(c.Expires) != 0 {
+		expires, err := parseExpires(c.Expires, time.Now())
 		if err != nil {
 			return err
 		}
-		m.Type = vault.TypeLoginPassword
-		value = vault.NewBytesBuffer(b)
-	case "card":
-		cc := &vault.CreditCard{}
-		cc.Prompt()
-		b, err := cc.Bytes()
-		if err != nil {
-			return err
+		m.SetExpires(expires)
+	}
+	if len(c.Tags) != 0 {
+		m.Tags = vault.NormalizeTags(c.Tags)
+	}
+	if len(c.Folder) != 0 {
+		m.Folder = vault.NormalizeFolder(c.Folder)
+	}
+
+	kind, ok := vault.LookupTypeName(c.Type)
+	if !ok {
+		return fmt.Errorf("%w: %s", vault.ErrUnknownType, c.Type)
+	}
+	m.Type = kind.Type
+	secret := kind.New()
+	if c.Generate {
+		g, ok := secret.(vault.Generator)
+		if !ok {
+			return fmt.Errorf("%w: %s", errNotGenerated, kind.Name)
+		}
+		opts := vault.GenerateOptions{Algorithm: c.KeyType, Bits: c.KeyBits, Comment: c.Comment}
+		if len(c.Policy) != 0 {
+			policy, err := loadPolicy(ctx, c.Policy)
+			if err != nil {
+				return err
+			}
+			opts.Policy = policy
 		}
-		m.Type = vault.TypeCreditCard
-		value = vault.NewBytesBuffer(b)
-	case "file":
-		if f, err := os.OpenFile(c.Value, os.O_RDONLY, 0660); err != nil {
+		if err := g.Generate(opts); err != nil {
 			return err
-		} else {
-			defer f.Close()
-			value = f
 		}
-		m.Type = vault.TypeFile
+	} else if err := secret.Prompt(c.Value); err != nil {
+		return err
+	}
+	if err := secret.Validate(); err != nil {
+		return err
+	}
+	fields, err := c.customFields(secret)
+	if err != nil {
+		return err
+	}
+	if d, ok := secret.(vault.Describer); ok {
+		m.Attributes = d.Attributes()
+	}
+	if names := fields.Names(); c.ExposeFields && len(names) != 0 {
+		if m.Attributes == nil {
+			m.Attributes = map[string]string{}
+		}
+		m.Attributes[vault.AttrFields] = strings.Join(names, ",")
+	}
+	compression := m.Type.Compression()
+	if c.NoCompress {
+		compression = crypto.CompressNone
+	}
+	meta, err := storeSecret(ctx, m, secret, fields, compression)
+	if err != nil {
+		return err
 	}
-	// TODO: вектор инициализации можно хранить в мета-данных
-	enc, _ := crypto.NewEncryptReader(ctx.secret, value, nil)
-	data := vault.NewDataReader(enc)
-	meta, err := ctx.keeper.PutSecret(ctx.ctx, m, data)
 	fmt.Println(meta.String())
-	return err
+	return nil
+}
+
+// storeSecret шифрует данные секрета secret с пользовательскими полями fields и сохраняет его
+// с мета-данными m в локальном хранилище как новую версию секрета.
+func storeSecret(ctx *Context, m vault.Meta, secret vault.Secret, fields vault.CustomFields, compression crypto.Compression) (*vault.Meta, error) {
+	value, err := vault.EncodePayload(secret, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer value.Close()
+	// данные каждого секрета шифруются своим ключом, который хранится в мета-данных зашифрованным мастер-ключом
+	master, err := ctx.Key()
+	if err != nil {
+		return nil, err
+	}
+	key, err := crypto.NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+	if m.WrappedKey, err = crypto.WrapKey(master, key); err != nil {
+		return nil, err
+	}
+	enc, err := crypto.NewEncryptReaderWithCompression(key, value, compression)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.keeper.NewVersion(ctx.ctx, &m); err != nil {
+		return nil, err
+	}
+	return ctx.keeper.PutSecret(ctx.ctx, m, vault.NewDataReader(enc))
+}
+
+// customFields разбирает пользовательские поля из командной строки. Поля не должны совпадать
+// с полями данных секрета.
+func (c *PutCmd) customFields(secret vault.Secret) (vault.CustomFields, error) {
+	fields := vault.CustomFields{}
+	for _, s := range c.Fields {
+		f, err := vault.ParseCustomField(s)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range secret.Fields() {
+			if name == f.Name {
+				return nil, fmt.Errorf("%w: field %s is defined by the secret type", vault.ErrInvalidSecret, f.Name)
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields, fields.Validate()
 }
 
 func (c *PullCmd) Run(ctx *Context) error {
+	if err := ctx.unlockSync(); err != nil {
+		return err
+	}
 	if c.All {
 		return ctx.sync.PullAll(ctx.ctx, c.Force)
 	}
-	meta, err := ctx.client.GetSecretMeta(ctx.ctx, vault.MetaID(c.Id))
+	var (
+		meta *vault.Meta
+		err  error
+	)
+	if len(c.Id) != 0 {
+		meta, err = ctx.client.GetSecretMeta(ctx.ctx, vault.MetaID(c.Id))
+	} else {
+		meta, err = ctx.sync.GetSecretMetaByAlias(ctx.ctx, c.Alias)
+	}
 	if err != nil {
 		return err
 	}
+	if meta == nil {
+		return vault.ErrMetaNotExists
+	}
 	newMeta, err := ctx.sync.Pull(ctx.ctx, *meta, c.Force)
+	if err != nil {
+		return err
+	}
 	fmt.Println(newMeta.String())
-	return err
+	return nil
+}
+
+// decryptedSecret расшифрованный секрет из локального хранилища.
+type decryptedSecret struct {
+	meta   *vault.Meta
+	secret vault.Secret
+	fields vault.CustomFields
+	// данные секрета могут читаться потоком, поэтому после использования их нужно закрыть
+	data io.Closer
+}
+
+// field возвращает значение поля данных секрета или его пользовательского поля с именем name.
+func (d *decryptedSecret) field(name string) (string, error) {
+	value, err := d.secret.Field(name)
+	if errors.Is(err, vault.ErrUnknownField) {
+		if f, ok := d.fields.Get(name); ok {
+			return f.Value, nil
+		}
+	}
+	return value, err
+}
+
+func (d *decryptedSecret) Close() error {
+	return d.data.Close()
+}
+
+// openSecret возвращает мета-данные и расшифрованные данные секрета из локального хранилища.
+func openSecret(ctx *Context, id string, alias string) (*decryptedSecret, error) {
+	meta, err := getMeta(ctx, vault.MetaID(id), alias)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, vault.ErrMetaNotExists
+	}
+	master, err := ctx.Key()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ctx.keeper.GetSecretData(ctx.ctx, vault.MetaID(meta.ID))
+	if err != nil {
+		return nil, err
+	}
+	// ключ проверяется до того, как что-либо будет выведено
+	dec, err := keeper.DecryptSecret(master, *meta, data)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	d := &decryptedSecret{meta: meta, data: data}
+	d.secret, err = meta.Type.New()
+	if err == nil {
+		d.fields, err = vault.DecodePayload(d.secret, dec)
+	}
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	return d, nil
 }
 
 func (c *ShCmd) Run(ctx *Context) error {
+	d, err := openSecret(ctx, c.Id, c.Alias)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if len(c.Field) != 0 {
+		value, err := d.field(c.Field)
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	}
+	if len(c.Out) == 0 && (c.Output == outputJSON || c.Output == outputYAML) {
+		v, err := newSecretView(d, c.Reveal)
+		if err != nil {
+			return err
+		}
+		return writeStructured(os.Stdout, c.Output, v)
+	}
+	render := d.secret.Render
+	if r, ok := d.secret.(vault.Revealer); ok && c.Reveal {
+		render = r.Reveal
+	}
+	if len(c.Out) == 0 && c.Output == outputRaw {
+		return render(os.Stdout)
+	}
+	if len(c.Out) == 0 {
+		fmt.Println(d.meta)
+		if err := render(os.Stdout); err != nil {
+			return err
+		}
+		if len(d.fields) == 0 {
+			return nil
+		}
+		fmt.Println()
+		return d.fields.Render(os.Stdout, c.Reveal)
+	}
+	// существующий файл не перезаписываем, а доступ к новому есть только у владельца
+	f, err := os.OpenFile(c.Out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if err := render(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (c *HistoryCmd) Run(ctx *Context) error {
 	var (
-		meta *vault.Meta
-		err  error
+		meta      *vault.Meta
+		revisions vault.List
+		err       error
 	)
-	if len(c.Id) != 0 {
-		meta, err = ctx.keeper.GetSecretMeta(ctx.ctx, vault.MetaID(c.Id))
+	if c.Remote {
+		if err := ctx.unlockSync(); err != nil {
+			return err
+		}
+		if len(c.Id) != 0 {
+			meta, err = ctx.client.GetSecretMeta(ctx.ctx, vault.MetaID(c.Id))
+		} else {
+			meta, err = ctx.sync.GetSecretMetaByAlias(ctx.ctx, c.Alias)
+		}
 	} else {
-		meta, err = ctx.keeper.GetSecretMetaByAlias(ctx.ctx, c.Alias)
+		meta, err = getMeta(ctx, vault.MetaID(c.Id), c.Alias)
 	}
 	if err != nil {
 		return err
 	}
-	fmt.Println(meta)
-	data, err := ctx.keeper.GetSecretData(ctx.ctx, vault.MetaID(meta.ID))
+	if meta == nil {
+		return vault.ErrMetaNotExists
+	}
+	if c.Remote {
+		revisions, err = ctx.sync.ListRevisions(ctx.ctx, meta.ID)
+	} else {
+		revisions, err = ctx.keeper.ListSecretRevisions(ctx.ctx, meta.ID)
+	}
 	if err != nil {
 		return err
 	}
-	dec, _ := crypto.NewDecryptReader(ctx.secret, data, nil)
-	defer data.Close()
-	_, err = io.Copy(os.Stdout, dec)
-	return err
+	// текущая версия выводится первой
+	return writeHistory(os.Stdout, c.Output, append(vault.List{*meta}, revisions...))
+}
+
+func (c *RestoreCmd) Run(ctx *Context) error {
+	meta, err := getMeta(ctx, vault.MetaID(c.Id), c.Alias)
+	if err != nil {
+		return err
+	}
+	if meta == nil {
+		return vault.ErrMetaNotExists
+	}
+	restored, err := ctx.keeper.RestoreSecret(ctx.ctx, meta.ID, c.Revision)
+	if err != nil {
+		return err
+	}
+	fmt.Println(restored)
+	return nil
+}
+
+func (c *OtpCmd) Run(ctx *Context) error {
+	d, err := openSecret(ctx, c.Id, c.Alias)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	totp, ok := d.secret.(*vault.TOTP)
+	if !ok {
+		return errNotTOTP
+	}
+	code, remaining, err := totp.Code(time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %ds\n", code, int(remaining.Seconds()))
+	return nil
+}
+
+func (c *RekeyCmd) Run(ctx *Context) error {
+	if c.New == defaultSecret && !cli.AllowDefault {
+		return errDefaultSecret
+	}
+	params, err := crypto.NewKDFParams(cli.KDF)
+	if err != nil {
+		return err
+	}
+	oldCred, err := credentials(c.Old, ctx.keyfile)
+	if err != nil {
+		return err
+	}
+	keyfile := ctx.keyfile
+	if len(c.NewKeyfile) != 0 {
+		keyfile = c.NewKeyfile
+	} else if c.RemoveKeyfile {
+		keyfile = ""
+	}
+	newCred, err := credentials(c.New, keyfile)
+	if err != nil {
+		return err
+	}
+	if ctx.client == nil {
+		dropped, err := ctx.keeper.Rekey(ctx.ctx, oldCred, newCred, params)
+		reportDropped(ctx, dropped)
+		return err
+	}
+	// если ключ уже сменен на другом устройстве, то локальное хранилище переводится на опубликованный заголовок
+	remote, err := ctx.sync.GetVaultHeader(ctx.ctx)
+	if err != nil {
+		return err
+	}
+	local, err := ctx.keeper.GetVaultHeader(ctx.ctx)
+	if err != nil {
+		return err
+	}
+	var dropped vault.List
+	if remote != nil && local != nil && local.Shared && !local.SameVault(*remote) {
+		dropped, err = ctx.keeper.RejoinVault(ctx.ctx, oldCred, newCred, *remote)
+	} else {
+		dropped, err = ctx.keeper.Rekey(ctx.ctx, oldCred, newCred, params)
+	}
+	reportDropped(ctx, dropped)
+	if err != nil {
+		return err
+	}
+	// новый заголовок заменяет опубликованный, секреты с новыми версиями отправляются через push --all
+	return ctx.sync.SyncHeader(ctx.ctx)
+}
+
+// reportDropped сообщает о предыдущих версиях секретов, удаленных при смене ключа.
+func reportDropped(ctx *Context, dropped vault.List) {
+	for _, m := range dropped {
+		ctx.log.Errorf("rekey: revision %d of %s is dropped, it has no data key", m.Revision, m)
+	}
+}
+
+func (c *GeneratePasswordCmd) Run(ctx *Context) error {
+	policy := c.PolicyFlags.policy()
+	if len(c.Policy) != 0 {
+		var err error
+		if policy, err = loadPolicy(ctx, c.Policy); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < c.Count; i++ {
+		password, err := passgen.Generate(policy)
+		if err != nil {
+			return err
+		}
+		fmt.Println(password)
+	}
+	return nil
+}
+
+func (c *PolicySaveCmd) Run(ctx *Context) error {
+	p := &vault.PasswordPolicy{Policy: c.PolicyFlags.policy()}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	m := vault.Meta{
+		ID:    vault.NewMetaID(),
+		Alias: vault.PolicyAlias(c.Name),
+		Type:  vault.TypePasswordPolicy,
+	}
+	if mm, _ := getMeta(ctx, "", m.Alias); mm != nil {
+		m.ID, m.Tags, m.Folder = mm.ID, mm.Tags, mm.Folder
+	}
+	if _, err := storeSecret(ctx, m, p, nil, m.Type.Compression()); err != nil {
+		return err
+	}
+	fmt.Printf("%s: %s\n", c.Name, p.Policy)
+	return nil
+}
+
+func (c *PolicyLsCmd) Run(ctx *Context) error {
+	list, err := ctx.keeper.ListSecretsByUser(ctx.ctx)
+	if err != nil {
+		return err
+	}
+	list = list.Filter(vault.Filter{Types: []vault.SecretType{vault.TypePasswordPolicy}})
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Alias < list[j].Alias
+	})
+	for _, m := range list {
+		policy, err := loadPolicy(ctx, vault.PolicyName(m.Alias))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %s\n", vault.PolicyName(m.Alias), policy)
+	}
+	return nil
+}
+
+func (c *AuditBreachedCmd) Run(ctx *Context) error {
+	list, err := breach.Open(c.Hashes)
+	if err != nil {
+		return err
+	}
+	defer list.Close()
+	logins, err := loginPasswords(ctx)
+	if err != nil {
+		return err
+	}
+	report := auditReport{Checked: len(logins), Findings: []auditFinding{}}
+	for _, l := range logins {
+		count, err := list.Count(l.password)
+		if err != nil {
+			return err
+		}
+		if count != 0 {
+			report.Findings = append(report.Findings, auditFinding{
+				ID:     l.meta.ID,
+				Alias:  l.meta.Alias,
+				Issue:  issueBreached,
+				Count:  count,
+				Detail: fmt.Sprintf("seen %d times in breaches", count),
+			})
+		}
+	}
+	return finishAudit(report, c.Output)
+}
+
+func (c *AuditReportCmd) Run(ctx *Context) error {
+	logins, err := loginPasswords(ctx)
+	if err != nil {
+		return err
+	}
+	report := auditReport{Checked: len(logins), Findings: []auditFinding{}}
+	// одинаковые пароли группируются по хешу, чтобы не держать в карте сами пароли
+	reused := map[[sha256.Size]byte][]string{}
+	for _, l := range logins {
+		sum := sha256.Sum256([]byte(l.password))
+		reused[sum] = append(reused[sum], l.meta.Alias)
+	}
+	now := time.Now()
+	for _, l := range logins {
+		if bits := passgen.Entropy(l.password); bits < c.MinEntropy {
+			report.Findings = append(report.Findings, auditFinding{
+				ID:      l.meta.ID,
+				Alias:   l.meta.Alias,
+				Issue:   issueWeak,
+				Entropy: math.Round(bits),
+				Detail:  fmt.Sprintf("%s, about %.0f bits", passgen.Strength(bits), bits),
+			})
+		}
+		if aliases := reused[sha256.Sum256([]byte(l.password))]; len(aliases) > 1 {
+			others := make([]string, 0, len(aliases)-1)
+			for _, a := range aliases {
+				if a != l.meta.Alias {
+					others = append(others, a)
+				}
+			}
+			report.Findings = append(report.Findings, auditFinding{
+				ID:     l.meta.ID,
+				Alias:  l.meta.Alias,
+				Issue:  issueReused,
+				Detail: "same password as " + strings.Join(others, ", "),
+			})
+		}
+		changed := vault.RevisionTime(l.meta.Revision)
+		if age := now.Sub(changed); c.MaxAge > 0 && age > time.Duration(c.MaxAge) {
+			report.Findings = append(report.Findings, auditFinding{
+				ID:     l.meta.ID,
+				Alias:  l.meta.Alias,
+				Issue:  issueStale,
+				Detail: fmt.Sprintf("not changed for %d days, since %s", int(age.Hours()/24), changed.UTC().Format(time.DateOnly)),
+			})
+		}
+	}
+	return finishAudit(report, c.Output)
+}
+
+// finishAudit выводит отчет аудита в формате format. Если в отчете есть проблемы и задан --fail-on-issues,
+// то возвращает errAuditIssues.
+func finishAudit(report auditReport, format string) error {
+	if err := writeReport(os.Stdout, format, report); err != nil {
+		return err
+	}
+	if cli.Audit.FailOnIssues && len(report.Findings) != 0 {
+		return fmt.Errorf("%w: %d", errAuditIssues, len(report.Findings))
+	}
+	return nil
+}
+
+// loginPassword пароль секрета типа "логин-пароль".
+type loginPassword struct {
+	meta     vault.Meta
+	password string
+}
+
+// loginPasswords возвращает пароли всех неудаленных секретов типа "логин-пароль" из локального хранилища.
+func loginPasswords(ctx *Context) ([]loginPassword, error) {
+	list, err := ctx.keeper.ListSecretsByUser(ctx.ctx)
+	if err != nil {
+		return nil, err
+	}
+	logins := []loginPassword{}
+	for _, m := range list.Filter(vault.Filter{Types: []vault.SecretType{vault.TypeLoginPassword}}) {
+		if m.IsDeleted {
+			continue
+		}
+		d, err := openSecret(ctx, string(m.ID), "")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Alias, err)
+		}
+		password, err := d.field("password")
+		d.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Alias, err)
+		}
+		if len(password) != 0 {
+			logins = append(logins, loginPassword{meta: m, password: password})
+		}
+	}
+	return logins, nil
+}
+
+// loadPolicy возвращает именованные правила формирования паролей name из локального хранилища.
+func loadPolicy(ctx *Context, name string) (passgen.Policy, error) {
+	d, err := openSecret(ctx, "", vault.PolicyAlias(name))
+	if err != nil {
+		return passgen.Policy{}, fmt.Errorf("policy %s: %w", name, err)
+	}
+	defer d.Close()
+	p, ok := d.secret.(*vault.PasswordPolicy)
+	if !ok {
+		return passgen.Policy{}, fmt.Errorf("%w: %s is not a password policy", vault.ErrInvalidSecret, name)
+	}
+	return p.Policy, nil
+}
+
+func (c *KeyfileGenerateCmd) Run(ctx *Context) error {
+	// существующий файл не перезаписываем, чтобы случайно не потерять доступ к хранилищу
+	f, err := os.OpenFile(c.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if err := crypto.GenerateKeyfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Println(c.Path)
+	return nil
+}
+
+func (c *SSHAgentCmd) Run(ctx *Context) error {
+	master, err := ctx.Key()
+	if err != nil {
+		return err
+	}
+	params, err := crypto.NewKDFParams(ctx.kdf)
+	if err != nil {
+		return err
+	}
+	// агент работает долго, поэтому локальное хранилище открывается только на время обращения к нему
+	storage := transientStorage{ctx: ctx}
+	if err := ctx.store.Close(); err != nil {
+		return err
+	}
+	// после блокировки ключи расшифровываются заново, поэтому разблокировать агент можно только секретом хранилища
+	a := sshagent.New(storage, func(passphrase []byte) (key *crypto.Key, err error) {
+		cred, err := credentials(string(passphrase), ctx.keyfile)
+		if err != nil {
+			return nil, err
+		}
+		err = storage.do(ctx.ctx, func() error {
+			key, err = ctx.keeper.UnlockVault(ctx.ctx, cred, params)
+			return err
+		})
+		return key, err
+	}, ctx.log)
+	a.SetAllowList(c.Allow)
+	a.SetIdleTimeout(c.IdleTimeout)
+	if c.Confirm {
+		a.SetConfirm(confirmKeyUsage)
+	}
+	if err := a.Open(ctx.ctx, master); err != nil {
+		return err
+	}
+	defer a.Close()
+	l, err := net.Listen("unix", c.Socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(c.Socket)
+	if err := os.Chmod(c.Socket, 0600); err != nil {
+		l.Close()
+		return err
+	}
+	fmt.Printf("SSH_AUTH_SOCK=%s; export SSH_AUTH_SOCK;\n", c.Socket)
+	return a.Serve(ctx.ctx, l)
+}
+
+// transientStorage открывает локальное хранилище только на время обращения к нему, чтобы долго работающая
+// команда не блокировала хранилище для других запусков клиента.
+type transientStorage struct {
+	ctx *Context
+}
+
+func (s transientStorage) do(ctx context.Context, f func() error) error {
+	if err := s.ctx.store.Open(ctx); err != nil {
+		return err
+	}
+	defer s.ctx.store.Close()
+	return f()
+}
+
+func (s transientStorage) ListSecretsByUser(ctx context.Context) (list vault.List, err error) {
+	err = s.do(ctx, func() error {
+		list, err = s.ctx.keeper.ListSecretsByUser(ctx)
+		return err
+	})
+	return list, err
+}
+
+// GetSecretData возвращает данные секрета. Данные хранятся в отдельных файлах, поэтому их можно читать
+// и после закрытия хранилища.
+func (s transientStorage) GetSecretData(ctx context.Context, id vault.MetaID) (data *vault.DataReader, err error) {
+	err = s.do(ctx, func() error {
+		data, err = s.ctx.keeper.GetSecretData(ctx, id)
+		return err
+	})
+	return data, err
+}
+
+// confirmKeyUsage запрашивает на терминале разрешение на использование ключа секрета alias.
+// Если терминала нет, то использование ключа запрещается.
+func confirmKeyUsage(alias string) bool {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	defer tty.Close()
+	fmt.Fprintf(tty, "Allow use of SSH key %s? [y/N] ", alias)
+	answer, _ := bufio.NewReader(tty).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func (c *CertsExpiringCmd) Run(ctx *Context) error {
+	return listExpiring(ctx, vault.TypeCertificate, time.Duration(c.Within), func(m vault.Meta) (time.Time, string, error) {
+		notAfter, err := vault.CertificateNotAfter(m)
+		return notAfter, m.Attributes[vault.AttrSubject], err
+	})
+}
+
+func (c *CardsExpiringCmd) Run(ctx *Context) error {
+	// срок действия карты есть только в ее зашифрованных данных
+	return listExpiring(ctx, vault.TypeCreditCard, time.Duration(c.Within), func(m vault.Meta) (time.Time, string, error) {
+		d, err := openSecret(ctx, string(m.ID), "")
+		if err != nil {
+			return time.Time{}, "", err
+		}
+		defer d.Close()
+		card, ok := d.secret.(*vault.CreditCard)
+		if !ok {
+			return time.Time{}, "", fmt.Errorf("%w: %s is not a credit card", vault.ErrUnknownType, m.Type)
+		}
+		expires, err := card.Expires()
+		return expires, card.Brand(), err
+	})
+}
+
+// listExpiring выводит секреты типа t, срок действия которых истек или истекает в течение within.
+// Срок действия и описание секрета для вывода определяются функцией expires.
+func listExpiring(ctx *Context, t vault.SecretType, within time.Duration, expires func(vault.Meta) (time.Time, string, error)) error {
+	list, err := ctx.keeper.ListSecretsByUser(ctx.ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	deadline := now.Add(within)
+	type expiring struct {
+		meta     vault.Meta
+		notAfter time.Time
+		detail   string
+	}
+	secrets := []expiring{}
+	for _, m := range list {
+		if m.IsDeleted || m.Type != t {
+			continue
+		}
+		notAfter, detail, err := expires(m)
+		if err != nil {
+			ctx.log.Errorf("%s %s: %v", t, m.Alias, err)
+			continue
+		}
+		if !notAfter.After(deadline) {
+			secrets = append(secrets, expiring{meta: m, notAfter: notAfter, detail: detail})
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].notAfter.Before(secrets[j].notAfter)
+	})
+	for _, s := range secrets {
+		state := "expires"
+		if s.notAfter.Before(now) {
+			state = "expired"
+		}
+		fmt.Printf("%s %s %s %s %s\n", s.meta.ID, s.meta.Alias, state, s.notAfter.Format(time.RFC3339), s.detail)
+	}
+	return nil
 }
@@ -2,19 +2,24 @@ package main
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/alecthomas/kong"
 	"github.com/k1nky/gophkeeper/internal/adapter/gophkeeper"
 	"github.com/k1nky/gophkeeper/internal/adapter/store"
 	"github.com/k1nky/gophkeeper/internal/entity/user"
+	"github.com/k1nky/gophkeeper/internal/entity/vault"
 	"github.com/k1nky/gophkeeper/internal/logger"
 	"github.com/k1nky/gophkeeper/internal/service/keeper"
 	"github.com/k1nky/gophkeeper/internal/service/sync"
 	"github.com/k1nky/gophkeeper/internal/store/meta/bolt"
 	"github.com/k1nky/gophkeeper/internal/store/objects/filestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func newClient(ctx context.Context, url string, u user.User, token string, l *logger.Logger) (*gophkeeper.Adapter, error) {
@@ -42,7 +47,8 @@ func main() {
 
 	log := logger.New()
 
-	cmd := kong.Parse(&cli)
+	// допустимые типы секретов берутся из реестра типов
+	cmd := kong.Parse(&cli, kong.Vars{"secret_types": strings.Join(vault.TypeNames(), ",")})
 	if cli.Debug {
 		log.SetLevel("debug")
 	}
@@ -52,25 +58,55 @@ func main() {
 	}, cli.Token, log)
 	if err != nil {
 		log.Errorf("connect to %s: %v", cli.RemoteVault, err)
-		os.Exit(1)
+		os.Exit(exitError)
 	}
 	store := store.New(bolt.New(cli.MetaStoreDSN), filestore.New(cli.ObjectStoreDSN))
 	if err := store.Open(ctx); err != nil {
 		log.Errorf("store: %v", err)
-		os.Exit(1)
+		os.Exit(exitError)
 	}
 	defer store.Close()
+	store.SetRetention(cli.Retention)
 	keeper := keeper.New(store, log)
 	sync := sync.New(client, keeper, log)
 
 	if err = cmd.Run(&Context{
-		keeper: keeper,
-		ctx:    ctx,
-		client: client,
-		sync:   sync,
-		log:    log,
-		secret: cli.Secret,
+		keeper:  keeper,
+		store:   store,
+		ctx:     ctx,
+		client:  client,
+		sync:    sync,
+		log:     log,
+		secret:  cli.Secret,
+		keyfile: cli.Keyfile,
+		kdf:     cli.KDF,
 	}); err != nil {
 		log.Errorf("command: %s", err)
+		store.Close()
+		os.Exit(exitCode(err))
 	}
 }
+
+// Коды завершения клиента. Ошибки разбора командной строки завершаются кодом kong.
+const (
+	exitError       = 1
+	exitNotFound    = 3
+	exitWrongSecret = 4
+	exitConflict    = 5
+	exitAuditIssues = 6
+)
+
+// exitCode возвращает код завершения по ошибке команды, в т.ч. полученной от сервера.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, vault.ErrMetaNotExists), errors.Is(err, vault.ErrObjectNotExists), errors.Is(err, vault.ErrNoRevision), status.Code(err) == codes.NotFound:
+		return exitNotFound
+	case errors.Is(err, vault.ErrWrongSecret), errors.Is(err, vault.ErrKeyfileRequired), errors.Is(err, vault.ErrKeyfileNotUsed):
+		return exitWrongSecret
+	case errors.Is(err, vault.ErrConflictVersion), errors.Is(err, vault.ErrOutdatedVersion), errors.Is(err, vault.ErrDuplicate), errors.Is(err, vault.ErrVaultMismatch), errors.Is(err, vault.ErrVaultRekeyed), status.Code(err) == codes.AlreadyExists, status.Code(err) == codes.Aborted:
+		return exitConflict
+	case errors.Is(err, errAuditIssues):
+		return exitAuditIssues
+	}
+	return exitError
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/k1nky/gophkeeper/internal/entity/vault"
+	"gopkg.in/yaml.v3"
+)
+
+// Форматы вывода команд.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputRaw   = "raw"
+)
+
+var errBinaryData = errors.New("secret data is binary, use --output raw or --out")
+
+// metaView мета-данные секрета для машиночитаемого вывода.
+type metaView struct {
+	ID         vault.MetaID      `json:"id" yaml:"id"`
+	Alias      string            `json:"alias" yaml:"alias"`
+	Type       string            `json:"type" yaml:"type"`
+	Revision   int64             `json:"revision" yaml:"revision"`
+	Version    vault.Version     `json:"version,omitempty" yaml:"version,omitempty"`
+	IsDeleted  bool              `json:"deleted,omitempty" yaml:"deleted,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+	Tags       []string          `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Folder     string            `json:"folder,omitempty" yaml:"folder,omitempty"`
+	ExpiresAt  string            `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+	IsExpired  bool              `json:"expired,omitempty" yaml:"expired,omitempty"`
+}
+
+// secretView секрет для машиночитаемого вывода.
+type secretView struct {
+	metaView `yaml:",inline"`
+	// Поля данных секрета
+	Fields map[string]string `json:"fields,omitempty" yaml:"fields,omitempty"`
+	// Пользовательские поля секрета
+	CustomFields vault.CustomFields `json:"custom_fields,omitempty" yaml:"custom_fields,omitempty"`
+	// Данные секрета, у которого нет полей (например, текста), или раскрытые данные
+	Data string `json:"data,omitempty" yaml:"data,omitempty"`
+}
+
+func newMetaView(m vault.Meta) metaView {
+	v := metaView{
+		ID:         m.ID,
+		Alias:      m.Alias,
+		Type:       m.Type.String(),
+		Revision:   m.Revision,
+		Version:    m.Version,
+		IsDeleted:  m.IsDeleted,
+		Attributes: m.Attributes,
+		Tags:       m.Tags,
+		Folder:     m.Folder,
+		IsExpired:  m.IsExpiredAt(time.Now()),
+	}
+	if m.HasExpiry() {
+		v.ExpiresAt = m.Expires().Format(time.RFC3339)
+	}
+	return v
+}
+
+// formatExpires возвращает срок действия секрета для вывода в таблице.
+func formatExpires(m vault.Meta, now time.Time) string {
+	if !m.HasExpiry() {
+		return ""
+	}
+	s := m.Expires().Format(time.RFC3339)
+	if m.IsExpiredAt(now) {
+		s += " (expired)"
+	}
+	return s
+}
+
+// newSecretView возвращает представление секрета d. Чувствительные поля и значения скрытых пользовательских полей
+// скрываются так же, как в выводе sh по умолчанию, и выводятся полностью только при reveal. Значение отдельного
+// поля без маски выводит sh --field.
+func newSecretView(d *decryptedSecret, reveal bool) (secretView, error) {
+	v := secretView{
+		metaView:     newMetaView(*d.meta),
+		CustomFields: d.fields.Masked(reveal),
+	}
+	field := d.secret.Field
+	if m, ok := d.secret.(vault.Masker); ok && !reveal {
+		field = m.MaskedField
+	}
+	names := d.secret.Fields()
+	if len(names) != 0 {
+		v.Fields = make(map[string]string, len(names))
+		for _, name := range names {
+			value, err := field(name)
+			if err != nil {
+				return v, err
+			}
+			v.Fields[name] = value
+		}
+	}
+	var render func(io.Writer) error
+	if r, ok := d.secret.(vault.Revealer); ok && reveal {
+		render = r.Reveal
+	} else if len(names) == 0 {
+		render = d.secret.Render
+	}
+	if render == nil {
+		return v, nil
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := render(buf); err != nil {
+		return v, err
+	}
+	if !utf8.Valid(buf.Bytes()) {
+		return v, errBinaryData
+	}
+	v.Data = buf.String()
+	return v, nil
+}
+
+// Проблемы паролей, которые находит аудит.
+const (
+	issueBreached = "breached"
+	issueWeak     = "weak"
+	issueReused   = "reused"
+	issueStale    = "stale"
+)
+
+// auditReport отчет аудита паролей.
+type auditReport struct {
+	// Количество проверенных секретов
+	Checked  int            `json:"checked" yaml:"checked"`
+	Findings []auditFinding `json:"findings" yaml:"findings"`
+}
+
+// auditFinding проблема пароля секрета, найденная аудитом.
+type auditFinding struct {
+	ID    vault.MetaID `json:"id" yaml:"id"`
+	Alias string       `json:"alias" yaml:"alias"`
+	Issue string       `json:"issue" yaml:"issue"`
+	// Количество утечек, в которых встречался пароль
+	Count int `json:"count,omitempty" yaml:"count,omitempty"`
+	// Оценка энтропии слабого пароля в битах
+	Entropy float64 `json:"entropy,omitempty" yaml:"entropy,omitempty"`
+	Detail  string  `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+// writeReport выводит отчет аудита в w в формате format.
+func writeReport(w io.Writer, format string, report auditReport) error {
+	if format == outputJSON || format == outputYAML {
+		return writeStructured(w, format, report)
+	}
+	if len(report.Findings) != 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ALIAS\tISSUE\tDETAIL")
+		for _, f := range report.Findings {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", f.Alias, f.Issue, f.Detail)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	_, err := fmt.Fprintf(w, "%d secrets checked, %d issues found\n", report.Checked, len(report.Findings))
+	return err
+}
+
+// writeStructured выводит v в w в формате format (json или yaml).
+func writeStructured(w io.Writer, format string, v interface{}) error {
+	if format == outputYAML {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeList выводит список мета-данных секретов в w в формате format.
+func writeList(w io.Writer, format string, list vault.List) error {
+	switch format {
+	case outputRaw:
+		_, err := io.WriteString(w, list.String())
+		return err
+	case outputJSON, outputYAML:
+		views := make([]metaView, 0, len(list))
+		for _, m := range list {
+			views = append(views, newMetaView(m))
+		}
+		return writeStructured(w, format, views)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tALIAS\tTYPE\tREVISION\tFOLDER\tTAGS\tEXPIRES\tATTRIBUTES")
+	now := time.Now()
+	for _, m := range list {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n", m.ID, m.Alias, m.Type, m.Revision, m.Folder, strings.Join(m.Tags, ","), formatExpires(m, now), formatAttributes(m.Attributes))
+	}
+	return tw.Flush()
+}
+
+// writeHistory выводит версии секрета в w в формате format. Первой в списке должна быть текущая версия.
+func writeHistory(w io.Writer, format string, list vault.List) error {
+	if format != outputTable {
+		return writeList(w, format, list)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REVISION\tUPDATED\tALIAS\tTYPE\tCURRENT")
+	for i, m := range list {
+		current := ""
+		if i == 0 {
+			current = "*"
+		}
+		updated := vault.RevisionTime(m.Revision).Format(time.RFC3339)
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", m.Revision, updated, m.Alias, m.Type, current)
+	}
+	return tw.Flush()
+}
+
+// writeTree выводит список мета-данных секретов в w в виде дерева папок. Папки выводятся перед секретами,
+// и те и другие упорядочены по имени.
+func writeTree(w io.Writer, list vault.List) error {
+	type node struct {
+		folders map[string]*node
+		secrets vault.List
+	}
+	root := &node{folders: map[string]*node{}}
+	for _, m := range list {
+		n := root
+		if folder := vault.NormalizeFolder(m.Folder); len(folder) != 0 {
+			for _, name := range strings.Split(folder, "/") {
+				child, ok := n.folders[name]
+				if !ok {
+					child = &node{folders: map[string]*node{}}
+					n.folders[name] = child
+				}
+				n = child
+			}
+		}
+		n.secrets = append(n.secrets, m)
+	}
+	var walk func(n *node, indent string) error
+	walk = func(n *node, indent string) error {
+		names := make([]string, 0, len(n.folders))
+		for name := range n.folders {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, err := fmt.Fprintf(w, "%s%s/\n", indent, name); err != nil {
+				return err
+			}
+			if err := walk(n.folders[name], indent+"  "); err != nil {
+				return err
+			}
+		}
+		sort.Slice(n.secrets, func(i, j int) bool {
+			return n.secrets[i].Alias < n.secrets[j].Alias
+		})
+		for _, m := range n.secrets {
+			line := fmt.Sprintf("%s%s (%s)", indent, m.Alias, m.Type)
+			if len(m.Tags) != 0 {
+				line += " [" + strings.Join(m.Tags, ", ") + "]"
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(root, "")
+}
+
+// formatAttributes возвращает атрибуты в виде k=v через пробел, упорядоченные по имени.
+func formatAttributes(attrs map[string]string) string {
+	pairs := make([]string, 0, len(attrs))
+	for k, v := range attrs {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
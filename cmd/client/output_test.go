@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/k1nky/gophkeeper/internal/entity/vault"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretViewMasked(t *testing.T) {
+	card := &vault.CreditCard{Number: "4111111111111111", Holder: "IVAN IVANOV", CVV: "987", Expiration: "12/30"}
+	d := &decryptedSecret{
+		meta:   &vault.Meta{ID: "id", Alias: "card", Type: vault.TypeCreditCard},
+		secret: card,
+		fields: vault.CustomFields{
+			{Name: "pin", Type: vault.FieldHidden, Value: "4321"},
+			{Name: "bank", Type: vault.FieldText, Value: "Example Bank"},
+		},
+	}
+	for _, format := range []string{outputJSON, outputYAML} {
+		// без --reveal номер, CVV и скрытые поля не выводятся
+		v, err := newSecretView(d, false)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, writeStructured(&buf, format, v))
+		assert.NotContains(t, buf.String(), card.Number, format)
+		assert.NotContains(t, buf.String(), card.CVV, format)
+		assert.NotContains(t, buf.String(), "4321", format)
+		assert.Contains(t, buf.String(), "**** 1111", format)
+		assert.Contains(t, buf.String(), "Example Bank", format)
+
+		v, err = newSecretView(d, true)
+		assert.NoError(t, err)
+		buf.Reset()
+		assert.NoError(t, writeStructured(&buf, format, v))
+		assert.Contains(t, buf.String(), card.Number, format)
+		assert.Contains(t, buf.String(), card.CVV, format)
+		assert.Contains(t, buf.String(), "4321", format)
+	}
+	// маскирование не меняет данные секрета, отдельное поле по-прежнему выводится полностью
+	value, err := d.field("number")
+	assert.NoError(t, err)
+	assert.Equal(t, card.Number, value)
+	value, err = d.field("pin")
+	assert.NoError(t, err)
+	assert.Equal(t, "4321", value)
+}
@@ -1,9 +1,14 @@
 package main
 
+import "time"
+
 var cli struct {
 	Debug          bool   `optional:"" name:"debug" env:"DEBUG" help:"Enable debug mode."`
 	MetaStoreDSN   string `optional:"" name:"meta-store-dsn" env:"META_STORE_DSN" default:"/tmp/server-meta.db"`
 	ObjectStoreDSN string `optional:"" name:"object-store-dsn" env:"OBJECT_STORE_DSN" default:"/tmp/server-vault"`
 	Secret         string `optional:"" name:"secret" env:"SECRET"`
 	Listen         string `optional:"" name:"listen" env:"LISTEN" default:":8080"`
+	Retention      int    `optional:"" name:"history-retention" env:"HISTORY_RETENTION" default:"10" help:"Number of previous revisions kept for each secret, 0 disables history."`
+	// период проверки секретов с истекшим сроком действия
+	ExpiryInterval time.Duration `optional:"" name:"expiry-check-interval" env:"EXPIRY_CHECK_INTERVAL" default:"1h" help:"How often to mark expired secrets, 0 disables the check."`
 }
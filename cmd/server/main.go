@@ -39,6 +39,27 @@ func newMux(grpcServer *grpc.Server, httpServer http.Handler) http.Handler {
 	})
 }
 
+// watchExpired помечает секреты с истекшим сроком действия при запуске и далее каждые interval до отмены ctx.
+func watchExpired(ctx context.Context, k *keeper.Service, interval time.Duration, l *logger.Logger) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if marked, err := k.MarkExpiredSecrets(ctx, time.Now()); err != nil {
+			l.Errorf("mark expired secrets: %v", err)
+		} else if len(marked) != 0 {
+			l.Infof("marked %d expired secrets", len(marked))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 func newGRPCServer(auth *auth.Service, l *logger.Logger) *grpc.Server {
 	unaryInterceptors := []grpc.UnaryServerInterceptor{
 		grpchandler.LoggerUnaryInterceptor(l),
@@ -69,8 +90,12 @@ func main() {
 
 	}
 	defer store.Close()
+	store.SetRetention(cli.Retention)
 	auth := auth.New(cli.Secret, time.Hour*24, store, log)
 	keeper := keeper.New(store, log)
+	// сервер не изменяет секреты сам, поэтому не добавляет свою запись в векторы версий
+	keeper.SetDeviceID("")
+	go watchExpired(ctx, keeper, cli.ExpiryInterval, log)
 	hh := httphandler.New(auth, log)
 	gh := grpchandler.New(auth, keeper, log)
 	grpcServer := newGRPCServer(auth, log)
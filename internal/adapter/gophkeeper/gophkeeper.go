@@ -12,13 +12,16 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/k1nky/gophkeeper/internal/crypto"
 	"github.com/k1nky/gophkeeper/internal/entity/user"
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
 	pb "github.com/k1nky/gophkeeper/internal/protocol/proto"
 	"github.com/k1nky/gophkeeper/internal/protocol/rest"
 	"github.com/k1nky/gophkeeper/internal/service/auth"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -44,26 +47,85 @@ func New(endpoint string, path string) *Adapter {
 
 func NewPBMeta(m vault.Meta) *pb.Meta {
 	return &pb.Meta{
-		Id:        string(m.ID),
-		Extra:     m.Extra,
-		Alias:     m.Alias,
-		Type:      int32(m.Type),
-		Revision:  m.Revision,
-		IsDeleted: m.IsDeleted,
+		Id:         string(m.ID),
+		Extra:      m.Extra,
+		Alias:      m.Alias,
+		Type:       int32(m.Type),
+		Revision:   m.Revision,
+		IsDeleted:  m.IsDeleted,
+		WrappedKey: m.WrappedKey,
+		Sealed:     m.Sealed,
+		Attributes: m.Attributes,
+		Tags:       m.Tags,
+		Folder:     m.Folder,
+		ExpiresAt:  m.ExpiresAt,
+		IsExpired:  m.IsExpired,
+		Version:    m.Version,
 	}
 }
 
 func NewMeta(pbm *pb.Meta) *vault.Meta {
 	return &vault.Meta{
-		Alias:     pbm.Alias,
-		Extra:     pbm.Extra,
-		ID:        vault.MetaID(pbm.Id),
-		Type:      vault.SecretType(pbm.Type),
-		Revision:  pbm.Revision,
-		IsDeleted: pbm.IsDeleted,
+		Alias:      pbm.Alias,
+		Extra:      pbm.Extra,
+		ID:         vault.MetaID(pbm.Id),
+		Type:       vault.SecretType(pbm.Type),
+		Revision:   pbm.Revision,
+		IsDeleted:  pbm.IsDeleted,
+		WrappedKey: pbm.WrappedKey,
+		Sealed:     pbm.Sealed,
+		Attributes: pbm.Attributes,
+		Tags:       pbm.Tags,
+		Folder:     pbm.Folder,
+		ExpiresAt:  pbm.ExpiresAt,
+		IsExpired:  pbm.IsExpired,
+		Version:    pbm.Version,
 	}
 }
 
+func NewPBVaultHeader(h vault.Header) *pb.VaultHeader {
+	pbh := &pb.VaultHeader{
+		Kdf: &pb.KDFParams{
+			Algorithm: h.KDF.Algorithm,
+			Salt:      h.KDF.Salt,
+			Time:      h.KDF.Time,
+			Memory:    h.KDF.Memory,
+			Threads:   uint32(h.KDF.Threads),
+			N:         int64(h.KDF.N),
+			R:         int64(h.KDF.R),
+			P:         int64(h.KDF.P),
+		},
+		Check:   h.Check,
+		Keyfile: h.Keyfile,
+	}
+	if h.Replaces != nil {
+		pbh.Previous = NewPBVaultHeader(h.Replaces.Published())
+	}
+	return pbh
+}
+
+func NewVaultHeader(pbh *pb.VaultHeader) *vault.Header {
+	kdf := pbh.GetKdf()
+	h := &vault.Header{
+		KDF: crypto.KDFParams{
+			Algorithm: kdf.GetAlgorithm(),
+			Salt:      kdf.GetSalt(),
+			Time:      kdf.GetTime(),
+			Memory:    kdf.GetMemory(),
+			Threads:   uint8(kdf.GetThreads()),
+			N:         int(kdf.GetN()),
+			R:         int(kdf.GetR()),
+			P:         int(kdf.GetP()),
+		},
+		Check:   pbh.Check,
+		Keyfile: pbh.Keyfile,
+	}
+	if pbh.Previous != nil {
+		h.Replaces = NewVaultHeader(pbh.Previous)
+	}
+	return h
+}
+
 func (a *Adapter) Open(ctx context.Context) error {
 	var retryPolicy = fmt.Sprintf(`{
 		"methodConfig": [{
@@ -129,9 +191,42 @@ func (a *Adapter) Login(ctx context.Context, username string, password string) (
 	return &claims.PrivateClaims, nil
 }
 
-func (a *Adapter) ListSecrets(ctx context.Context) (vault.List, error) {
+// ListSecrets возвращает мета-данные секретов пользователя, отобранные на сервере по фильтру filter.
+func (a *Adapter) ListSecrets(ctx context.Context, filter vault.Filter) (vault.List, error) {
 	cli := pb.NewKeeperClient(a.cc)
-	resp, err := cli.ListSecrets(ctx, &pb.ListSecretRequest{})
+	req := &pb.ListSecretRequest{Tags: filter.Tags, Folder: filter.Folder}
+	for _, t := range filter.Types {
+		req.Types = append(req.Types, int32(t))
+	}
+	resp, err := cli.ListSecrets(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	list := make(vault.List, 0)
+	for _, v := range resp.Meta {
+		list = append(list, *NewMeta(v))
+	}
+	return list, nil
+}
+
+// ListRevisions возвращает мета-данные предыдущих версий секрета id, начиная с последней.
+func (a *Adapter) ListRevisions(ctx context.Context, id vault.MetaID) (vault.List, error) {
+	cli := pb.NewKeeperClient(a.cc)
+	resp, err := cli.ListRevisions(ctx, &pb.ListRevisionsRequest{Id: string(id)})
+	if err != nil {
+		return nil, err
+	}
+	list := make(vault.List, 0)
+	for _, v := range resp.Meta {
+		list = append(list, *NewMeta(v))
+	}
+	return list, nil
+}
+
+// ListExpiring возвращает мета-данные секретов, срок действия которых истекает в течение within или уже истек.
+func (a *Adapter) ListExpiring(ctx context.Context, within time.Duration) (vault.List, error) {
+	cli := pb.NewKeeperClient(a.cc)
+	resp, err := cli.ListExpiring(ctx, &pb.ListExpiringRequest{Within: int64(within / time.Second)})
 	if err != nil {
 		return nil, err
 	}
@@ -169,9 +264,15 @@ func (a *Adapter) GetSecretMetaByAlias(ctx context.Context, alias string) (*vaul
 }
 
 func (a *Adapter) GetSecretData(ctx context.Context, id vault.MetaID, w io.Writer) error {
+	return a.GetSecretRevisionData(ctx, id, 0, w)
+}
+
+// GetSecretRevisionData записывает в w данные версии revision секрета id. Версия 0 означает текущую версию.
+func (a *Adapter) GetSecretRevisionData(ctx context.Context, id vault.MetaID, revision int64, w io.Writer) error {
 	cli := pb.NewKeeperClient(a.cc)
 	stream, err := cli.GetSecretData(ctx, &pb.GetSecretDataRequest{
-		Id: string(id),
+		Id:       string(id),
+		Revision: revision,
 	})
 	if err != nil {
 		return err
@@ -235,3 +336,30 @@ func (a *Adapter) PutSecret(ctx context.Context, meta vault.Meta, r io.Reader) (
 	}
 	return NewMeta(resp), nil
 }
+
+// GetVaultHeader возвращает заголовок хранилища, опубликованный в удаленном хранилище, или nil,
+// если заголовок еще не опубликован.
+func (a *Adapter) GetVaultHeader(ctx context.Context) (*vault.Header, error) {
+	cli := pb.NewKeeperClient(a.cc)
+	h, err := cli.GetVaultHeader(ctx, &pb.GetVaultHeaderRequest{})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return NewVaultHeader(h), nil
+}
+
+// PutVaultHeader публикует заголовок хранилища h в удаленном хранилище. Если там уже опубликован заголовок
+// другого хранилища, который h не заменяет (Header.Replaces), то возвращается vault.ErrVaultMismatch.
+func (a *Adapter) PutVaultHeader(ctx context.Context, h vault.Header) error {
+	cli := pb.NewKeeperClient(a.cc)
+	if _, err := cli.PutVaultHeader(ctx, NewPBVaultHeader(h)); err != nil {
+		if status.Code(err) == codes.FailedPrecondition {
+			return vault.ErrVaultMismatch
+		}
+		return err
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"time"
 
 	"github.com/k1nky/gophkeeper/internal/entity/user"
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
@@ -18,7 +19,13 @@ type keeperService interface {
 	GetSecretMeta(ctx context.Context, id vault.MetaID) (*vault.Meta, error)
 	GetSecretMetaByAlias(ctx context.Context, alias string) (*vault.Meta, error)
 	ListSecretsByUser(ctx context.Context) (vault.List, error)
+	ListSecretsByFilter(ctx context.Context, filter vault.Filter) (vault.List, error)
 	PutSecret(ctx context.Context, meta vault.Meta, data *vault.DataReader) (*vault.Meta, error)
+	ListSecretRevisions(ctx context.Context, metaID vault.MetaID) (vault.List, error)
+	ListExpiringSecrets(ctx context.Context, within time.Duration) (vault.List, error)
+	GetSecretRevisionData(ctx context.Context, metaID vault.MetaID, revision int64) (*vault.DataReader, error)
+	GetUserVaultHeader(ctx context.Context) (*vault.Header, error)
+	PutUserVaultHeader(ctx context.Context, h vault.Header) (*vault.Header, error)
 }
 
 type logger interface {
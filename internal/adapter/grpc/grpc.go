@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
+	"github.com/k1nky/gophkeeper/internal/crypto"
 	"github.com/k1nky/gophkeeper/internal/entity/user"
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
 	pb "github.com/k1nky/gophkeeper/internal/protocol/proto"
@@ -35,25 +37,88 @@ func New(auth authService, keeper keeperService, log logger) *Adapter {
 
 func NewPBMeta(m vault.Meta) *pb.Meta {
 	return &pb.Meta{
-		Id:        string(m.ID),
-		Extra:     m.Extra,
-		Alias:     m.Alias,
-		Type:      int32(m.Type),
-		Revision:  m.Revision,
-		IsDeleted: m.IsDeleted,
+		Id:         string(m.ID),
+		Extra:      m.Extra,
+		Alias:      m.Alias,
+		Type:       int32(m.Type),
+		Revision:   m.Revision,
+		IsDeleted:  m.IsDeleted,
+		WrappedKey: m.WrappedKey,
+		Sealed:     m.Sealed,
+		Attributes: m.Attributes,
+		Tags:       m.Tags,
+		Folder:     m.Folder,
+		ExpiresAt:  m.ExpiresAt,
+		IsExpired:  m.IsExpired,
+		Version:    m.Version,
 	}
 }
 
 func NewMeta(pbm *pb.Meta) vault.Meta {
 	return vault.Meta{
-		Alias:    pbm.Alias,
-		Extra:    pbm.Extra,
-		ID:       vault.MetaID(pbm.Id),
-		Type:     vault.SecretType(pbm.Type),
-		Revision: pbm.Revision,
+		Alias:      pbm.Alias,
+		Extra:      pbm.Extra,
+		ID:         vault.MetaID(pbm.Id),
+		Type:       vault.SecretType(pbm.Type),
+		Revision:   pbm.Revision,
+		WrappedKey: pbm.WrappedKey,
+		Sealed:     pbm.Sealed,
+		Attributes: pbm.Attributes,
+		Tags:       pbm.Tags,
+		Folder:     pbm.Folder,
+		ExpiresAt:  pbm.ExpiresAt,
+		IsExpired:  pbm.IsExpired,
+		Version:    pbm.Version,
 	}
 }
 
+// NewPBVaultHeader возвращает заголовок хранилища h для передачи по сети. Журнал смены ключа и локальные признаки
+// заголовка не передаются, кроме заменяемого заголовка, по которому сервер разрешает замену после смены ключа.
+func NewPBVaultHeader(h vault.Header) *pb.VaultHeader {
+	pbh := &pb.VaultHeader{
+		Kdf: &pb.KDFParams{
+			Algorithm: h.KDF.Algorithm,
+			Salt:      h.KDF.Salt,
+			Time:      h.KDF.Time,
+			Memory:    h.KDF.Memory,
+			Threads:   uint32(h.KDF.Threads),
+			N:         int64(h.KDF.N),
+			R:         int64(h.KDF.R),
+			P:         int64(h.KDF.P),
+		},
+		Check:   h.Check,
+		Keyfile: h.Keyfile,
+	}
+	if h.Replaces != nil {
+		pbh.Previous = NewPBVaultHeader(h.Replaces.Published())
+	}
+	return pbh
+}
+
+// NewVaultHeader возвращает заголовок хранилища, полученный по сети.
+func NewVaultHeader(pbh *pb.VaultHeader) vault.Header {
+	kdf := pbh.GetKdf()
+	h := vault.Header{
+		KDF: crypto.KDFParams{
+			Algorithm: kdf.GetAlgorithm(),
+			Salt:      kdf.GetSalt(),
+			Time:      kdf.GetTime(),
+			Memory:    kdf.GetMemory(),
+			Threads:   uint8(kdf.GetThreads()),
+			N:         int(kdf.GetN()),
+			R:         int(kdf.GetR()),
+			P:         int(kdf.GetP()),
+		},
+		Check:   pbh.Check,
+		Keyfile: pbh.Keyfile,
+	}
+	if pbh.Previous != nil {
+		previous := NewVaultHeader(pbh.Previous)
+		h.Replaces = &previous
+	}
+	return h
+}
+
 func (a *Adapter) GetSecretMeta(ctx context.Context, in *pb.GetSecretMetaRequest) (*pb.Meta, error) {
 	var (
 		m   *vault.Meta
@@ -76,11 +141,23 @@ func (a *Adapter) GetSecretMeta(ctx context.Context, in *pb.GetSecretMetaRequest
 }
 
 func (a *Adapter) GetSecretData(in *pb.GetSecretDataRequest, stream pb.Keeper_GetSecretDataServer) error {
-	reader, err := a.keeper.GetSecretData(stream.Context(), vault.MetaID(in.Id))
+	var (
+		reader *vault.DataReader
+		err    error
+	)
+	// версия 0 означает текущую версию секрета
+	if in.Revision != 0 {
+		reader, err = a.keeper.GetSecretRevisionData(stream.Context(), vault.MetaID(in.Id), in.Revision)
+	} else {
+		reader, err = a.keeper.GetSecretData(stream.Context(), vault.MetaID(in.Id))
+	}
 	if err != nil {
 		a.log.Errorf("grpc: GetSecretData: %v", err)
 		return status.Error(codes.Internal, ErrUnexpected.Error())
 	}
+	if reader == nil {
+		return status.Error(codes.NotFound, fmt.Sprintf("[%s] not found", in.Id))
+	}
 	defer reader.Close()
 
 	// отправлять данные секрета будем по частям в потоке
@@ -116,19 +193,25 @@ func (a *Adapter) PutSecret(stream pb.Keeper_PutSecretServer) error {
 	claims, _ := user.GetEffectiveUser(stream.Context())
 	meta := NewMeta(req.GetMeta())
 	meta.UserID = claims.ID
+	// принимаются только секреты типов, известных по реестру типов
+	if !meta.Type.IsKnown() {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("%s: %d", vault.ErrUnknownType, meta.Type))
+	}
 
 	// Данные секрета будут приходить частями в потоке stream.
 	// С помощью Pipe будем передавать данные также по частям в хранилище.
 	r, w := io.Pipe()
 	go func() {
-		defer w.Close()
 		for {
 			req, err := stream.Recv()
 			if err != nil {
 				if err == io.EOF {
-					break
+					w.Close()
+					return
 				}
 				a.log.Errorf("grpc: PutSecret: receiving chunk %v", err)
+				// прерываем сохранение, чтобы неполные данные не были записаны как секрет
+				w.CloseWithError(err)
 				return
 			}
 			chunk := req.GetChunkData().ChunkData
@@ -146,7 +229,11 @@ func (a *Adapter) PutSecret(stream pb.Keeper_PutSecretServer) error {
 }
 
 func (a *Adapter) ListSecrets(ctx context.Context, in *pb.ListSecretRequest) (*pb.ListSecretResponse, error) {
-	secrets, err := a.keeper.ListSecretsByUser(ctx)
+	filter := vault.Filter{Tags: in.GetTags(), Folder: in.GetFolder()}
+	for _, t := range in.GetTypes() {
+		filter.Types = append(filter.Types, vault.SecretType(t))
+	}
+	secrets, err := a.keeper.ListSecretsByFilter(ctx, filter)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
@@ -156,3 +243,53 @@ func (a *Adapter) ListSecrets(ctx context.Context, in *pb.ListSecretRequest) (*p
 	}
 	return list, nil
 }
+
+func (a *Adapter) ListExpiring(ctx context.Context, in *pb.ListExpiringRequest) (*pb.ListSecretResponse, error) {
+	secrets, err := a.keeper.ListExpiringSecrets(ctx, time.Duration(in.Within)*time.Second)
+	if err != nil {
+		a.log.Errorf("grpc: ListExpiring: %v", err)
+		return nil, status.Error(codes.Internal, ErrUnexpected.Error())
+	}
+	list := &pb.ListSecretResponse{}
+	for _, v := range secrets {
+		list.Meta = append(list.Meta, NewPBMeta(v))
+	}
+	return list, nil
+}
+
+func (a *Adapter) ListRevisions(ctx context.Context, in *pb.ListRevisionsRequest) (*pb.ListSecretResponse, error) {
+	revisions, err := a.keeper.ListSecretRevisions(ctx, vault.MetaID(in.Id))
+	if err != nil {
+		a.log.Errorf("grpc: ListRevisions: %v", err)
+		return nil, status.Error(codes.Internal, ErrUnexpected.Error())
+	}
+	list := &pb.ListSecretResponse{}
+	for _, v := range revisions {
+		list.Meta = append(list.Meta, NewPBMeta(v))
+	}
+	return list, nil
+}
+
+func (a *Adapter) GetVaultHeader(ctx context.Context, in *pb.GetVaultHeaderRequest) (*pb.VaultHeader, error) {
+	h, err := a.keeper.GetUserVaultHeader(ctx)
+	if err != nil {
+		a.log.Errorf("grpc: GetVaultHeader: %v", err)
+		return nil, status.Error(codes.Internal, ErrUnexpected.Error())
+	}
+	if h == nil {
+		return nil, status.Error(codes.NotFound, "vault header not found")
+	}
+	return NewPBVaultHeader(*h), nil
+}
+
+func (a *Adapter) PutVaultHeader(ctx context.Context, in *pb.VaultHeader) (*pb.VaultHeader, error) {
+	h, err := a.keeper.PutUserVaultHeader(ctx, NewVaultHeader(in))
+	if err != nil {
+		if errors.Is(err, vault.ErrVaultMismatch) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		a.log.Errorf("grpc: PutVaultHeader: %v", err)
+		return nil, status.Error(codes.Internal, ErrUnexpected.Error())
+	}
+	return NewPBVaultHeader(*h), nil
+}
@@ -10,10 +10,13 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 
 	"github.com/k1nky/gophkeeper/internal/adapter/grpc/mock"
+	"github.com/k1nky/gophkeeper/internal/crypto"
 	"github.com/k1nky/gophkeeper/internal/entity/user"
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
 	log "github.com/k1nky/gophkeeper/internal/logger"
@@ -77,9 +80,13 @@ func (suite *adapterTestSuite) TestListSecrets() {
 			Extra:  "extra data",
 		},
 	}
-	suite.keeper.EXPECT().ListSecretsByUser(gomock.Any()).Return(expected, nil)
+	filter := vault.Filter{Tags: []string{"work"}, Folder: "banks", Types: []vault.SecretType{vault.TypeCreditCard}}
+	suite.keeper.EXPECT().ListSecretsByFilter(gomock.Any(), filter).Return(expected, nil)
 	resp, err := client.ListSecrets(ctx, &pb.ListSecretRequest{
 		UserId: 1,
+		Tags:   []string{"work"},
+		Folder: "banks",
+		Types:  []int32{int32(vault.TypeCreditCard)},
 	})
 	suite.NoError(err)
 	got := vault.List{}
@@ -147,3 +154,128 @@ func (suite *adapterTestSuite) TestPutSecret() {
 	suite.NoError(err)
 	suite.Equal(expected.Extra, resp.Extra)
 }
+
+func (suite *adapterTestSuite) TestGetSecretMeta() {
+	ctx := user.NewContextWithClaims(context.Background(), user.PrivateClaims{
+		ID:    1,
+		Login: "u",
+	})
+	conn, err := grpc.DialContext(ctx, "buffer", grpc.WithContextDialer(suite.dial), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		suite.FailNow(err.Error())
+		return
+	}
+	defer conn.Close()
+	client := pb.NewKeeperClient(conn)
+	expected := vault.Meta{
+		ID:         vault.NewMetaID(),
+		Alias:      "alias",
+		Type:       vault.TypeLoginPassword,
+		Revision:   vault.NewRevision(),
+		WrappedKey: []byte("wrapped data key"),
+		Attributes: map[string]string{vault.AttrSubject: "CN=example.com"},
+	}
+	suite.keeper.EXPECT().GetSecretMeta(gomock.Any(), expected.ID).Return(&expected, nil)
+	resp, err := client.GetSecretMeta(ctx, &pb.GetSecretMetaRequest{
+		Key: &pb.GetSecretMetaRequest_Id{Id: string(expected.ID)},
+	})
+	suite.NoError(err)
+	suite.Equal(expected, NewMeta(resp))
+}
+
+func (suite *adapterTestSuite) TestGetSecretMetaBySealedAlias() {
+	ctx := user.NewContextWithClaims(context.Background(), user.PrivateClaims{
+		ID:    1,
+		Login: "u",
+	})
+	conn, err := grpc.DialContext(ctx, "buffer", grpc.WithContextDialer(suite.dial), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		suite.FailNow(err.Error())
+		return
+	}
+	defer conn.Close()
+	client := pb.NewKeeperClient(conn)
+	// сервер хранит только слепой индекс псевдонима и зашифрованные мета-данные
+	expected := vault.Meta{
+		ID:       vault.NewMetaID(),
+		Alias:    "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0",
+		Type:     vault.TypeText,
+		Revision: vault.NewRevision(),
+		Sealed:   []byte("sealed alias and extra"),
+	}
+	suite.keeper.EXPECT().GetSecretMetaByAlias(gomock.Any(), expected.Alias).Return(&expected, nil)
+	resp, err := client.GetSecretMeta(ctx, &pb.GetSecretMetaRequest{
+		Key: &pb.GetSecretMetaRequest_Alias{Alias: expected.Alias},
+	})
+	suite.NoError(err)
+	suite.Equal(expected, NewMeta(resp))
+}
+
+func (suite *adapterTestSuite) TestPutSecretUnknownType() {
+	ctx := user.NewContextWithClaims(context.Background(), user.PrivateClaims{
+		ID:    1,
+		Login: "u",
+	})
+	conn, err := grpc.DialContext(ctx, "buffer", grpc.WithContextDialer(suite.dial), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		suite.FailNow(err.Error())
+		return
+	}
+	defer conn.Close()
+	client := pb.NewKeeperClient(conn)
+	stream, err := client.PutSecret(ctx)
+	suite.NoError(err)
+	err = stream.Send(&pb.PutSecretRequest{
+		Data: &pb.PutSecretRequest_Meta{
+			Meta: &pb.Meta{
+				Id:   string(vault.NewMetaID()),
+				Type: 1000,
+			},
+		},
+	})
+	suite.NoError(err)
+	_, err = stream.CloseAndRecv()
+	suite.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (suite *adapterTestSuite) TestVaultHeader() {
+	ctx := user.NewContextWithClaims(context.Background(), user.PrivateClaims{
+		ID:    1,
+		Login: "u",
+	})
+	conn, err := grpc.DialContext(ctx, "buffer", grpc.WithContextDialer(suite.dial), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		suite.FailNow(err.Error())
+		return
+	}
+	defer conn.Close()
+	client := pb.NewKeeperClient(conn)
+	expected := vault.Header{
+		KDF:   crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 3, Memory: 64 * 1024, Threads: 4},
+		Check: []byte("check"),
+	}
+
+	suite.keeper.EXPECT().GetUserVaultHeader(gomock.Any()).Return(nil, nil)
+	_, err = client.GetVaultHeader(ctx, &pb.GetVaultHeaderRequest{})
+	suite.Equal(codes.NotFound, status.Code(err))
+
+	suite.keeper.EXPECT().PutUserVaultHeader(gomock.Any(), expected).Return(&expected, nil)
+	resp, err := client.PutVaultHeader(ctx, NewPBVaultHeader(expected))
+	suite.NoError(err)
+	suite.Equal(expected, NewVaultHeader(resp))
+
+	suite.keeper.EXPECT().GetUserVaultHeader(gomock.Any()).Return(&expected, nil)
+	resp, err = client.GetVaultHeader(ctx, &pb.GetVaultHeaderRequest{})
+	suite.NoError(err)
+	suite.Equal(expected, NewVaultHeader(resp))
+
+	// после смены ключа передается и заменяемый заголовок
+	rekeyed := vault.Header{KDF: expected.KDF, Check: []byte("new check"), Replaces: &expected}
+	suite.keeper.EXPECT().PutUserVaultHeader(gomock.Any(), rekeyed).Return(&vault.Header{KDF: rekeyed.KDF, Check: rekeyed.Check}, nil)
+	_, err = client.PutVaultHeader(ctx, NewPBVaultHeader(rekeyed))
+	suite.NoError(err)
+
+	suite.keeper.EXPECT().PutUserVaultHeader(gomock.Any(), gomock.Any()).Return(nil, vault.ErrVaultMismatch)
+	_, err = client.PutVaultHeader(ctx, NewPBVaultHeader(expected))
+	suite.Equal(codes.FailedPrecondition, status.Code(err))
+}
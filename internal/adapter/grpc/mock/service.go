@@ -7,6 +7,7 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	user "github.com/k1nky/gophkeeper/internal/entity/user"
@@ -119,6 +120,81 @@ func (mr *MockkeeperServiceMockRecorder) GetSecretMetaByAlias(ctx, alias interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretMetaByAlias", reflect.TypeOf((*MockkeeperService)(nil).GetSecretMetaByAlias), ctx, alias)
 }
 
+// GetSecretRevisionData mocks base method.
+func (m *MockkeeperService) GetSecretRevisionData(ctx context.Context, metaID vault.MetaID, revision int64) (*vault.DataReader, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecretRevisionData", ctx, metaID, revision)
+	ret0, _ := ret[0].(*vault.DataReader)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecretRevisionData indicates an expected call of GetSecretRevisionData.
+func (mr *MockkeeperServiceMockRecorder) GetSecretRevisionData(ctx, metaID, revision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretRevisionData", reflect.TypeOf((*MockkeeperService)(nil).GetSecretRevisionData), ctx, metaID, revision)
+}
+
+// GetUserVaultHeader mocks base method.
+func (m *MockkeeperService) GetUserVaultHeader(ctx context.Context) (*vault.Header, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserVaultHeader", ctx)
+	ret0, _ := ret[0].(*vault.Header)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserVaultHeader indicates an expected call of GetUserVaultHeader.
+func (mr *MockkeeperServiceMockRecorder) GetUserVaultHeader(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserVaultHeader", reflect.TypeOf((*MockkeeperService)(nil).GetUserVaultHeader), ctx)
+}
+
+// ListExpiringSecrets mocks base method.
+func (m *MockkeeperService) ListExpiringSecrets(ctx context.Context, within time.Duration) (vault.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpiringSecrets", ctx, within)
+	ret0, _ := ret[0].(vault.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpiringSecrets indicates an expected call of ListExpiringSecrets.
+func (mr *MockkeeperServiceMockRecorder) ListExpiringSecrets(ctx, within interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiringSecrets", reflect.TypeOf((*MockkeeperService)(nil).ListExpiringSecrets), ctx, within)
+}
+
+// ListSecretRevisions mocks base method.
+func (m *MockkeeperService) ListSecretRevisions(ctx context.Context, metaID vault.MetaID) (vault.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSecretRevisions", ctx, metaID)
+	ret0, _ := ret[0].(vault.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSecretRevisions indicates an expected call of ListSecretRevisions.
+func (mr *MockkeeperServiceMockRecorder) ListSecretRevisions(ctx, metaID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecretRevisions", reflect.TypeOf((*MockkeeperService)(nil).ListSecretRevisions), ctx, metaID)
+}
+
+// ListSecretsByFilter mocks base method.
+func (m *MockkeeperService) ListSecretsByFilter(ctx context.Context, filter vault.Filter) (vault.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSecretsByFilter", ctx, filter)
+	ret0, _ := ret[0].(vault.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSecretsByFilter indicates an expected call of ListSecretsByFilter.
+func (mr *MockkeeperServiceMockRecorder) ListSecretsByFilter(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecretsByFilter", reflect.TypeOf((*MockkeeperService)(nil).ListSecretsByFilter), ctx, filter)
+}
+
 // ListSecretsByUser mocks base method.
 func (m *MockkeeperService) ListSecretsByUser(ctx context.Context) (vault.List, error) {
 	m.ctrl.T.Helper()
@@ -149,6 +225,21 @@ func (mr *MockkeeperServiceMockRecorder) PutSecret(ctx, meta, data interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutSecret", reflect.TypeOf((*MockkeeperService)(nil).PutSecret), ctx, meta, data)
 }
 
+// PutUserVaultHeader mocks base method.
+func (m *MockkeeperService) PutUserVaultHeader(ctx context.Context, h vault.Header) (*vault.Header, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutUserVaultHeader", ctx, h)
+	ret0, _ := ret[0].(*vault.Header)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutUserVaultHeader indicates an expected call of PutUserVaultHeader.
+func (mr *MockkeeperServiceMockRecorder) PutUserVaultHeader(ctx, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutUserVaultHeader", reflect.TypeOf((*MockkeeperService)(nil).PutUserVaultHeader), ctx, h)
+}
+
 // Mocklogger is a mock of logger interface.
 type Mocklogger struct {
 	ctrl     *gomock.Controller
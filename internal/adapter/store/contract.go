@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/k1nky/gophkeeper/internal/entity/user"
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
@@ -25,9 +26,19 @@ type MetaStore interface {
 	NewMeta(ctx context.Context, m vault.Meta) (*vault.Meta, error)
 	GetMetaByID(ctx context.Context, metaID vault.MetaID, userID user.ID) (*vault.Meta, error)
 	GetMetaByAlias(ctx context.Context, alias string, userID user.ID) (*vault.Meta, error)
+	GetVaultHeader(ctx context.Context) (*vault.Header, error)
 	ListMetaByUser(ctx context.Context, userID user.ID) (vault.List, error)
+	ListMetaByFilter(ctx context.Context, userID user.ID, filter vault.Filter) (vault.List, error)
 	Open(ctx context.Context) (err error)
+	PutVaultHeader(ctx context.Context, h vault.Header) error
+	GetUserVaultHeader(ctx context.Context, userID user.ID) (*vault.Header, error)
+	PutUserVaultHeader(ctx context.Context, userID user.ID, h vault.Header) error
 	UpdateMeta(ctx context.Context, meta vault.Meta) (*vault.Meta, error)
+	PutMetaRevision(ctx context.Context, meta vault.Meta) error
+	ListMetaRevisions(ctx context.Context, metaID vault.MetaID, userID user.ID) (vault.List, error)
+	DeleteMetaRevision(ctx context.Context, meta vault.Meta) error
+	ListExpiredMeta(ctx context.Context, now time.Time) (vault.List, error)
+	GetDeviceID(ctx context.Context) (string, error)
 }
 
 type Store interface {
@@ -39,8 +50,20 @@ type Store interface {
 	GetSecretMetaByID(ctx context.Context, metaID vault.MetaID, userID user.ID) (*vault.Meta, error)
 	GetSecretMetaByAlias(ctx context.Context, alias string, userID user.ID) (*vault.Meta, error)
 	ListSecretsByUser(ctx context.Context, userID user.ID) (vault.List, error)
+	ListSecretsByFilter(ctx context.Context, userID user.ID, filter vault.Filter) (vault.List, error)
 	Close() error
 	DeleteSecret(ctx context.Context, meta vault.Meta) error
 	UpdateSecret(ctx context.Context, meta vault.Meta, data *vault.DataReader) (*vault.Meta, error)
 	UpdateSecretMeta(ctx context.Context, meta vault.Meta) (*vault.Meta, error)
+	GetVaultHeader(ctx context.Context) (*vault.Header, error)
+	PutVaultHeader(ctx context.Context, h vault.Header) error
+	GetUserVaultHeader(ctx context.Context, userID user.ID) (*vault.Header, error)
+	PutUserVaultHeader(ctx context.Context, userID user.ID, h vault.Header) error
+	ListSecretRevisions(ctx context.Context, metaID vault.MetaID, userID user.ID) (vault.List, error)
+	GetSecretRevisionMeta(ctx context.Context, metaID vault.MetaID, revision int64, userID user.ID) (*vault.Meta, error)
+	GetSecretRevisionData(ctx context.Context, metaID vault.MetaID, revision int64, userID user.ID) (*vault.DataReader, error)
+	UpdateSecretRevisionMeta(ctx context.Context, meta vault.Meta) error
+	DeleteSecretRevision(ctx context.Context, meta vault.Meta) error
+	ListExpiredSecrets(ctx context.Context, now time.Time) (vault.List, error)
+	GetDeviceID(ctx context.Context) (string, error)
 }
@@ -7,6 +7,7 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	user "github.com/k1nky/gophkeeper/internal/entity/user"
@@ -158,6 +159,35 @@ func (mr *MockMetaStoreMockRecorder) DeleteMeta(ctx, meta interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMeta", reflect.TypeOf((*MockMetaStore)(nil).DeleteMeta), ctx, meta)
 }
 
+// DeleteMetaRevision mocks base method.
+func (m *MockMetaStore) DeleteMetaRevision(ctx context.Context, meta vault.Meta) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMetaRevision", ctx, meta)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMetaRevision indicates an expected call of DeleteMetaRevision.
+func (mr *MockMetaStoreMockRecorder) DeleteMetaRevision(ctx, meta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMetaRevision", reflect.TypeOf((*MockMetaStore)(nil).DeleteMetaRevision), ctx, meta)
+}
+
+// GetDeviceID mocks base method.
+func (m *MockMetaStore) GetDeviceID(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceID", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceID indicates an expected call of GetDeviceID.
+func (mr *MockMetaStoreMockRecorder) GetDeviceID(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceID", reflect.TypeOf((*MockMetaStore)(nil).GetDeviceID), ctx)
+}
+
 // GetMetaByAlias mocks base method.
 func (m *MockMetaStore) GetMetaByAlias(ctx context.Context, alias string, userID user.ID) (*vault.Meta, error) {
 	m.ctrl.T.Helper()
@@ -203,6 +233,66 @@ func (mr *MockMetaStoreMockRecorder) GetUserByLogin(ctx, login interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByLogin", reflect.TypeOf((*MockMetaStore)(nil).GetUserByLogin), ctx, login)
 }
 
+// GetUserVaultHeader mocks base method.
+func (m *MockMetaStore) GetUserVaultHeader(ctx context.Context, userID user.ID) (*vault.Header, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserVaultHeader", ctx, userID)
+	ret0, _ := ret[0].(*vault.Header)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserVaultHeader indicates an expected call of GetUserVaultHeader.
+func (mr *MockMetaStoreMockRecorder) GetUserVaultHeader(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserVaultHeader", reflect.TypeOf((*MockMetaStore)(nil).GetUserVaultHeader), ctx, userID)
+}
+
+// GetVaultHeader mocks base method.
+func (m *MockMetaStore) GetVaultHeader(ctx context.Context) (*vault.Header, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVaultHeader", ctx)
+	ret0, _ := ret[0].(*vault.Header)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVaultHeader indicates an expected call of GetVaultHeader.
+func (mr *MockMetaStoreMockRecorder) GetVaultHeader(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVaultHeader", reflect.TypeOf((*MockMetaStore)(nil).GetVaultHeader), ctx)
+}
+
+// ListExpiredMeta mocks base method.
+func (m *MockMetaStore) ListExpiredMeta(ctx context.Context, now time.Time) (vault.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpiredMeta", ctx, now)
+	ret0, _ := ret[0].(vault.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpiredMeta indicates an expected call of ListExpiredMeta.
+func (mr *MockMetaStoreMockRecorder) ListExpiredMeta(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredMeta", reflect.TypeOf((*MockMetaStore)(nil).ListExpiredMeta), ctx, now)
+}
+
+// ListMetaByFilter mocks base method.
+func (m *MockMetaStore) ListMetaByFilter(ctx context.Context, userID user.ID, filter vault.Filter) (vault.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMetaByFilter", ctx, userID, filter)
+	ret0, _ := ret[0].(vault.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMetaByFilter indicates an expected call of ListMetaByFilter.
+func (mr *MockMetaStoreMockRecorder) ListMetaByFilter(ctx, userID, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMetaByFilter", reflect.TypeOf((*MockMetaStore)(nil).ListMetaByFilter), ctx, userID, filter)
+}
+
 // ListMetaByUser mocks base method.
 func (m *MockMetaStore) ListMetaByUser(ctx context.Context, userID user.ID) (vault.List, error) {
 	m.ctrl.T.Helper()
@@ -218,6 +308,21 @@ func (mr *MockMetaStoreMockRecorder) ListMetaByUser(ctx, userID interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMetaByUser", reflect.TypeOf((*MockMetaStore)(nil).ListMetaByUser), ctx, userID)
 }
 
+// ListMetaRevisions mocks base method.
+func (m *MockMetaStore) ListMetaRevisions(ctx context.Context, metaID vault.MetaID, userID user.ID) (vault.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMetaRevisions", ctx, metaID, userID)
+	ret0, _ := ret[0].(vault.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMetaRevisions indicates an expected call of ListMetaRevisions.
+func (mr *MockMetaStoreMockRecorder) ListMetaRevisions(ctx, metaID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMetaRevisions", reflect.TypeOf((*MockMetaStore)(nil).ListMetaRevisions), ctx, metaID, userID)
+}
+
 // NewMeta mocks base method.
 func (m_2 *MockMetaStore) NewMeta(ctx context.Context, m vault.Meta) (*vault.Meta, error) {
 	m_2.ctrl.T.Helper()
@@ -262,6 +367,48 @@ func (mr *MockMetaStoreMockRecorder) Open(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockMetaStore)(nil).Open), ctx)
 }
 
+// PutMetaRevision mocks base method.
+func (m *MockMetaStore) PutMetaRevision(ctx context.Context, meta vault.Meta) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutMetaRevision", ctx, meta)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutMetaRevision indicates an expected call of PutMetaRevision.
+func (mr *MockMetaStoreMockRecorder) PutMetaRevision(ctx, meta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutMetaRevision", reflect.TypeOf((*MockMetaStore)(nil).PutMetaRevision), ctx, meta)
+}
+
+// PutUserVaultHeader mocks base method.
+func (m *MockMetaStore) PutUserVaultHeader(ctx context.Context, userID user.ID, h vault.Header) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutUserVaultHeader", ctx, userID, h)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutUserVaultHeader indicates an expected call of PutUserVaultHeader.
+func (mr *MockMetaStoreMockRecorder) PutUserVaultHeader(ctx, userID, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutUserVaultHeader", reflect.TypeOf((*MockMetaStore)(nil).PutUserVaultHeader), ctx, userID, h)
+}
+
+// PutVaultHeader mocks base method.
+func (m *MockMetaStore) PutVaultHeader(ctx context.Context, h vault.Header) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutVaultHeader", ctx, h)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutVaultHeader indicates an expected call of PutVaultHeader.
+func (mr *MockMetaStoreMockRecorder) PutVaultHeader(ctx, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutVaultHeader", reflect.TypeOf((*MockMetaStore)(nil).PutVaultHeader), ctx, h)
+}
+
 // UpdateMeta mocks base method.
 func (m *MockMetaStore) UpdateMeta(ctx context.Context, meta vault.Meta) (*vault.Meta, error) {
 	m.ctrl.T.Helper()
@@ -328,6 +475,35 @@ func (mr *MockStoreMockRecorder) DeleteSecret(ctx, meta interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecret", reflect.TypeOf((*MockStore)(nil).DeleteSecret), ctx, meta)
 }
 
+// DeleteSecretRevision mocks base method.
+func (m *MockStore) DeleteSecretRevision(ctx context.Context, meta vault.Meta) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSecretRevision", ctx, meta)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSecretRevision indicates an expected call of DeleteSecretRevision.
+func (mr *MockStoreMockRecorder) DeleteSecretRevision(ctx, meta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecretRevision", reflect.TypeOf((*MockStore)(nil).DeleteSecretRevision), ctx, meta)
+}
+
+// GetDeviceID mocks base method.
+func (m *MockStore) GetDeviceID(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceID", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceID indicates an expected call of GetDeviceID.
+func (mr *MockStoreMockRecorder) GetDeviceID(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceID", reflect.TypeOf((*MockStore)(nil).GetDeviceID), ctx)
+}
+
 // GetSecretData mocks base method.
 func (m *MockStore) GetSecretData(ctx context.Context, metaID vault.MetaID, userID user.ID) (*vault.DataReader, error) {
 	m.ctrl.T.Helper()
@@ -373,6 +549,36 @@ func (mr *MockStoreMockRecorder) GetSecretMetaByID(ctx, metaID, userID interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretMetaByID", reflect.TypeOf((*MockStore)(nil).GetSecretMetaByID), ctx, metaID, userID)
 }
 
+// GetSecretRevisionData mocks base method.
+func (m *MockStore) GetSecretRevisionData(ctx context.Context, metaID vault.MetaID, revision int64, userID user.ID) (*vault.DataReader, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecretRevisionData", ctx, metaID, revision, userID)
+	ret0, _ := ret[0].(*vault.DataReader)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecretRevisionData indicates an expected call of GetSecretRevisionData.
+func (mr *MockStoreMockRecorder) GetSecretRevisionData(ctx, metaID, revision, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretRevisionData", reflect.TypeOf((*MockStore)(nil).GetSecretRevisionData), ctx, metaID, revision, userID)
+}
+
+// GetSecretRevisionMeta mocks base method.
+func (m *MockStore) GetSecretRevisionMeta(ctx context.Context, metaID vault.MetaID, revision int64, userID user.ID) (*vault.Meta, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecretRevisionMeta", ctx, metaID, revision, userID)
+	ret0, _ := ret[0].(*vault.Meta)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecretRevisionMeta indicates an expected call of GetSecretRevisionMeta.
+func (mr *MockStoreMockRecorder) GetSecretRevisionMeta(ctx, metaID, revision, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretRevisionMeta", reflect.TypeOf((*MockStore)(nil).GetSecretRevisionMeta), ctx, metaID, revision, userID)
+}
+
 // GetUserByLogin mocks base method.
 func (m *MockStore) GetUserByLogin(ctx context.Context, login string) (*user.User, error) {
 	m.ctrl.T.Helper()
@@ -388,6 +594,81 @@ func (mr *MockStoreMockRecorder) GetUserByLogin(ctx, login interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByLogin", reflect.TypeOf((*MockStore)(nil).GetUserByLogin), ctx, login)
 }
 
+// GetUserVaultHeader mocks base method.
+func (m *MockStore) GetUserVaultHeader(ctx context.Context, userID user.ID) (*vault.Header, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserVaultHeader", ctx, userID)
+	ret0, _ := ret[0].(*vault.Header)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserVaultHeader indicates an expected call of GetUserVaultHeader.
+func (mr *MockStoreMockRecorder) GetUserVaultHeader(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserVaultHeader", reflect.TypeOf((*MockStore)(nil).GetUserVaultHeader), ctx, userID)
+}
+
+// GetVaultHeader mocks base method.
+func (m *MockStore) GetVaultHeader(ctx context.Context) (*vault.Header, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVaultHeader", ctx)
+	ret0, _ := ret[0].(*vault.Header)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVaultHeader indicates an expected call of GetVaultHeader.
+func (mr *MockStoreMockRecorder) GetVaultHeader(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVaultHeader", reflect.TypeOf((*MockStore)(nil).GetVaultHeader), ctx)
+}
+
+// ListExpiredSecrets mocks base method.
+func (m *MockStore) ListExpiredSecrets(ctx context.Context, now time.Time) (vault.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpiredSecrets", ctx, now)
+	ret0, _ := ret[0].(vault.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpiredSecrets indicates an expected call of ListExpiredSecrets.
+func (mr *MockStoreMockRecorder) ListExpiredSecrets(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredSecrets", reflect.TypeOf((*MockStore)(nil).ListExpiredSecrets), ctx, now)
+}
+
+// ListSecretRevisions mocks base method.
+func (m *MockStore) ListSecretRevisions(ctx context.Context, metaID vault.MetaID, userID user.ID) (vault.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSecretRevisions", ctx, metaID, userID)
+	ret0, _ := ret[0].(vault.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSecretRevisions indicates an expected call of ListSecretRevisions.
+func (mr *MockStoreMockRecorder) ListSecretRevisions(ctx, metaID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecretRevisions", reflect.TypeOf((*MockStore)(nil).ListSecretRevisions), ctx, metaID, userID)
+}
+
+// ListSecretsByFilter mocks base method.
+func (m *MockStore) ListSecretsByFilter(ctx context.Context, userID user.ID, filter vault.Filter) (vault.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSecretsByFilter", ctx, userID, filter)
+	ret0, _ := ret[0].(vault.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSecretsByFilter indicates an expected call of ListSecretsByFilter.
+func (mr *MockStoreMockRecorder) ListSecretsByFilter(ctx, userID, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecretsByFilter", reflect.TypeOf((*MockStore)(nil).ListSecretsByFilter), ctx, userID, filter)
+}
+
 // ListSecretsByUser mocks base method.
 func (m *MockStore) ListSecretsByUser(ctx context.Context, userID user.ID) (vault.List, error) {
 	m.ctrl.T.Helper()
@@ -447,6 +728,34 @@ func (mr *MockStoreMockRecorder) PutSecret(ctx, meta, data interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutSecret", reflect.TypeOf((*MockStore)(nil).PutSecret), ctx, meta, data)
 }
 
+// PutUserVaultHeader mocks base method.
+func (m *MockStore) PutUserVaultHeader(ctx context.Context, userID user.ID, h vault.Header) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutUserVaultHeader", ctx, userID, h)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutUserVaultHeader indicates an expected call of PutUserVaultHeader.
+func (mr *MockStoreMockRecorder) PutUserVaultHeader(ctx, userID, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutUserVaultHeader", reflect.TypeOf((*MockStore)(nil).PutUserVaultHeader), ctx, userID, h)
+}
+
+// PutVaultHeader mocks base method.
+func (m *MockStore) PutVaultHeader(ctx context.Context, h vault.Header) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutVaultHeader", ctx, h)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutVaultHeader indicates an expected call of PutVaultHeader.
+func (mr *MockStoreMockRecorder) PutVaultHeader(ctx, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutVaultHeader", reflect.TypeOf((*MockStore)(nil).PutVaultHeader), ctx, h)
+}
+
 // UpdateSecret mocks base method.
 func (m *MockStore) UpdateSecret(ctx context.Context, meta vault.Meta, data *vault.DataReader) (*vault.Meta, error) {
 	m.ctrl.T.Helper()
@@ -476,3 +785,17 @@ func (mr *MockStoreMockRecorder) UpdateSecretMeta(ctx, meta interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSecretMeta", reflect.TypeOf((*MockStore)(nil).UpdateSecretMeta), ctx, meta)
 }
+
+// UpdateSecretRevisionMeta mocks base method.
+func (m *MockStore) UpdateSecretRevisionMeta(ctx context.Context, meta vault.Meta) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSecretRevisionMeta", ctx, meta)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSecretRevisionMeta indicates an expected call of UpdateSecretRevisionMeta.
+func (mr *MockStoreMockRecorder) UpdateSecretRevisionMeta(ctx, meta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSecretRevisionMeta", reflect.TypeOf((*MockStore)(nil).UpdateSecretRevisionMeta), ctx, meta)
+}
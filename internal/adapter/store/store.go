@@ -4,16 +4,22 @@ package store
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/k1nky/gophkeeper/internal/entity/user"
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
 	"golang.org/x/sync/errgroup"
 )
 
+// DefaultRetention количество предыдущих версий секрета, которые хранятся по умолчанию.
+const DefaultRetention = 10
+
 // Adapter адаптер хранилища секретов.
 type Adapter struct {
 	mstore MetaStore
 	ostore ObjectStore
+	// количество хранимых предыдущих версий каждого секрета
+	retention int
 }
 
 var _ Store = new(Adapter)
@@ -34,11 +40,18 @@ func (a *Adapter) Close() error {
 // а ostore хранилище самой секретной информации.
 func New(mstore MetaStore, ostore ObjectStore) *Adapter {
 	return &Adapter{
-		mstore: mstore,
-		ostore: ostore,
+		mstore:    mstore,
+		ostore:    ostore,
+		retention: DefaultRetention,
 	}
 }
 
+// SetRetention задает количество хранимых предыдущих версий каждого секрета. При n <= 0 предыдущие версии
+// удаляются сразу после обновления секрета.
+func (a *Adapter) SetRetention(n int) {
+	a.retention = n
+}
+
 // GetSecretData возвращает данные секрета пользователя userID с ид metaID. Обязательно нужно следить за своевременным
 // закрытием полученных данных.
 func (a *Adapter) GetSecretData(ctx context.Context, metaID vault.MetaID, userID user.ID) (*vault.DataReader, error) {
@@ -81,11 +94,38 @@ func (a *Adapter) GetUserByLogin(ctx context.Context, login string) (*user.User,
 	return a.mstore.GetUserByLogin(ctx, login)
 }
 
+// GetVaultHeader возвращает заголовок хранилища секретов или nil, если хранилище еще не инициализировано.
+func (a *Adapter) GetVaultHeader(ctx context.Context) (*vault.Header, error) {
+	return a.mstore.GetVaultHeader(ctx)
+}
+
+// GetUserVaultHeader возвращает заголовок хранилища пользователя userID, общий для всех его устройств,
+// или nil, если заголовок еще не опубликован.
+func (a *Adapter) GetUserVaultHeader(ctx context.Context, userID user.ID) (*vault.Header, error) {
+	return a.mstore.GetUserVaultHeader(ctx, userID)
+}
+
 // ListSecretsByUser возвращает список мета-данных секретов пользователя userID.
 func (a *Adapter) ListSecretsByUser(ctx context.Context, userID user.ID) (vault.List, error) {
 	return a.mstore.ListMetaByUser(ctx, userID)
 }
 
+// ListSecretsByFilter возвращает список мета-данных секретов пользователя userID, удовлетворяющих фильтру filter.
+func (a *Adapter) ListSecretsByFilter(ctx context.Context, userID user.ID, filter vault.Filter) (vault.List, error) {
+	return a.mstore.ListMetaByFilter(ctx, userID, filter)
+}
+
+// ListExpiredSecrets возвращает мета-данные неудаленных секретов всех пользователей, срок действия которых
+// истек на момент now, но которые еще не помечены как истекшие.
+func (a *Adapter) ListExpiredSecrets(ctx context.Context, now time.Time) (vault.List, error) {
+	return a.mstore.ListExpiredMeta(ctx, now)
+}
+
+// GetDeviceID возвращает ИД устройства, на котором расположено хранилище.
+func (a *Adapter) GetDeviceID(ctx context.Context) (string, error) {
+	return a.mstore.GetDeviceID(ctx)
+}
+
 // NewUser создает нового пользователя u и возвращает указатель на него.
 func (a *Adapter) NewUser(ctx context.Context, u user.User) (*user.User, error) {
 	return a.mstore.NewUser(ctx, u)
@@ -128,6 +168,16 @@ func (a *Adapter) PutSecret(ctx context.Context, meta vault.Meta, data *vault.Da
 	return &meta, nil
 }
 
+// PutVaultHeader сохраняет заголовок хранилища секретов h.
+func (a *Adapter) PutVaultHeader(ctx context.Context, h vault.Header) error {
+	return a.mstore.PutVaultHeader(ctx, h)
+}
+
+// PutUserVaultHeader сохраняет заголовок хранилища h пользователя userID.
+func (a *Adapter) PutUserVaultHeader(ctx context.Context, userID user.ID, h vault.Header) error {
+	return a.mstore.PutUserVaultHeader(ctx, userID, h)
+}
+
 func (a *Adapter) UpdateSecretMeta(ctx context.Context, meta vault.Meta) (*vault.Meta, error) {
 	return a.mstore.UpdateMeta(ctx, meta)
 }
@@ -145,17 +195,101 @@ func (a *Adapter) UpdateSecret(ctx context.Context, meta vault.Meta, data *vault
 	if err := a.ostore.Put(ctx, meta.DataID, data); err != nil {
 		return nil, err
 	}
+	// данные той же версии перезаписаны, поэтому в историю ее не сохраняем
+	keep := a.retention > 0 && cm.DataID != meta.DataID
+	if keep {
+		if err := a.mstore.PutMetaRevision(ctx, *cm); err != nil {
+			a.ostore.Delete(ctx, meta.DataID)
+			return nil, err
+		}
+	}
 	// потом записываем мета-данные
 	if _, err := a.mstore.UpdateMeta(ctx, meta); err != nil {
 		// если их записать не удалось, то удаляем секрет
 		a.ostore.Delete(ctx, meta.DataID)
+		if keep {
+			a.mstore.DeleteMetaRevision(ctx, *cm)
+		}
 		return nil, err
 	}
-	a.ostore.Delete(ctx, cm.DataID)
+	if !keep {
+		if cm.DataID != meta.DataID {
+			a.ostore.Delete(ctx, cm.DataID)
+		}
+		return &meta, nil
+	}
+	a.pruneRevisions(ctx, meta.ID, meta.UserID)
 	return &meta, nil
 }
 
+// pruneRevisions удаляет предыдущие версии секрета metaID пользователя userID сверх количества хранимых версий.
+func (a *Adapter) pruneRevisions(ctx context.Context, metaID vault.MetaID, userID user.ID) {
+	revisions, err := a.mstore.ListMetaRevisions(ctx, metaID, userID)
+	if err != nil || len(revisions) <= a.retention {
+		return
+	}
+	for _, m := range revisions[a.retention:] {
+		a.DeleteSecretRevision(ctx, m)
+	}
+}
+
+// ListSecretRevisions возвращает мета-данные предыдущих версий секрета metaID пользователя userID, начиная с последней.
+func (a *Adapter) ListSecretRevisions(ctx context.Context, metaID vault.MetaID, userID user.ID) (vault.List, error) {
+	return a.mstore.ListMetaRevisions(ctx, metaID, userID)
+}
+
+// GetSecretRevisionMeta возвращает мета-данные версии revision секрета metaID пользователя userID,
+// в т.ч. текущей. Если такой версии нет, то возвращается nil.
+func (a *Adapter) GetSecretRevisionMeta(ctx context.Context, metaID vault.MetaID, revision int64, userID user.ID) (*vault.Meta, error) {
+	m, err := a.GetSecretMetaByID(ctx, metaID, userID)
+	if err != nil || m == nil || m.Revision == revision {
+		return m, err
+	}
+	revisions, err := a.mstore.ListMetaRevisions(ctx, metaID, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range revisions {
+		if r.Revision == revision {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetSecretRevisionData возвращает данные версии revision секрета metaID пользователя userID, в т.ч. текущей.
+// Если такой версии нет, то возвращается nil.
+func (a *Adapter) GetSecretRevisionData(ctx context.Context, metaID vault.MetaID, revision int64, userID user.ID) (*vault.DataReader, error) {
+	m, err := a.GetSecretRevisionMeta(ctx, metaID, revision, userID)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	return a.ostore.Get(ctx, m.DataID)
+}
+
+// UpdateSecretRevisionMeta обновляет мета-данные предыдущей версии секрета, например, при смене ключа хранилища.
+func (a *Adapter) UpdateSecretRevisionMeta(ctx context.Context, meta vault.Meta) error {
+	return a.mstore.PutMetaRevision(ctx, meta)
+}
+
+// DeleteSecretRevision удаляет предыдущую версию секрета вместе с ее данными.
+func (a *Adapter) DeleteSecretRevision(ctx context.Context, meta vault.Meta) error {
+	if err := a.mstore.DeleteMetaRevision(ctx, meta); err != nil {
+		return err
+	}
+	return a.ostore.Delete(ctx, meta.DataID)
+}
+
 func (a *Adapter) DeleteSecret(ctx context.Context, meta vault.Meta) error {
+	revisions, err := a.mstore.ListMetaRevisions(ctx, meta.ID, meta.UserID)
+	if err != nil {
+		return err
+	}
+	for _, m := range revisions {
+		if err := a.DeleteSecretRevision(ctx, m); err != nil {
+			return err
+		}
+	}
 	if err := a.mstore.DeleteMeta(ctx, meta); err != nil {
 		return err
 	}
@@ -165,3 +165,80 @@ func (suite *adapterTestSuite) TestGetSecretData() {
 	suite.Assert().Equal(expected, got.Bytes())
 
 }
+
+func (suite *adapterTestSuite) TestUpdateSecretKeepsRevision() {
+	ctx := context.TODO()
+	current := vault.Meta{ID: vault.NewMetaID(), UserID: 1, Revision: 10, DataID: "1-old-10"}
+	next := vault.Meta{ID: current.ID, UserID: 1, Revision: 20}
+	d := vault.NewDataReader(vault.NewBytesBuffer([]byte("new secret")))
+
+	suite.mstore.EXPECT().GetMetaByID(gomock.Any(), current.ID, current.UserID).Return(&current, nil)
+	suite.ostore.EXPECT().Put(gomock.Any(), gomock.Any(), d).Return(nil)
+	suite.mstore.EXPECT().PutMetaRevision(gomock.Any(), current).Return(nil)
+	suite.mstore.EXPECT().UpdateMeta(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mstore.EXPECT().ListMetaRevisions(gomock.Any(), current.ID, current.UserID).Return(vault.List{current}, nil)
+
+	got, err := suite.a.UpdateSecret(ctx, next, d)
+	suite.NoError(err)
+	suite.Equal(next.Revision, got.Revision)
+}
+
+func (suite *adapterTestSuite) TestUpdateSecretPrunesRevisions() {
+	ctx := context.TODO()
+	suite.a.SetRetention(1)
+	current := vault.Meta{ID: vault.NewMetaID(), UserID: 1, Revision: 30, DataID: "1-old-30"}
+	kept := vault.Meta{ID: current.ID, UserID: 1, Revision: 20, DataID: "1-old-20"}
+	pruned := vault.Meta{ID: current.ID, UserID: 1, Revision: 10, DataID: "1-old-10"}
+	d := vault.NewDataReader(vault.NewBytesBuffer([]byte("new secret")))
+
+	suite.mstore.EXPECT().GetMetaByID(gomock.Any(), gomock.Any(), gomock.Any()).Return(&current, nil)
+	suite.ostore.EXPECT().Put(gomock.Any(), gomock.Any(), d).Return(nil)
+	suite.mstore.EXPECT().PutMetaRevision(gomock.Any(), current).Return(nil)
+	suite.mstore.EXPECT().UpdateMeta(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mstore.EXPECT().ListMetaRevisions(gomock.Any(), gomock.Any(), gomock.Any()).Return(vault.List{current, kept, pruned}, nil)
+	suite.mstore.EXPECT().DeleteMetaRevision(gomock.Any(), kept).Return(nil)
+	suite.ostore.EXPECT().Delete(gomock.Any(), kept.DataID).Return(nil)
+	suite.mstore.EXPECT().DeleteMetaRevision(gomock.Any(), pruned).Return(nil)
+	suite.ostore.EXPECT().Delete(gomock.Any(), pruned.DataID).Return(nil)
+
+	_, err := suite.a.UpdateSecret(ctx, vault.Meta{ID: current.ID, UserID: 1, Revision: 40}, d)
+	suite.NoError(err)
+}
+
+func (suite *adapterTestSuite) TestUpdateSecretWithoutRetention() {
+	ctx := context.TODO()
+	suite.a.SetRetention(0)
+	current := vault.Meta{ID: vault.NewMetaID(), UserID: 1, Revision: 10, DataID: "1-old-10"}
+	d := vault.NewDataReader(vault.NewBytesBuffer([]byte("new secret")))
+
+	suite.mstore.EXPECT().GetMetaByID(gomock.Any(), gomock.Any(), gomock.Any()).Return(&current, nil)
+	suite.ostore.EXPECT().Put(gomock.Any(), gomock.Any(), d).Return(nil)
+	suite.mstore.EXPECT().UpdateMeta(gomock.Any(), gomock.Any()).Return(nil, nil)
+	// без истории данные предыдущей версии удаляются сразу
+	suite.ostore.EXPECT().Delete(gomock.Any(), current.DataID).Return(nil)
+
+	_, err := suite.a.UpdateSecret(ctx, vault.Meta{ID: current.ID, UserID: 1, Revision: 20}, d)
+	suite.NoError(err)
+}
+
+func (suite *adapterTestSuite) TestGetSecretRevisionData() {
+	ctx := context.TODO()
+	current := vault.Meta{ID: vault.NewMetaID(), UserID: 1, Revision: 20, DataID: "1-20"}
+	prev := vault.Meta{ID: current.ID, UserID: 1, Revision: 10, DataID: "1-10"}
+	expected := []byte("previous secret")
+
+	suite.mstore.EXPECT().GetMetaByID(gomock.Any(), gomock.Any(), gomock.Any()).Return(&current, nil).Times(2)
+	suite.mstore.EXPECT().ListMetaRevisions(gomock.Any(), current.ID, current.UserID).Return(vault.List{prev}, nil).Times(2)
+	suite.ostore.EXPECT().Get(gomock.Any(), prev.DataID).Return(vault.NewDataReader(vault.NewBytesBuffer(expected)), nil)
+
+	reader, err := suite.a.GetSecretRevisionData(ctx, current.ID, prev.Revision, current.UserID)
+	suite.NoError(err)
+	got := bytes.NewBuffer(nil)
+	_, err = got.ReadFrom(reader)
+	suite.NoError(err)
+	suite.Equal(expected, got.Bytes())
+
+	reader, err = suite.a.GetSecretRevisionData(ctx, current.ID, 5, current.UserID)
+	suite.NoError(err)
+	suite.Nil(reader)
+}
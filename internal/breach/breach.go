@@ -0,0 +1,216 @@
+// Package breach реализует проверку паролей по локальному списку утекших паролей в формате Have I Been Pwned.
+// Проверка не требует доступа к сети, а пароли сравниваются по хешу SHA-1.
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// длина хеша SHA-1 в шестнадцатеричном виде
+	hashLen = 2 * sha1.Size
+	// длина префикса хеша в именах файлов каталога префиксов
+	prefixLen = 5
+	// размер блока, начиная с которого сортированный список читается последовательно
+	scanBlock = 64 * 1024
+)
+
+var ErrInvalidList = errors.New("invalid breached password list")
+
+// List список утекших паролей.
+type List interface {
+	// Count возвращает, сколько раз пароль password встречался в утечках, или 0, если его нет в списке.
+	Count(password string) (int, error)
+	Close() error
+}
+
+// Open открывает список утекших паролей по пути path. Если path каталог, то он должен содержать файлы
+// с именами по первым пяти символам хеша (как ответы range API), иначе path это файл с хешами, упорядоченными
+// по возрастанию (ordered by hash). Строки списков имеют вид HASH[:COUNT].
+func Open(path string) (List, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return &PrefixDir{dir: path}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &SortedFile{f: f, size: fi.Size()}, nil
+}
+
+// Hash возвращает хеш SHA-1 пароля в шестнадцатеричном виде в верхнем регистре.
+func Hash(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// parseLine разбирает строку списка на хеш (или его часть) в верхнем регистре и количество утечек.
+func parseLine(line string) (string, int, error) {
+	line = strings.TrimSpace(line)
+	key, count, found := strings.Cut(line, ":")
+	if !found {
+		return strings.ToUpper(key), 1, nil
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %s", ErrInvalidList, line)
+	}
+	return strings.ToUpper(key), n, nil
+}
+
+// SortedFile файл с хешами, упорядоченными по возрастанию. Поиск выполняется двоичным поиском по смещениям
+// в файле, поэтому файл не загружается в память целиком.
+type SortedFile struct {
+	f    *os.File
+	size int64
+}
+
+func (s *SortedFile) Close() error {
+	return s.f.Close()
+}
+
+func (s *SortedFile) Count(password string) (int, error) {
+	target := Hash(password)
+	lo, hi := int64(0), s.size
+	// сужаем область поиска, пока она не станет достаточно маленькой для последовательного чтения.
+	// Хеш искомой строки, если она есть, находится в строке, начинающейся не раньше lineStart(lo)
+	// и не позже lineStart(hi).
+	for hi-lo > scanBlock {
+		mid := lo + (hi-lo)/2
+		start, err := s.lineStart(mid)
+		if err != nil {
+			return 0, err
+		}
+		if start >= s.size {
+			hi = mid
+			continue
+		}
+		key, _, err := s.readLine(start)
+		if err != nil {
+			return 0, err
+		}
+		if key >= target {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	start, err := s.lineStart(lo)
+	if err != nil {
+		return 0, err
+	}
+	r := bufio.NewReader(io.NewSectionReader(s.f, start, s.size-start))
+	for {
+		line, err := r.ReadString('\n')
+		if len(line) != 0 {
+			key, count, perr := parseLine(line)
+			if perr != nil {
+				return 0, perr
+			}
+			if key == target {
+				return count, nil
+			}
+			if key > target {
+				return 0, nil
+			}
+		}
+		if err == io.EOF {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// lineStart возвращает смещение первой строки, которая начинается не раньше offset.
+func (s *SortedFile) lineStart(offset int64) (int64, error) {
+	if offset == 0 {
+		return 0, nil
+	}
+	r := bufio.NewReader(io.NewSectionReader(s.f, offset-1, s.size-offset+1))
+	skipped, err := r.ReadString('\n')
+	if err == io.EOF {
+		return s.size, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return offset - 1 + int64(len(skipped)), nil
+}
+
+// readLine разбирает строку, которая начинается со смещения offset.
+func (s *SortedFile) readLine(offset int64) (string, int, error) {
+	r := bufio.NewReader(io.NewSectionReader(s.f, offset, s.size-offset))
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", 0, err
+	}
+	return parseLine(line)
+}
+
+// PrefixDir каталог файлов с хешами, сгруппированными по первым пяти символам хеша. Каждый файл содержит
+// окончания хешей, поэтому при проверке читается только один небольшой файл.
+type PrefixDir struct {
+	dir string
+}
+
+func (p *PrefixDir) Close() error {
+	return nil
+}
+
+func (p *PrefixDir) Count(password string) (int, error) {
+	hash := Hash(password)
+	prefix, suffix := hash[:prefixLen], hash[prefixLen:]
+	f, err := p.open(prefix)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		key, count, err := parseLine(sc.Text())
+		if err != nil {
+			return 0, err
+		}
+		// в файлах могут быть как окончания, так и хеши полностью
+		if key == suffix || (len(key) == hashLen && key == hash) {
+			return count, nil
+		}
+	}
+	return 0, sc.Err()
+}
+
+// open открывает файл префикса prefix. Файлы могут называться по префиксу в любом регистре, в т.ч. с расширением .txt.
+func (p *PrefixDir) open(prefix string) (*os.File, error) {
+	var err error
+	for _, name := range []string{prefix, prefix + ".txt", strings.ToLower(prefix), strings.ToLower(prefix) + ".txt"} {
+		var f *os.File
+		if f, err = os.Open(filepath.Join(p.dir, name)); err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,99 @@
+package breach
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeSorted записывает в файл упорядоченные хеши паролей passwords, количество утечек i+1.
+func writeSorted(t *testing.T, passwords []string, eol string) string {
+	lines := make([]string, 0, len(passwords))
+	for i, p := range passwords {
+		lines = append(lines, fmt.Sprintf("%s:%d", Hash(p), i+1))
+	}
+	sort.Strings(lines)
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, eol)+eol), 0600))
+	return path
+}
+
+func TestSortedFile(t *testing.T) {
+	// список должен быть больше блока последовательного чтения, чтобы работал двоичный поиск
+	passwords := make([]string, 0, 20000)
+	for i := 0; i < cap(passwords); i++ {
+		passwords = append(passwords, fmt.Sprintf("password%d", i))
+	}
+	for _, eol := range []string{"\n", "\r\n"} {
+		l, err := Open(writeSorted(t, passwords, eol))
+		if !assert.NoError(t, err) {
+			return
+		}
+		for _, i := range []int{0, 1, 777, 9999, 19998, 19999} {
+			count, err := l.Count(passwords[i])
+			assert.NoError(t, err)
+			assert.Equal(t, i+1, count, passwords[i])
+		}
+		for _, p := range []string{"", "not leaked", "password20000"} {
+			count, err := l.Count(p)
+			assert.NoError(t, err)
+			assert.Zero(t, count, p)
+		}
+		assert.NoError(t, l.Close())
+	}
+}
+
+func TestSortedFileWithoutCount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(strings.ToLower(Hash("qwerty"))+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Open(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer l.Close()
+	count, err := l.Count("qwerty")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestPrefixDir(t *testing.T) {
+	dir := t.TempDir()
+	hash := Hash("qwerty")
+	content := "0018A45C4D1DEF81644B54AB7F969B88D65:1\n" + hash[prefixLen:] + ":3946\n"
+	if err := os.WriteFile(filepath.Join(dir, hash[:prefixLen]+".txt"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Open(dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer l.Close()
+	count, err := l.Count("qwerty")
+	assert.NoError(t, err)
+	assert.Equal(t, 3946, count)
+	// файла с префиксом нет
+	count, err = l.Count("correct horse battery staple")
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestInvalidList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(Hash("qwerty")+":many\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Open(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer l.Close()
+	_, err = l.Count("qwerty")
+	assert.ErrorIs(t, err, ErrInvalidList)
+}
@@ -0,0 +1,92 @@
+package crypto
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// Compression режим сжатия данных перед шифрованием.
+type Compression int
+
+const (
+	// CompressNone данные не сжимаются.
+	CompressNone Compression = iota
+	// CompressAuto данные сжимаются, только если сжатие начала данных дает заметный выигрыш.
+	// Так пропускаются небольшие и уже сжатые данные (архивы, изображения и т.п.).
+	CompressAuto
+	// CompressAlways данные сжимаются всегда.
+	CompressAlways
+)
+
+// FlagGzip флаг заголовка зашифрованного потока: открытые данные сжаты gzip перед шифрованием.
+const FlagGzip byte = 1 << 0
+
+const (
+	// minCompressSize минимальный размер данных, которые имеет смысл сжимать
+	minCompressSize = 512
+	// maxCompressRatio максимальное отношение размера сжатых данных к исходному, при котором сжатие имеет смысл
+	maxCompressRatio = 0.9
+)
+
+// shouldCompress возвращает true, если данные, начинающиеся с sample, имеет смысл сжимать.
+func shouldCompress(sample []byte) bool {
+	if len(sample) < minCompressSize {
+		return false
+	}
+	compressed := &bytes.Buffer{}
+	w := gzip.NewWriter(compressed)
+	if _, err := w.Write(sample); err != nil {
+		return false
+	}
+	if err := w.Close(); err != nil {
+		return false
+	}
+	return float64(compressed.Len()) <= maxCompressRatio*float64(len(sample))
+}
+
+// compressReader читатель, возвращающий сжатые gzip данные другого читателя.
+type compressReader struct {
+	r   io.Reader
+	w   *gzip.Writer
+	buf bytes.Buffer
+	eof bool
+}
+
+func newCompressReader(r io.Reader) *compressReader {
+	cr := &compressReader{r: r}
+	cr.w = gzip.NewWriter(&cr.buf)
+	return cr
+}
+
+// Read сжимает очередную порцию исходных данных. После окончания исходных данных дописывается окончание потока gzip.
+func (r *compressReader) Read(p []byte) (int, error) {
+	src := make([]byte, SegmentSize)
+	for r.buf.Len() == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		n, err := r.r.Read(src)
+		if n > 0 {
+			if _, err := r.w.Write(src[:n]); err != nil {
+				return 0, err
+			}
+		}
+		if err == io.EOF {
+			r.eof = true
+			if err := r.w.Close(); err != nil {
+				return 0, err
+			}
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	return r.buf.Read(p)
+}
+
+// readerFunc адаптер функции к интерфейсу io.Reader.
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,67 @@
+package crypto
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encryptWithCompression(t *testing.T, secret string, data []byte, compression Compression) []byte {
+	enc, err := NewEncryptReaderWithCompression(testKey(secret), bytes.NewReader(data), compression)
+	assert.NoError(t, err)
+	ciphertext, err := io.ReadAll(enc)
+	assert.NoError(t, err)
+	return ciphertext
+}
+
+func TestEncryptCompressed(t *testing.T) {
+	text := []byte(strings.Repeat("listen_addresses = 'localhost'\nmax_connections = 100\n", 10000))
+	cases := []struct {
+		name        string
+		data        []byte
+		compression Compression
+		compressed  bool
+	}{
+		{name: "text auto", data: text, compression: CompressAuto, compressed: true},
+		{name: "text always", data: text, compression: CompressAlways, compressed: true},
+		{name: "text none", data: text, compression: CompressNone, compressed: false},
+		// уже сжатые (случайные) данные не сжимаются
+		{name: "random auto", data: generateRandom(3 * SegmentSize), compression: CompressAuto, compressed: false},
+		// слишком маленькие данные не сжимаются
+		{name: "small auto", data: text[:100], compression: CompressAuto, compressed: false},
+		{name: "empty always", data: nil, compression: CompressAlways, compressed: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext := encryptWithCompression(t, "secret", tt.data, tt.compression)
+			h, err := parseHeader(ciphertext[:headerFieldsSize])
+			assert.NoError(t, err)
+			assert.Equal(t, tt.compressed, h.flags&FlagGzip != 0)
+			if tt.compressed && len(tt.data) > 0 {
+				assert.Less(t, len(ciphertext), len(tt.data)/10)
+			}
+			plaintext, err := decrypt(t, "secret", ciphertext)
+			assert.NoError(t, err)
+			assert.Equal(t, len(tt.data), len(plaintext))
+			assert.True(t, bytes.Equal(tt.data, plaintext))
+		})
+	}
+}
+
+func TestDecryptCompressedTampered(t *testing.T) {
+	text := []byte(strings.Repeat("some text ", 100000))
+	ciphertext := encryptWithCompression(t, "secret", text, CompressAlways)
+	ciphertext[HeaderSize+10] ^= 0x01
+	_, err := decrypt(t, "secret", ciphertext)
+	assert.ErrorIs(t, err, ErrTampered)
+}
+
+func TestDecryptUnknownFlags(t *testing.T) {
+	ciphertext := encrypt(t, "secret", generateRandom(100))
+	ciphertext[len(magic)+1] |= 0x80
+	_, err := decrypt(t, "secret", ciphertext)
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+}
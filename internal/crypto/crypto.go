@@ -1,132 +1,428 @@
-// Пакет crypto содержит читателей для работы с AES-CBC.
+// Пакет crypto содержит читателей для потокового шифрования и расшифровки данных секретов.
+//
+// Формат зашифрованного потока (версия 2):
+//
+//	заголовок: magic "GKSE" | версия (1 байт) | флаги (1 байт) | размер сегмента (4 байта) | случайный префикс nonce (19 байт)
+//	тег:       XChaCha20-Poly1305(ключ, nonce = префикс | 0 (4 байта) | 2, пусто, заголовок)
+//	сегменты:  XChaCha20-Poly1305(ключ, nonce = префикс | номер сегмента (4 байта) | признак последнего сегмента (1 байт), данные, заголовок)
+//
+// Каждый сегмент аутентифицируется отдельно, а номер сегмента и признак последнего сегмента входят в nonce,
+// поэтому изменение, перестановка или отбрасывание сегментов обнаруживаются при расшифровке.
+// Тег заголовка позволяет до расшифровки данных убедиться, что поток зашифрован этим ключом.
+// Потоки версии 1 отличаются только отсутствием тега заголовка.
+// Флаг FlagGzip означает, что открытые данные были сжаты перед шифрованием, при расшифровке они распаковываются.
+// Данные, зашифрованные ранее в режиме AES-CBC, расшифровываются через устаревший (legacy) путь.
 package crypto
 
 import (
-	"crypto/aes"
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"crypto/cipher"
-	"crypto/sha256"
+	"crypto/rand"
+	"encoding/binary"
 	"io"
+	"runtime"
 
-	"github.com/enceve/crypto/pad"
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
-// EncryptReader читатель для шифрования блока данных алгоритмом AES в режиме CBC из другого читателя.
+const (
+	// Version текущая версия формата зашифрованного потока.
+	Version byte = 2
+	// SegmentSize размер открытых данных в одном сегменте.
+	SegmentSize = 64 * 1024
+	// HeaderSize размер заголовка зашифрованного потока вместе с тегом заголовка.
+	HeaderSize = headerFieldsSize + HeaderTagSize
+	// HeaderTagSize размер тега аутентификации заголовка.
+	HeaderTagSize = chacha20poly1305.Overhead
+	// NoncePrefixSize размер префикса nonce, хранящегося в заголовке.
+	NoncePrefixSize = chacha20poly1305.NonceSizeX - 5
+)
+
+// magic сигнатура зашифрованного потока
+const magic = "GKSE"
+
+// version1 версия формата без тега заголовка. Потоки этой версии по-прежнему расшифровываются.
+const version1 byte = 1
+
+// headerFieldsSize размер полей заголовка без тега.
+const headerFieldsSize = len(magic) + 1 + 1 + 4 + NoncePrefixSize
+
+// headerNonceFlag значение последнего байта nonce для тега заголовка, отличное от признаков сегментов.
+const headerNonceFlag byte = 2
+
+// header заголовок зашифрованного потока.
+type header struct {
+	version     byte
+	flags       byte
+	segmentSize uint32
+	noncePrefix []byte
+}
+
+// EncryptReader читатель для шифрования данных из другого читателя.
 // Можно считать как middleware для io.Reader. Реализует интерфейс io.ReadCloser.
-// Результат шифрования аналогичен openssl enc -aes-256-cbc -nosalt -e -out <file> -K "<key>" -iv 0.
+// Данные шифруются сегментами по SegmentSize байт алгоритмом XChaCha20-Poly1305. Сегменты независимы,
+// поэтому шифруются параллельно, но возвращаются строго по порядку. Если читатель не дочитан до конца,
+// его нужно закрыть, чтобы остановить шифрование.
 type EncryptReader struct {
-	key   []byte
-	r     io.Reader
-	iv    []byte
-	pad   pad.Padding
-	block cipher.Block
+	aead cipher.AEAD
+	r    io.Reader
+	iv   []byte
+	// режим сжатия данных перед шифрованием
+	compression Compression
+	flags       byte
+	// заголовок в сериализованном виде, используется как дополнительные аутентифицируемые данные
+	header []byte
+	// следующий за текущим сегментом байт исходных данных
+	lookahead []byte
+	segment   uint64
+	done      bool
+	// зашифрованные данные, которые еще не были прочитаны
+	buf bytes.Buffer
+	// количество одновременно шифруемых сегментов
+	workers int
+	// очередь результатов конвейера шифрования в порядке сегментов
+	results chan chan sealedSegment
+	// закрывается для остановки конвейера
+	stop chan struct{}
+	// ошибка чтения, повторяется при последующих чтениях
+	err error
 }
 
-// DecryptReader читатель для расшифровки блока данных алгоритмом AES в режиме CBC из другого читателя.
+// DecryptReader читатель для расшифровки данных из другого читателя.
 // Можно считать как middleware для io.Reader. Реализует интерфейс io.ReadCloser.
-// Результат расшифрования аналогичен openssl enc -aes-256-cbc -nosalt -d -out <file> -K "<key>" -iv 0.
+// Если исходные данные зашифрованы в устаревшем формате AES-CBC, то используется устаревший путь расшифровки.
 type DecryptReader struct {
-	key   []byte
-	r     io.Reader
-	iv    []byte
-	pad   pad.Padding
-	block cipher.Block
-}
-
-// NewEncryptReader возвращет новый EncryptReader с ключом `secret` для исходного читателя открытых данных `r` и вектором инициализации `iv`.
-// Если iv равен nil, то будет использоваться значение 0.
-func NewEncryptReader(secret string, r io.Reader, iv []byte) (*EncryptReader, error) {
-	key := sha256.Sum256([]byte(secret))
-	block, err := aes.NewCipher(key[:])
+	key    []byte
+	aead   cipher.AEAD
+	r      *bufio.Reader
+	header []byte
+	hdr    header
+	// читатель для данных в устаревшем формате
+	legacy  io.Reader
+	segment uint64
+	done    bool
+	ready   bool
+	// ошибка чтения заголовка, повторяется при последующих чтениях
+	err       error
+	plaintext bytes.Buffer
+	// читатель для распаковки сжатых данных
+	gz *gzip.Reader
+}
+
+// NewEncryptReader возвращет новый EncryptReader с ключом `key` для исходного читателя открытых данных `r`.
+// Для каждого читателя генерируется случайный префикс nonce, который сохраняется в заголовке потока.
+func NewEncryptReader(key *Key, r io.Reader) (*EncryptReader, error) {
+	return NewEncryptReaderWithCompression(key, r, CompressNone)
+}
+
+// NewEncryptReaderWithCompression возвращет новый EncryptReader, который перед шифрованием сжимает данные
+// в режиме compression. Признак сжатия сохраняется в заголовке потока.
+func NewEncryptReaderWithCompression(key *Key, r io.Reader, compression Compression) (*EncryptReader, error) {
+	aead, err := chacha20poly1305.NewX(key.key)
 	if err != nil {
 		return nil, err
 	}
+	prefix := make([]byte, NoncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
 	return &EncryptReader{
-		pad:   pad.NewPKCS7(aes.BlockSize),
-		key:   key[:],
-		block: block,
-		r:     r,
-		iv:    iv,
+		aead:        aead,
+		r:           r,
+		iv:          prefix,
+		compression: compression,
+		workers:     runtime.GOMAXPROCS(0),
 	}, nil
 }
 
-// NewDecryptReader возвращет новый DecryptReader с ключом `secret` для исходного читателя зашифрованных данных `r` и вектором инициализации `iv`.
-// Если iv равен nil, то будет использоваться значение 0.
-func NewDecryptReader(secret string, r io.Reader, iv []byte) (*DecryptReader, error) {
-	key := sha256.Sum256([]byte(secret))
-	block, err := aes.NewCipher(key[:])
+// NewDecryptReader возвращет новый DecryptReader с ключом `key` для исходного читателя зашифрованных данных `r`.
+// Префикс nonce читается из заголовка потока.
+func NewDecryptReader(key *Key, r io.Reader) (*DecryptReader, error) {
+	aead, err := chacha20poly1305.NewX(key.key)
 	if err != nil {
 		return nil, err
 	}
 	return &DecryptReader{
-		pad:   pad.NewPKCS7(aes.BlockSize),
-		key:   key[:],
-		block: block,
-		r:     r,
-		iv:    iv,
+		key:  key.legacy,
+		aead: aead,
+		r:    bufio.NewReaderSize(r, SegmentSize+chacha20poly1305.Overhead+1),
 	}, nil
 }
 
-func (r *EncryptReader) encrypt(plaintext []byte) []byte {
-	ciphertext := make([]byte, (len(plaintext)/16+1)*aes.BlockSize)
-	// выравниваем до размера блока
-	padded := r.pad.Pad(plaintext)
-	blk := cipher.NewCBCEncrypter(r.block, r.iv)
-	blk.CryptBlocks(ciphertext, padded)
-	return ciphertext
+func (h header) bytes() []byte {
+	b := make([]byte, 0, headerFieldsSize)
+	b = append(b, magic...)
+	b = append(b, h.version, h.flags)
+	b = binary.BigEndian.AppendUint32(b, h.segmentSize)
+	return append(b, h.noncePrefix...)
 }
 
-func (r *DecryptReader) decrypt(ciphertext []byte) ([]byte, error) {
-	plaintext := make([]byte, (len(ciphertext)/16)*aes.BlockSize)
-	blk := cipher.NewCBCDecrypter(r.block, r.iv)
-	blk.CryptBlocks(plaintext, ciphertext)
-	return r.pad.Unpad(plaintext)
+func parseHeader(b []byte) (header, error) {
+	h := header{}
+	if len(b) != headerFieldsSize || string(b[:len(magic)]) != magic {
+		return h, ErrInvalidHeader
+	}
+	b = b[len(magic):]
+	h.version, h.flags = b[0], b[1]
+	if h.version != Version && h.version != version1 {
+		return h, ErrUnsupportedVersion
+	}
+	if h.flags&^FlagGzip != 0 {
+		return h, ErrInvalidHeader
+	}
+	h.segmentSize = binary.BigEndian.Uint32(b[2:6])
+	if h.segmentSize == 0 || h.segmentSize > SegmentSize {
+		return h, ErrInvalidHeader
+	}
+	h.noncePrefix = b[6:]
+	return h, nil
 }
 
-// Read читает небольше aes.BlockSize из исходного читателя и шифрует их.
-func (r *EncryptReader) Read(p []byte) (n int, err error) {
-	src := make([]byte, aes.BlockSize)
-	// читаем из источника не больше одного блока
-	n, err = r.r.Read(src)
+// headerNonce возвращает nonce для тега заголовка.
+func headerNonce(prefix []byte) []byte {
+	nonce := segmentNonce(prefix, 0, false)
+	nonce[len(nonce)-1] = headerNonceFlag
+	return nonce
+}
+
+// segmentNonce возвращает nonce для сегмента с номером segment.
+func segmentNonce(prefix []byte, segment uint64, last bool) []byte {
+	nonce := make([]byte, 0, chacha20poly1305.NonceSizeX)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, uint32(segment))
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// readSegment читает из источника очередной сегмент открытых данных. Возвращает true, если сегмент последний.
+func (r *EncryptReader) readSegment() ([]byte, bool, error) {
+	src := make([]byte, SegmentSize)
+	n := copy(src, r.lookahead)
+	m, err := io.ReadFull(r.r, src[n:])
+	n += m
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return src[:n], true, nil
+	}
 	if err != nil {
-		return
+		return nil, false, err
 	}
-	if r.iv == nil {
-		r.iv = make([]byte, aes.BlockSize)
+	// чтобы понять, является ли сегмент последним, заглядываем на один байт вперед
+	r.lookahead = make([]byte, 1)
+	if _, err := io.ReadFull(r.r, r.lookahead); err != nil {
+		if err == io.EOF {
+			r.lookahead = nil
+			return src, true, nil
+		}
+		return nil, false, err
 	}
-	// шифруем
-	ciphertext := r.encrypt(src[:n])
+	return src, false, nil
+}
 
-	n = copy(p, ciphertext)
-	// каждый последующий вектор инициализации должен быть равен последнему блоку зашифрованного текста
-	r.iv = ciphertext[len(ciphertext)-aes.BlockSize:]
+// prepare выбирает, сжимать ли исходные данные. В режиме CompressAuto решение принимается по первому сегменту.
+func (r *EncryptReader) prepare() error {
+	switch r.compression {
+	case CompressAlways:
+		r.flags |= FlagGzip
+	case CompressAuto:
+		br := bufio.NewReaderSize(r.r, SegmentSize)
+		sample, err := br.Peek(SegmentSize)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if shouldCompress(sample) {
+			r.flags |= FlagGzip
+		}
+		r.r = br
+	}
+	if r.flags&FlagGzip != 0 {
+		r.r = newCompressReader(r.r)
+	}
+	return nil
+}
 
-	return n, err
+// Read читает сегмент из исходного читателя и шифрует его. Перед первым сегментом возвращается заголовок потока.
+func (r *EncryptReader) Read(p []byte) (n int, err error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.header == nil {
+		if err := r.prepare(); err != nil {
+			return 0, err
+		}
+		r.header = header{
+			version:     Version,
+			flags:       r.flags,
+			segmentSize: SegmentSize,
+			noncePrefix: r.iv,
+		}.bytes()
+		r.buf.Write(r.header)
+		r.buf.Write(r.aead.Seal(nil, headerNonce(r.iv), nil, r.header))
+	}
+	for r.buf.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if r.workers > 1 {
+			r.err = r.readPipeline()
+		} else {
+			r.err = r.sealSegment()
+		}
+		if r.err != nil {
+			return 0, r.err
+		}
+	}
+	return r.buf.Read(p)
 }
 
-// Close для реализации io.ReadCloser
+// sealSegment читает и шифрует очередной сегмент в вызывающей горутине.
+func (r *EncryptReader) sealSegment() error {
+	plaintext, last, err := r.readSegment()
+	if err != nil {
+		return err
+	}
+	r.buf.Write(r.aead.Seal(nil, segmentNonce(r.iv, r.segment, last), plaintext, r.header))
+	r.segment++
+	r.done = last
+	return nil
+}
+
+// Close останавливает конвейер шифрования. Для реализации io.ReadCloser.
 func (r *EncryptReader) Close() error {
+	if r.stop != nil && r.err != ErrClosed {
+		close(r.stop)
+	}
+	r.err = ErrClosed
 	return nil
 }
 
-// Read читает небольше 2*aes.BlockSize из исходного читателя и расшифровывает их.
-func (r *DecryptReader) Read(p []byte) (n int, err error) {
-	// читать будем больше из-за возможного выравнивания
-	src := make([]byte, aes.BlockSize*2)
-	n, err = r.r.Read(src)
-	if err != nil {
-		return
+// init читает заголовок потока и определяет формат исходных данных. Для потоков с тегом заголовка проверяется,
+// что поток зашифрован ключом читателя.
+func (r *DecryptReader) init() error {
+	r.ready = true
+	b, err := r.r.Peek(len(magic))
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if len(b) < len(magic) {
+		// пустой или обрезанный поток не может быть ни новым, ни устаревшим форматом (блоки AES по 16 байт)
+		return ErrTruncated
 	}
-	if r.iv == nil {
-		r.iv = make([]byte, aes.BlockSize)
+	if string(b[:len(magic)]) != magic {
+		// данные без заголовка считаем зашифрованными в устаревшем формате
+		r.legacy = newLegacyDecryptReader(r.key, r.r)
+		return nil
+	}
+	r.header = make([]byte, headerFieldsSize)
+	if err := readHeader(r.r, r.header); err != nil {
+		return err
+	}
+	if r.hdr, err = parseHeader(r.header); err != nil {
+		return err
+	}
+	if r.hdr.version == version1 {
+		return nil
+	}
+	tag := make([]byte, HeaderTagSize)
+	if err := readHeader(r.r, tag); err != nil {
+		return err
+	}
+	if _, err := r.aead.Open(nil, headerNonce(r.hdr.noncePrefix), tag, r.header); err != nil {
+		return ErrWrongKey
+	}
+	return nil
+}
+
+// readHeader читает из r часть заголовка размером len(b).
+func readHeader(r io.Reader, b []byte) error {
+	if _, err := io.ReadFull(r, b); err != nil {
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			return ErrInvalidHeader
+		}
+		return err
 	}
-	plaintext, err := r.decrypt(src[:n])
+	return nil
+}
+
+// Verify читает заголовок потока и проверяет, что поток зашифрован ключом читателя, не расшифровывая данные.
+// Если поток зашифрован другим ключом, то возвращается ErrWrongKey. Для потоков без тега заголовка
+// проверка ключа невозможна, и ошибка не возвращается.
+func (r *DecryptReader) Verify() error {
+	if !r.ready {
+		r.err = r.init()
+	}
+	return r.err
+}
+
+// readSegment читает и расшифровывает очередной сегмент.
+func (r *DecryptReader) readSegment() error {
+	size := int(r.hdr.segmentSize) + r.aead.Overhead()
+	ciphertext := make([]byte, size)
+	n, err := io.ReadFull(r.r, ciphertext)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		if err == io.EOF {
+			// поток закончился раньше, чем был получен последний сегмент
+			return NewStreamError(r.segment, ErrTruncated)
+		}
+		return err
+	}
+	ciphertext = ciphertext[:n]
+	last := true
+	if n == size {
+		if _, err := r.r.Peek(1); err == nil {
+			last = false
+		}
+	}
+	plaintext, err := r.aead.Open(nil, segmentNonce(r.hdr.noncePrefix, r.segment, last), ciphertext, r.header)
 	if err != nil {
-		return
+		if last {
+			// если сегмент расшифровывается как промежуточный, значит поток был обрезан
+			if _, err := r.aead.Open(nil, segmentNonce(r.hdr.noncePrefix, r.segment, false), ciphertext, r.header); err == nil {
+				return NewStreamError(r.segment, ErrTruncated)
+			}
+		}
+		return NewStreamError(r.segment, ErrTampered)
 	}
-	n = copy(p, plaintext)
-	r.iv = src[len(src)-aes.BlockSize:]
+	r.plaintext.Write(plaintext)
+	r.segment++
+	r.done = last
+	return nil
+}
+
+// Read читает сегмент из исходного читателя и расшифровывает его. Данные сегмента возвращаются только после
+// успешной проверки его подлинности. Сжатые данные распаковываются.
+func (r *DecryptReader) Read(p []byte) (n int, err error) {
+	if err := r.Verify(); err != nil {
+		return 0, err
+	}
+	if r.legacy != nil {
+		return r.legacy.Read(p)
+	}
+	if r.hdr.flags&FlagGzip != 0 {
+		if r.gz == nil {
+			if r.gz, err = gzip.NewReader(readerFunc(r.readPlaintext)); err != nil {
+				return 0, err
+			}
+		}
+		return r.gz.Read(p)
+	}
+	return r.readPlaintext(p)
+}
 
-	return n, err
+// readPlaintext возвращает расшифрованные данные сегментов.
+func (r *DecryptReader) readPlaintext(p []byte) (n int, err error) {
+	for r.plaintext.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.readSegment(); err != nil {
+			return 0, err
+		}
+	}
+	return r.plaintext.Read(p)
 }
 
 // Close для реализации io.ReadCloser
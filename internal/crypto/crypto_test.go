@@ -2,11 +2,17 @@ package crypto
 
 import (
 	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"io"
 	"testing"
 
+	"github.com/enceve/crypto/pad"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
 func generateRandom(size int) []byte {
@@ -15,13 +21,64 @@ func generateRandom(size int) []byte {
 	return b
 }
 
+// legacyEncrypt шифрует данные в устаревшем формате AES-CBC, как это делал EncryptReader до появления заголовка.
+func legacyEncrypt(secret string, plaintext []byte) []byte {
+	key := sha256.Sum256([]byte(secret))
+	block, _ := aes.NewCipher(key[:])
+	p := pad.NewPKCS7(aes.BlockSize)
+	iv := make([]byte, aes.BlockSize)
+	result := bytes.NewBuffer(nil)
+	for len(plaintext) > 0 {
+		n := aes.BlockSize
+		if len(plaintext) < n {
+			n = len(plaintext)
+		}
+		padded := p.Pad(plaintext[:n])
+		ciphertext := make([]byte, len(padded))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+		result.Write(ciphertext)
+		iv = ciphertext[len(ciphertext)-aes.BlockSize:]
+		plaintext = plaintext[n:]
+	}
+	return result.Bytes()
+}
+
+// testKey возвращает ключ, полученный из пароля secret с минимальными параметрами, чтобы не замедлять тесты.
+func testKey(secret string) *Key {
+	key, err := DeriveKey(secret, KDFParams{
+		Algorithm: KDFArgon2id,
+		Salt:      []byte("salt"),
+		Time:      1,
+		Memory:    64,
+		Threads:   1,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func encrypt(t *testing.T, secret string, plaintext []byte) []byte {
+	enc, err := NewEncryptReader(testKey(secret), bytes.NewBuffer(plaintext))
+	assert.NoError(t, err)
+	ciphertext, err := io.ReadAll(enc)
+	assert.NoError(t, err)
+	return ciphertext
+}
+
+func decrypt(t *testing.T, secret string, ciphertext []byte) ([]byte, error) {
+	dec, err := NewDecryptReader(testKey(secret), bytes.NewBuffer(ciphertext))
+	assert.NoError(t, err)
+	return io.ReadAll(dec)
+}
+
 func TestEncrypt(t *testing.T) {
-	cases := []int{1, 10, 100, 300, 512, 1000, 10000, 100000}
+	cases := []int{1, 10, 100, 300, 512, 1000, 10000, 100000, SegmentSize, 2*SegmentSize + 1}
 	for _, size := range cases {
 		original := generateRandom(size)
-		key := generateRandom(32)
+		key := testKey(hex.EncodeToString(generateRandom(32)))
 
-		enc, err := NewEncryptReader(hex.EncodeToString(key), bytes.NewBuffer(original), nil)
+		enc, err := NewEncryptReader(key, bytes.NewBuffer(original))
 		assert.NoError(t, err)
 		cipher := bytes.NewBuffer(nil)
 		n, err := cipher.ReadFrom(enc)
@@ -29,7 +86,7 @@ func TestEncrypt(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotEqual(t, original, cipher.Bytes())
 
-		dec, err := NewDecryptReader(hex.EncodeToString(key), cipher, nil)
+		dec, err := NewDecryptReader(key, cipher)
 		assert.NoError(t, err)
 		plain := bytes.NewBuffer(nil)
 		n, err = plain.ReadFrom(dec)
@@ -38,3 +95,148 @@ func TestEncrypt(t *testing.T) {
 		assert.Equal(t, original, plain.Bytes())
 	}
 }
+
+func TestEncryptUniqueNonce(t *testing.T) {
+	cases := []int{1, 100, SegmentSize + 1}
+	for _, size := range cases {
+		original := generateRandom(size)
+		c1 := encrypt(t, "secret", original)
+		c2 := encrypt(t, "secret", original)
+		// одинаковые данные с одним ключом должны давать разный шифротекст, в том числе заголовок и первый блок
+		assert.Equal(t, len(c1), len(c2))
+		assert.NotEqual(t, c1[HeaderSize-NoncePrefixSize:HeaderSize], c2[HeaderSize-NoncePrefixSize:HeaderSize])
+		assert.NotEqual(t, c1[HeaderSize:HeaderSize+16], c2[HeaderSize:HeaderSize+16])
+		for _, c := range [][]byte{c1, c2} {
+			plaintext, err := decrypt(t, "secret", c)
+			assert.NoError(t, err)
+			assert.Equal(t, original, plaintext)
+		}
+	}
+}
+
+func TestEncryptEmpty(t *testing.T) {
+	ciphertext := encrypt(t, "secret", nil)
+	assert.Len(t, ciphertext, HeaderSize+chacha20poly1305.Overhead)
+	plaintext, err := decrypt(t, "secret", ciphertext)
+	assert.NoError(t, err)
+	assert.Empty(t, plaintext)
+}
+
+func TestDecryptLegacy(t *testing.T) {
+	cases := []int{1, 16, 100, 1000, 100000}
+	for _, size := range cases {
+		original := generateRandom(size)
+		plaintext, err := decrypt(t, "secret", legacyEncrypt("secret", original))
+		assert.NoError(t, err)
+		assert.Equal(t, original, plaintext)
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	original := generateRandom(3 * SegmentSize)
+	segment := SegmentSize + chacha20poly1305.Overhead
+	ciphertext := encrypt(t, "secret", original)
+
+	// изменение данных
+	tampered := bytes.Clone(ciphertext)
+	tampered[HeaderSize+segment+10] ^= 0x01
+	_, err := decrypt(t, "secret", tampered)
+	assert.ErrorIs(t, err, ErrTampered)
+	var streamErr *StreamError
+	if assert.ErrorAs(t, err, &streamErr) {
+		assert.Equal(t, uint64(1), streamErr.Segment)
+	}
+
+	// изменение заголовка или его тега
+	for _, i := range []int{HeaderSize - HeaderTagSize - 1, HeaderSize - 1} {
+		tampered = bytes.Clone(ciphertext)
+		tampered[i] ^= 0x01
+		plaintext, err := decrypt(t, "secret", tampered)
+		assert.ErrorIs(t, err, ErrWrongKey)
+		assert.Empty(t, plaintext)
+	}
+
+	// неверный ключ обнаруживается до расшифровки данных
+	plaintext, err := decrypt(t, "other secret", ciphertext)
+	assert.ErrorIs(t, err, ErrWrongKey)
+	assert.Empty(t, plaintext)
+}
+
+func TestDecryptVerify(t *testing.T) {
+	ciphertext := encrypt(t, "secret", generateRandom(100))
+	dec, err := NewDecryptReader(testKey("secret"), bytes.NewReader(ciphertext))
+	assert.NoError(t, err)
+	assert.NoError(t, dec.Verify())
+
+	dec, err = NewDecryptReader(testKey("other secret"), bytes.NewReader(ciphertext))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, dec.Verify(), ErrWrongKey)
+	// ошибка заголовка повторяется при чтении
+	_, err = dec.Read(make([]byte, 10))
+	assert.ErrorIs(t, err, ErrWrongKey)
+
+	// для данных в устаревшем формате ключ не проверяется
+	dec, err = NewDecryptReader(testKey("other secret"), bytes.NewReader(legacyEncrypt("secret", generateRandom(100))))
+	assert.NoError(t, err)
+	assert.NoError(t, dec.Verify())
+}
+
+func TestDecryptVersion1(t *testing.T) {
+	original := generateRandom(SegmentSize + 100)
+	key := testKey("secret")
+	aead, _ := chacha20poly1305.NewX(key.key)
+	prefix := generateRandom(NoncePrefixSize)
+	// поток версии 1 не содержит тега заголовка
+	h := header{version: version1, segmentSize: SegmentSize, noncePrefix: prefix}.bytes()
+	ciphertext := bytes.Clone(h)
+	ciphertext = aead.Seal(ciphertext, segmentNonce(prefix, 0, false), original[:SegmentSize], h)
+	ciphertext = aead.Seal(ciphertext, segmentNonce(prefix, 1, true), original[SegmentSize:], h)
+
+	plaintext, err := decrypt(t, "secret", ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, original, plaintext)
+	_, err = decrypt(t, "other secret", ciphertext)
+	assert.ErrorIs(t, err, ErrTampered)
+}
+
+func TestDecryptReordered(t *testing.T) {
+	original := generateRandom(3 * SegmentSize)
+	segment := SegmentSize + chacha20poly1305.Overhead
+	ciphertext := encrypt(t, "secret", original)
+
+	reordered := bytes.NewBuffer(nil)
+	reordered.Write(ciphertext[:HeaderSize])
+	reordered.Write(ciphertext[HeaderSize+segment : HeaderSize+2*segment])
+	reordered.Write(ciphertext[HeaderSize : HeaderSize+segment])
+	reordered.Write(ciphertext[HeaderSize+2*segment:])
+	plaintext, err := decrypt(t, "secret", reordered.Bytes())
+	assert.ErrorIs(t, err, ErrTampered)
+	assert.Empty(t, plaintext)
+}
+
+func TestDecryptTruncated(t *testing.T) {
+	original := generateRandom(3 * SegmentSize)
+	segment := SegmentSize + chacha20poly1305.Overhead
+	ciphertext := encrypt(t, "secret", original)
+
+	cases := []int{
+		// отброшены сегменты по границе
+		HeaderSize + 2*segment,
+		HeaderSize + segment,
+		// остался только заголовок
+		HeaderSize,
+	}
+	for _, size := range cases {
+		_, err := decrypt(t, "secret", ciphertext[:size])
+		assert.ErrorIs(t, err, ErrTruncated)
+	}
+	_, err := decrypt(t, "secret", ciphertext[:HeaderSize+segment+100])
+	assert.Error(t, err)
+	_, err = decrypt(t, "secret", ciphertext[:HeaderSize-1])
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+	// поток короче сигнатуры не считается данными в устаревшем формате
+	for _, size := range []int{0, 1, len(magic) - 1} {
+		_, err = decrypt(t, "secret", ciphertext[:size])
+		assert.ErrorIs(t, err, ErrTruncated)
+	}
+}
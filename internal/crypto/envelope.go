@@ -0,0 +1,55 @@
+package crypto
+
+import (
+	"crypto/rand"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// wrapVersion версия формата зашифрованного ключа данных.
+const wrapVersion byte = 1
+
+// WrappedKeySize размер ключа данных, зашифрованного мастер-ключом.
+const WrappedKeySize = 1 + chacha20poly1305.NonceSizeX + KeySize + chacha20poly1305.Overhead
+
+// NewDataKey возвращает новый случайный ключ для шифрования данных одного секрета.
+func NewDataKey() (*Key, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &Key{key: key}, nil
+}
+
+// WrapKey шифрует ключ данных key мастер-ключом master. Результат можно хранить рядом с мета-данными секрета,
+// тогда при смене пароля достаточно перешифровать только ключи данных, а не сами данные.
+// Формат: версия (1 байт) | nonce (24 байта) | XChaCha20-Poly1305(master, nonce, key, версия).
+func WrapKey(master *Key, key *Key) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(master.key)
+	if err != nil {
+		return nil, err
+	}
+	wrapped := make([]byte, 1+chacha20poly1305.NonceSizeX, WrappedKeySize)
+	wrapped[0] = wrapVersion
+	if _, err := rand.Read(wrapped[1:]); err != nil {
+		return nil, err
+	}
+	return aead.Seal(wrapped, wrapped[1:], key.key, wrapped[:1]), nil
+}
+
+// UnwrapKey расшифровывает ключ данных wrapped мастер-ключом master.
+func UnwrapKey(master *Key, wrapped []byte) (*Key, error) {
+	if len(wrapped) != WrappedKeySize || wrapped[0] != wrapVersion {
+		return nil, ErrInvalidWrappedKey
+	}
+	aead, err := chacha20poly1305.NewX(master.key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := wrapped[1 : 1+chacha20poly1305.NonceSizeX]
+	key, err := aead.Open(nil, nonce, wrapped[1+chacha20poly1305.NonceSizeX:], wrapped[:1])
+	if err != nil {
+		return nil, ErrInvalidWrappedKey
+	}
+	return &Key{key: key}, nil
+}
@@ -0,0 +1,66 @@
+package crypto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapKey(t *testing.T) {
+	master := testKey("secret")
+	key, err := NewDataKey()
+	assert.NoError(t, err)
+	other, err := NewDataKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, other)
+
+	wrapped, err := WrapKey(master, key)
+	assert.NoError(t, err)
+	assert.Len(t, wrapped, WrappedKeySize)
+	got, err := UnwrapKey(master, wrapped)
+	assert.NoError(t, err)
+	assert.Equal(t, key, got)
+
+	// данные, зашифрованные ключом данных, расшифровываются развернутым ключом
+	original := generateRandom(1000)
+	enc, err := NewEncryptReader(key, bytes.NewBuffer(original))
+	assert.NoError(t, err)
+	dec, err := NewDecryptReader(got, enc)
+	assert.NoError(t, err)
+	plaintext, err := io.ReadAll(dec)
+	assert.NoError(t, err)
+	assert.Equal(t, original, plaintext)
+}
+
+func TestRewrapKey(t *testing.T) {
+	key, _ := NewDataKey()
+	wrapped, err := WrapKey(testKey("old secret"), key)
+	assert.NoError(t, err)
+	// при смене пароля ключ данных перешифровывается новым мастер-ключом и остается прежним
+	unwrapped, err := UnwrapKey(testKey("old secret"), wrapped)
+	assert.NoError(t, err)
+	rewrapped, err := WrapKey(testKey("new secret"), unwrapped)
+	assert.NoError(t, err)
+	got, err := UnwrapKey(testKey("new secret"), rewrapped)
+	assert.NoError(t, err)
+	assert.Equal(t, key, got)
+}
+
+func TestUnwrapKeyInvalid(t *testing.T) {
+	master := testKey("secret")
+	key, _ := NewDataKey()
+	wrapped, _ := WrapKey(master, key)
+
+	_, err := UnwrapKey(testKey("other secret"), wrapped)
+	assert.ErrorIs(t, err, ErrInvalidWrappedKey)
+	tampered := bytes.Clone(wrapped)
+	tampered[len(tampered)-1] ^= 0x01
+	_, err = UnwrapKey(master, tampered)
+	assert.ErrorIs(t, err, ErrInvalidWrappedKey)
+	_, err = UnwrapKey(master, wrapped[:10])
+	assert.ErrorIs(t, err, ErrInvalidWrappedKey)
+	_, err = UnwrapKey(master, nil)
+	assert.ErrorIs(t, err, ErrInvalidWrappedKey)
+}
@@ -0,0 +1,40 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidHeader      = errors.New("invalid encrypted stream header")
+	ErrUnsupportedVersion = errors.New("unsupported encrypted stream version")
+	ErrTampered           = errors.New("encrypted stream is tampered or corrupted")
+	ErrTruncated          = errors.New("encrypted stream is truncated")
+	ErrUnknownKDF         = errors.New("unknown key derivation function")
+	ErrInvalidKDFParams   = errors.New("invalid key derivation parameters")
+	ErrInvalidWrappedKey  = errors.New("data key could not be unwrapped")
+	ErrWrongKey           = errors.New("encrypted stream is encrypted with another key or its header is corrupted")
+	ErrClosed             = errors.New("reader is closed")
+	ErrInvalidSealed      = errors.New("sealed data is tampered or encrypted with another key")
+)
+
+// StreamError ошибка расшифровки потока. Содержит номер сегмента, на котором произошла ошибка.
+type StreamError struct {
+	Segment uint64
+	Err     error
+}
+
+func NewStreamError(segment uint64, err error) error {
+	return &StreamError{
+		Segment: segment,
+		Err:     err,
+	}
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("segment %d: %v", e.Segment, e.Err)
+}
+
+func (e *StreamError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,113 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// KDFArgon2id формирование ключа алгоритмом Argon2id.
+	KDFArgon2id = "argon2id"
+	// KDFScrypt формирование ключа алгоритмом scrypt. Используется там, где Argon2id по какой-то причине не подходит.
+	KDFScrypt = "scrypt"
+	// SaltSize размер соли для формирования ключа.
+	SaltSize = 16
+	// KeySize размер ключа шифрования.
+	KeySize = chacha20poly1305.KeySize
+)
+
+// KDFParams параметры формирования ключа шифрования из пароля. Хранятся вместе с хранилищем секретов,
+// чтобы ключ всегда получался одинаковым и параметры можно было изменить в будущем.
+type KDFParams struct {
+	// Алгоритм формирования ключа
+	Algorithm string
+	// Случайная соль
+	Salt []byte
+	// Количество итераций (Argon2id)
+	Time uint32
+	// Объем памяти в КиБ (Argon2id)
+	Memory uint32
+	// Степень параллелизма (Argon2id)
+	Threads uint8
+	// Параметр стоимости (scrypt)
+	N int
+	// Размер блока (scrypt)
+	R int
+	// Степень параллелизма (scrypt)
+	P int
+}
+
+// Equal возвращает true, если параметры p и other дают одинаковый ключ для одного и того же пароля.
+func (p KDFParams) Equal(other KDFParams) bool {
+	return p.Algorithm == other.Algorithm && bytes.Equal(p.Salt, other.Salt) &&
+		p.Time == other.Time && p.Memory == other.Memory && p.Threads == other.Threads &&
+		p.N == other.N && p.R == other.R && p.P == other.P
+}
+
+// Key ключ шифрования данных секретов.
+type Key struct {
+	key []byte
+	// ключ для расшифровки данных в устаревшем формате, получался как sha256 от пароля
+	legacy []byte
+}
+
+// NewKDFParams возвращает рекомендуемые параметры формирования ключа алгоритмом algorithm со случайной солью.
+func NewKDFParams(algorithm string) (KDFParams, error) {
+	p := KDFParams{
+		Algorithm: algorithm,
+		Salt:      make([]byte, SaltSize),
+	}
+	switch algorithm {
+	case KDFArgon2id:
+		p.Time, p.Memory, p.Threads = 3, 64*1024, 4
+	case KDFScrypt:
+		p.N, p.R, p.P = 1<<15, 8, 1
+	default:
+		return p, ErrUnknownKDF
+	}
+	if _, err := rand.Read(p.Salt); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// DeriveKey возвращает ключ шифрования, полученный из пароля secret с параметрами p.
+func DeriveKey(secret string, p KDFParams) (*Key, error) {
+	return DeriveCompositeKey(secret, nil, p)
+}
+
+// DeriveCompositeKey возвращает ключ шифрования, полученный из пароля secret и хеша ключевого файла keyfile
+// с параметрами p. Если keyfile пустой, то ключ формируется только из пароля, как DeriveKey.
+func DeriveCompositeKey(secret string, keyfile []byte, p KDFParams) (*Key, error) {
+	var (
+		key []byte
+		err error
+	)
+	input := CompositeSecret(secret, keyfile)
+	if len(p.Salt) == 0 {
+		return nil, ErrInvalidKDFParams
+	}
+	switch p.Algorithm {
+	case KDFArgon2id:
+		if p.Time == 0 || p.Memory == 0 || p.Threads == 0 {
+			return nil, ErrInvalidKDFParams
+		}
+		key = argon2.IDKey(input, p.Salt, p.Time, p.Memory, p.Threads, KeySize)
+	case KDFScrypt:
+		if key, err = scrypt.Key(input, p.Salt, p.N, p.R, p.P, KeySize); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnknownKDF
+	}
+	legacy := sha256.Sum256([]byte(secret))
+	return &Key{
+		key:    key,
+		legacy: legacy[:],
+	}, nil
+}
@@ -0,0 +1,62 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewKDFParams(t *testing.T) {
+	for _, algorithm := range []string{KDFArgon2id, KDFScrypt} {
+		p1, err := NewKDFParams(algorithm)
+		assert.NoError(t, err)
+		assert.Equal(t, algorithm, p1.Algorithm)
+		assert.Len(t, p1.Salt, SaltSize)
+		p2, err := NewKDFParams(algorithm)
+		assert.NoError(t, err)
+		assert.NotEqual(t, p1.Salt, p2.Salt)
+	}
+	_, err := NewKDFParams("md5")
+	assert.ErrorIs(t, err, ErrUnknownKDF)
+}
+
+func TestDeriveKey(t *testing.T) {
+	cases := []KDFParams{
+		{Algorithm: KDFArgon2id, Salt: []byte("salt#1"), Time: 1, Memory: 64, Threads: 1},
+		{Algorithm: KDFScrypt, Salt: []byte("salt#1"), N: 1 << 4, R: 8, P: 1},
+	}
+	for _, p := range cases {
+		k1, err := DeriveKey("secret", p)
+		assert.NoError(t, err)
+		assert.Len(t, k1.key, KeySize)
+		// ключ должен получаться одинаковым при одинаковых параметрах
+		k2, err := DeriveKey("secret", p)
+		assert.NoError(t, err)
+		assert.Equal(t, k1, k2)
+		// и разным для разных паролей или соли
+		k3, err := DeriveKey("other secret", p)
+		assert.NoError(t, err)
+		assert.NotEqual(t, k1.key, k3.key)
+		p.Salt = []byte("salt#2")
+		k4, err := DeriveKey("secret", p)
+		assert.NoError(t, err)
+		assert.NotEqual(t, k1.key, k4.key)
+	}
+}
+
+func TestDeriveKeyInvalidParams(t *testing.T) {
+	cases := []struct {
+		p   KDFParams
+		err error
+	}{
+		{p: KDFParams{Algorithm: KDFArgon2id, Time: 1, Memory: 64, Threads: 1}, err: ErrInvalidKDFParams},
+		{p: KDFParams{Algorithm: KDFArgon2id, Salt: []byte("salt")}, err: ErrInvalidKDFParams},
+		{p: KDFParams{Algorithm: "unknown", Salt: []byte("salt")}, err: ErrUnknownKDF},
+	}
+	for _, tt := range cases {
+		_, err := DeriveKey("secret", tt.p)
+		assert.ErrorIs(t, err, tt.err)
+	}
+	_, err := DeriveKey("secret", KDFParams{Algorithm: KDFScrypt, Salt: []byte("salt"), N: 3, R: 8, P: 1})
+	assert.Error(t, err)
+}
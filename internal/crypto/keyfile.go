@@ -0,0 +1,40 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+)
+
+// KeyfileSize размер генерируемого ключевого файла.
+const KeyfileSize = 64
+
+// GenerateKeyfile записывает в w содержимое нового ключевого файла из KeyfileSize случайных байт.
+func GenerateKeyfile(w io.Writer) error {
+	b := make([]byte, KeyfileSize)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// ReadKeyfile возвращает хеш SHA-256 содержимого ключевого файла r. Ключевым файлом может быть любой файл,
+// поэтому он читается потоком и в формировании ключа участвует только его хеш.
+func ReadKeyfile(r io.Reader) ([]byte, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// CompositeSecret возвращает составной секрет из пароля secret и хеша ключевого файла keyfile,
+// полученного ReadKeyfile: SHA-256(пароль) | хеш ключевого файла. Без ключевого файла секретом остается пароль.
+func CompositeSecret(secret string, keyfile []byte) []byte {
+	if len(keyfile) == 0 {
+		return []byte(secret)
+	}
+	h := sha256.Sum256([]byte(secret))
+	return append(h[:], keyfile...)
+}
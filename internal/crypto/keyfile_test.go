@@ -0,0 +1,42 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateKeyfile(t *testing.T) {
+	k1, k2 := bytes.Buffer{}, bytes.Buffer{}
+	assert.NoError(t, GenerateKeyfile(&k1))
+	assert.NoError(t, GenerateKeyfile(&k2))
+	assert.Equal(t, KeyfileSize, k1.Len())
+	assert.NotEqual(t, k1.Bytes(), k2.Bytes())
+}
+
+func TestDeriveCompositeKey(t *testing.T) {
+	p := KDFParams{Algorithm: KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	keyfile, err := ReadKeyfile(bytes.NewReader([]byte("keyfile content")))
+	assert.NoError(t, err)
+	other, err := ReadKeyfile(bytes.NewReader([]byte("other keyfile content")))
+	assert.NoError(t, err)
+
+	k1, err := DeriveCompositeKey("secret", keyfile, p)
+	assert.NoError(t, err)
+	k2, err := DeriveCompositeKey("secret", keyfile, p)
+	assert.NoError(t, err)
+	assert.Equal(t, k1, k2)
+	// ключ зависит и от пароля, и от ключевого файла
+	k3, _ := DeriveCompositeKey("secret", other, p)
+	assert.NotEqual(t, k1.key, k3.key)
+	k4, _ := DeriveCompositeKey("other secret", keyfile, p)
+	assert.NotEqual(t, k1.key, k4.key)
+	k5, _ := DeriveKey("secret", p)
+	assert.NotEqual(t, k1.key, k5.key)
+
+	// без ключевого файла ключ совпадает с ключом только из пароля
+	k6, err := DeriveCompositeKey("secret", nil, p)
+	assert.NoError(t, err)
+	assert.Equal(t, k5, k6)
+}
@@ -0,0 +1,74 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"io"
+
+	"github.com/enceve/crypto/pad"
+)
+
+// legacyDecryptReader читатель для расшифровки данных, зашифрованных в устаревшем формате: алгоритмом AES в режиме CBC
+// без аутентификации, где каждый блок исходных данных размером aes.BlockSize дополнялся и шифровался отдельно.
+type legacyDecryptReader struct {
+	r     io.Reader
+	iv    []byte
+	pad   pad.Padding
+	block cipher.Block
+	err   error
+	// расшифрованные данные, которые еще не были прочитаны
+	buf []byte
+}
+
+// newLegacyDecryptReader возвращает читатель для данных в устаревшем формате. Такие данные всегда шифровались
+// с нулевым вектором инициализации.
+func newLegacyDecryptReader(key []byte, r io.Reader) *legacyDecryptReader {
+	block, err := aes.NewCipher(key)
+	return &legacyDecryptReader{
+		pad:   pad.NewPKCS7(aes.BlockSize),
+		block: block,
+		r:     r,
+		iv:    make([]byte, aes.BlockSize),
+		err:   err,
+	}
+}
+
+func (r *legacyDecryptReader) decrypt(ciphertext []byte) ([]byte, error) {
+	plaintext := make([]byte, len(ciphertext))
+	blk := cipher.NewCBCDecrypter(r.block, r.iv)
+	blk.CryptBlocks(plaintext, ciphertext)
+	return r.pad.Unpad(plaintext)
+}
+
+// Read читает небольше 2*aes.BlockSize из исходного читателя и расшифровывает их.
+func (r *legacyDecryptReader) Read(p []byte) (n int, err error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if len(r.buf) > 0 {
+		n = copy(p, r.buf)
+		r.buf = r.buf[n:]
+		return n, nil
+	}
+	// читать будем больше из-за возможного выравнивания
+	src := make([]byte, aes.BlockSize*2)
+	n, err = io.ReadFull(r.r, src)
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if n%aes.BlockSize != 0 {
+		return 0, ErrTruncated
+	}
+	plaintext, err := r.decrypt(src[:n])
+	if err != nil {
+		return 0, err
+	}
+	r.iv = src[n-aes.BlockSize : n]
+	n = copy(p, plaintext)
+	r.buf = plaintext[n:]
+
+	return n, nil
+}
@@ -0,0 +1,80 @@
+package crypto
+
+// sealedSegment результат шифрования сегмента.
+type sealedSegment struct {
+	data []byte
+	err  error
+}
+
+// segmentJob задание на шифрование сегмента для исполнителя.
+type segmentJob struct {
+	plaintext []byte
+	segment   uint64
+	last      bool
+	out       chan<- sealedSegment
+}
+
+// SetWorkers задает количество сегментов, которые шифруются одновременно. При n <= 1 сегменты шифруются
+// последовательно в вызывающей горутине. Должен вызываться до первого чтения.
+func (r *EncryptReader) SetWorkers(n int) {
+	r.workers = n
+}
+
+// startPipeline запускает конвейер шифрования: сегменты читаются из источника по порядку и шифруются пулом
+// из r.workers исполнителей. Порядок сегментов сохраняется очередью результатов, а ее размер ограничивает
+// количество сегментов в обработке, т.е. и расход памяти.
+func (r *EncryptReader) startPipeline() {
+	r.results = make(chan chan sealedSegment, r.workers)
+	r.stop = make(chan struct{})
+	jobs := make(chan segmentJob)
+	for i := 0; i < r.workers; i++ {
+		go func() {
+			for j := range jobs {
+				j.out <- sealedSegment{data: r.aead.Seal(nil, segmentNonce(r.iv, j.segment, j.last), j.plaintext, r.header)}
+			}
+		}()
+	}
+	go func() {
+		defer close(r.results)
+		defer close(jobs)
+		for segment := uint64(0); ; segment++ {
+			plaintext, last, err := r.readSegment()
+			out := make(chan sealedSegment, 1)
+			select {
+			case r.results <- out:
+			case <-r.stop:
+				return
+			}
+			if err != nil {
+				out <- sealedSegment{err: err}
+				return
+			}
+			select {
+			case jobs <- segmentJob{plaintext: plaintext, segment: segment, last: last, out: out}:
+			case <-r.stop:
+				return
+			}
+			if last {
+				return
+			}
+		}
+	}()
+}
+
+// readPipeline возвращает в буфер очередной зашифрованный конвейером сегмент.
+func (r *EncryptReader) readPipeline() error {
+	if r.results == nil {
+		r.startPipeline()
+	}
+	out, ok := <-r.results
+	if !ok {
+		r.done = true
+		return nil
+	}
+	s := <-out
+	if s.err != nil {
+		return s.err
+	}
+	r.buf.Write(s.data)
+	return nil
+}
@@ -0,0 +1,136 @@
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+func TestEncryptParallel(t *testing.T) {
+	cases := []int{0, 1, SegmentSize, 10*SegmentSize + 1}
+	for _, size := range cases {
+		original := generateRandom(size)
+		segments := (size + SegmentSize - 1) / SegmentSize
+		if segments == 0 {
+			segments = 1
+		}
+		for _, workers := range []int{1, 2, 8} {
+			enc, err := NewEncryptReader(testKey("secret"), bytes.NewReader(original))
+			assert.NoError(t, err)
+			enc.SetWorkers(workers)
+			ciphertext, err := io.ReadAll(enc)
+			assert.NoError(t, err)
+			assert.Equal(t, HeaderSize+size+segments*chacha20poly1305.Overhead, len(ciphertext))
+			plaintext, err := decrypt(t, "secret", ciphertext)
+			assert.NoError(t, err, "size %d, workers %d", size, workers)
+			assert.True(t, bytes.Equal(original, plaintext), "size %d, workers %d", size, workers)
+		}
+	}
+}
+
+func TestEncryptParallelClose(t *testing.T) {
+	before := runtime.NumGoroutine()
+	enc, err := NewEncryptReader(testKey("secret"), bytes.NewReader(generateRandom(20*SegmentSize)))
+	assert.NoError(t, err)
+	enc.SetWorkers(4)
+	_, err = enc.Read(make([]byte, 100))
+	assert.NoError(t, err)
+	assert.NoError(t, enc.Close())
+	assert.NoError(t, enc.Close())
+	_, err = enc.Read(make([]byte, 100))
+	assert.ErrorIs(t, err, ErrClosed)
+	// после закрытия конвейер шифрования останавливается
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+type failingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err == io.EOF {
+		return n, r.err
+	}
+	return n, err
+}
+
+func TestEncryptParallelReadError(t *testing.T) {
+	expected := errors.New("read error")
+	for _, workers := range []int{1, 4} {
+		r := &failingReader{r: bytes.NewReader(generateRandom(5*SegmentSize + 10)), err: expected}
+		enc, err := NewEncryptReader(testKey("secret"), r)
+		assert.NoError(t, err)
+		enc.SetWorkers(workers)
+		_, err = io.ReadAll(enc)
+		assert.ErrorIs(t, err, expected)
+		enc.Close()
+	}
+}
+
+// benchmarkSizes размеры открытых данных, на которых сравниваются последовательное и параллельное шифрование.
+var benchmarkSizes = []int{SegmentSize, 16 * SegmentSize, 64 * SegmentSize}
+
+func BenchmarkEncrypt(b *testing.B) {
+	key := testKey("secret")
+	for _, size := range benchmarkSizes {
+		data := generateRandom(size)
+		for _, workers := range []int{1, 2, 4, 8} {
+			name := fmt.Sprintf("size=%d/workers=%d", size, workers)
+			if workers == 1 {
+				// прежний последовательный путь, сегменты шифруются в вызывающей горутине
+				name = fmt.Sprintf("size=%d/sequential", size)
+			}
+			b.Run(name, func(b *testing.B) {
+				b.SetBytes(int64(len(data)))
+				for i := 0; i < b.N; i++ {
+					enc, _ := NewEncryptReader(key, bytes.NewReader(data))
+					enc.SetWorkers(workers)
+					if _, err := io.Copy(io.Discard, enc); err != nil {
+						b.Fatal(err)
+					}
+					enc.Close()
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	key := testKey("secret")
+	for _, size := range benchmarkSizes {
+		data := generateRandom(size)
+		enc, _ := NewEncryptReader(key, bytes.NewReader(data))
+		ciphertext, _ := io.ReadAll(enc)
+		inputs := []struct {
+			name       string
+			ciphertext []byte
+		}{
+			{name: "segmented", ciphertext: ciphertext},
+			// данные в устаревшем формате AES-CBC расшифровываются последовательно через устаревший путь
+			{name: "legacy", ciphertext: legacyEncrypt("secret", data)},
+		}
+		for _, in := range inputs {
+			b.Run(fmt.Sprintf("size=%d/%s", size, in.name), func(b *testing.B) {
+				b.SetBytes(int64(len(data)))
+				for i := 0; i < b.N; i++ {
+					dec, _ := NewDecryptReader(key, bytes.NewReader(in.ciphertext))
+					if _, err := io.Copy(io.Discard, dec); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
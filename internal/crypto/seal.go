@@ -0,0 +1,108 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// sealVersion версия формата зашифрованных мета-данных.
+const sealVersion byte = 1
+
+const (
+	// назначения ключей, получаемых из мастер-ключа, для разделения областей их применения
+	sealKeyInfo  = "gophkeeper meta seal"
+	indexKeyInfo = "gophkeeper blind index"
+	checkKeyInfo = "gophkeeper key check"
+)
+
+// derive возвращает ключ для назначения info, полученный из ключа k по HKDF-SHA256.
+func (k *Key) derive(info string) ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, k.key, nil, []byte(info)), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Seal шифрует небольшие данные plaintext, например мета-данные секрета, ключом, полученным из мастер-ключа master.
+// Дополнительные данные ad аутентифицируются, но не шифруются, и должны совпадать при расшифровке.
+// Формат: версия (1 байт) | nonce (24 байта) | XChaCha20-Poly1305(ключ, nonce, plaintext, версия | ad).
+func Seal(master *Key, plaintext []byte, ad []byte) ([]byte, error) {
+	key, err := master.derive(sealKeyInfo)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed := make([]byte, 1+chacha20poly1305.NonceSizeX, 1+chacha20poly1305.NonceSizeX+len(plaintext)+aead.Overhead())
+	sealed[0] = sealVersion
+	if _, err := rand.Read(sealed[1:]); err != nil {
+		return nil, err
+	}
+	return aead.Seal(sealed, sealed[1:], plaintext, append([]byte{sealVersion}, ad...)), nil
+}
+
+// Open расшифровывает данные sealed, зашифрованные Seal, мастер-ключом master.
+func Open(master *Key, sealed []byte, ad []byte) ([]byte, error) {
+	if len(sealed) < 1+chacha20poly1305.NonceSizeX+chacha20poly1305.Overhead || sealed[0] != sealVersion {
+		return nil, ErrInvalidSealed
+	}
+	key, err := master.derive(sealKeyInfo)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := sealed[1 : 1+chacha20poly1305.NonceSizeX]
+	plaintext, err := aead.Open(nil, nonce, sealed[1+chacha20poly1305.NonceSizeX:], append([]byte{sealVersion}, ad...))
+	if err != nil {
+		return nil, ErrInvalidSealed
+	}
+	return plaintext, nil
+}
+
+// BlindIndex возвращает слепой индекс значения value: HMAC-SHA256 с ключом, полученным из мастер-ключа master.
+// Индекс позволяет искать по значению на сервере, не раскрывая само значение.
+func BlindIndex(master *Key, value string) (string, error) {
+	key, err := master.derive(indexKeyInfo)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// KeyCheckValue возвращает контрольное значение ключа key. Значение хранится вместе с хранилищем и позволяет
+// проверить ключ, не раскрывая его и не расшифровывая данные.
+func KeyCheckValue(key *Key) ([]byte, error) {
+	k, err := key.derive(checkKeyInfo)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, k)
+	mac.Write([]byte(checkKeyInfo))
+	return mac.Sum(nil), nil
+}
+
+// VerifyKeyCheckValue проверяет, что контрольное значение kcv получено из ключа key. Иначе возвращает ErrWrongKey.
+func VerifyKeyCheckValue(key *Key, kcv []byte) error {
+	expected, err := KeyCheckValue(key)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(expected, kcv) {
+		return ErrWrongKey
+	}
+	return nil
+}
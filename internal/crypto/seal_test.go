@@ -0,0 +1,63 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeal(t *testing.T) {
+	master := testKey("secret")
+	plaintext := []byte("prod-db-root")
+	sealed, err := Seal(master, plaintext, []byte("id"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(sealed), string(plaintext))
+	got, err := Open(master, sealed, []byte("id"))
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, got)
+
+	// одинаковые данные шифруются по-разному
+	other, err := Seal(master, plaintext, []byte("id"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, sealed, other)
+}
+
+func TestOpenInvalid(t *testing.T) {
+	master := testKey("secret")
+	sealed, err := Seal(master, []byte("prod-db-root"), []byte("id"))
+	assert.NoError(t, err)
+
+	_, err = Open(testKey("other secret"), sealed, []byte("id"))
+	assert.ErrorIs(t, err, ErrInvalidSealed)
+	// зашифрованные данные нельзя перенести в другой секрет
+	_, err = Open(master, sealed, []byte("other id"))
+	assert.ErrorIs(t, err, ErrInvalidSealed)
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = Open(master, tampered, []byte("id"))
+	assert.ErrorIs(t, err, ErrInvalidSealed)
+	_, err = Open(master, sealed[:10], []byte("id"))
+	assert.ErrorIs(t, err, ErrInvalidSealed)
+}
+
+func TestBlindIndex(t *testing.T) {
+	master := testKey("secret")
+	index, err := BlindIndex(master, "prod-db-root")
+	assert.NoError(t, err)
+	assert.NotContains(t, index, "prod-db-root")
+	// индекс детерминирован для одного ключа
+	same, _ := BlindIndex(master, "prod-db-root")
+	assert.Equal(t, index, same)
+	other, _ := BlindIndex(master, "prod-db-user")
+	assert.NotEqual(t, index, other)
+	otherKey, _ := BlindIndex(testKey("other secret"), "prod-db-root")
+	assert.NotEqual(t, index, otherKey)
+}
+
+func TestKeyCheckValue(t *testing.T) {
+	kcv, err := KeyCheckValue(testKey("secret"))
+	assert.NoError(t, err)
+	assert.NoError(t, VerifyKeyCheckValue(testKey("secret"), kcv))
+	assert.ErrorIs(t, VerifyKeyCheckValue(testKey("other secret"), kcv), ErrWrongKey)
+	assert.ErrorIs(t, VerifyKeyCheckValue(testKey("secret"), nil), ErrWrongKey)
+}
@@ -0,0 +1,244 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// BrandVisa платежная система Visa.
+	BrandVisa = "Visa"
+	// BrandMastercard платежная система Mastercard.
+	BrandMastercard = "Mastercard"
+	// BrandAmex платежная система American Express.
+	BrandAmex = "Amex"
+	// BrandMir платежная система Мир.
+	BrandMir = "Mir"
+	// BrandUnknown платежная система не определена.
+	BrandUnknown = "Unknown"
+)
+
+// cardBrand правила определения платежной системы по номеру карты.
+type cardBrand struct {
+	name string
+	// диапазоны префиксов номера (включительно), префиксы в диапазоне одной длины
+	prefixes [][2]int
+	lengths  []int
+	cvv      int
+}
+
+var cardBrands = []cardBrand{
+	{name: BrandAmex, prefixes: [][2]int{{34, 34}, {37, 37}}, lengths: []int{15}, cvv: 4},
+	{name: BrandMir, prefixes: [][2]int{{2200, 2204}}, lengths: []int{16, 17, 18, 19}, cvv: 3},
+	{name: BrandMastercard, prefixes: [][2]int{{51, 55}, {2221, 2720}}, lengths: []int{16}, cvv: 3},
+	{name: BrandVisa, prefixes: [][2]int{{4, 4}}, lengths: []int{13, 16, 19}, cvv: 3},
+}
+
+// CreditCard секрет как "банковская карта". Платежная система и срок действия карты в мета-данные не попадают:
+// открытые мета-данные хранятся и передаются на сервер без шифрования, поэтому они определяются по данным карты.
+type CreditCard struct {
+	Number     string `json:"number"`
+	Holder     string `json:"holder"`
+	CVV        string `json:"cvv"`
+	Expiration string `json:"expiration"`
+}
+
+// Запрос от пользователя данных банковской карты для секрета.
+func (cc *CreditCard) Prompt(value string) error {
+	cc.Number = StringPrompt("Number")
+	cc.Holder = StringPrompt("Holder")
+	cc.CVV = StringPrompt("CVV")
+	cc.Expiration = StringPrompt("Expiration (MM/YY)")
+	// номер и срок действия сохраняются в едином виде
+	cc.Number = normalizeCardNumber(cc.Number)
+	if month, year, err := cc.Expiry(); err == nil {
+		cc.Expiration = fmt.Sprintf("%02d/%02d", month, year%100)
+	}
+	return nil
+}
+
+// Validate проверяет номер карты алгоритмом Луна и по правилам платежной системы, срок действия и длину CVV.
+func (cc *CreditCard) Validate() error {
+	number := normalizeCardNumber(cc.Number)
+	if len(number) < 12 || len(number) > 19 || strings.Trim(number, "0123456789") != "" {
+		return fmt.Errorf("%w: card number must contain from 12 to 19 digits", ErrInvalidSecret)
+	}
+	if !luhn(number) {
+		return fmt.Errorf("%w: card number checksum is wrong", ErrInvalidSecret)
+	}
+	brand := detectCardBrand(number)
+	if brand != nil && !containsInt(brand.lengths, len(number)) {
+		return fmt.Errorf("%w: wrong %s card number length", ErrInvalidSecret, brand.name)
+	}
+	if _, _, err := cc.Expiry(); err != nil {
+		return err
+	}
+	if len(cc.CVV) != 0 {
+		cvv := 0
+		if brand != nil {
+			cvv = brand.cvv
+		}
+		if strings.Trim(cc.CVV, "0123456789") != "" || (cvv != 0 && len(cc.CVV) != cvv) || len(cc.CVV) < 3 || len(cc.CVV) > 4 {
+			return fmt.Errorf("%w: wrong CVV length", ErrInvalidSecret)
+		}
+	}
+	return nil
+}
+
+// Brand возвращает платежную систему карты.
+func (cc *CreditCard) Brand() string {
+	if brand := detectCardBrand(normalizeCardNumber(cc.Number)); brand != nil {
+		return brand.name
+	}
+	return BrandUnknown
+}
+
+// Expiry возвращает месяц и год окончания срока действия карты. Поддерживаются форматы MM/YY, MM/YYYY,
+// MM-YY и MMYY.
+func (cc *CreditCard) Expiry() (month int, year int, err error) {
+	s := strings.ReplaceAll(strings.TrimSpace(cc.Expiration), "-", "/")
+	m, y, ok := strings.Cut(s, "/")
+	if !ok && len(s) == 4 {
+		m, y = s[:2], s[2:]
+	}
+	if month, err = strconv.Atoi(m); err != nil || month < 1 || month > 12 {
+		return 0, 0, fmt.Errorf("%w: wrong expiration month %q", ErrInvalidSecret, cc.Expiration)
+	}
+	if year, err = strconv.Atoi(y); err != nil || (len(y) != 2 && len(y) != 4) {
+		return 0, 0, fmt.Errorf("%w: wrong expiration year %q", ErrInvalidSecret, cc.Expiration)
+	}
+	if len(y) == 2 {
+		year += 2000
+	}
+	return month, year, nil
+}
+
+// Expires возвращает момент окончания срока действия карты: карта действует до конца месяца.
+func (cc *CreditCard) Expires() (time.Time, error) {
+	month, year, err := cc.Expiry()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(year, time.Month(month)+1, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+// Masked возвращает номер карты, в котором видны только последние 4 цифры.
+func (cc *CreditCard) Masked() string {
+	number := normalizeCardNumber(cc.Number)
+	if len(number) <= 4 {
+		return strings.Repeat("*", len(number))
+	}
+	return "**** " + number[len(number)-4:]
+}
+
+func (cc *CreditCard) Bytes() ([]byte, error) {
+	return encodeJSON(cc)
+}
+
+func (cc *CreditCard) Encode() (io.ReadCloser, error) {
+	b, err := cc.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return NewBytesBuffer(b), nil
+}
+
+func (cc *CreditCard) Decode(r io.Reader) error {
+	return json.NewDecoder(r).Decode(cc)
+}
+
+// Render выводит данные карты, скрывая номер и CVV.
+func (cc *CreditCard) Render(w io.Writer) error {
+	return renderFields(w, cc.maskedFields())
+}
+
+// Reveal выводит данные карты полностью.
+func (cc *CreditCard) Reveal(w io.Writer) error {
+	return renderFields(w, cc.fields())
+}
+
+func (cc *CreditCard) Fields() []string {
+	return fieldNames(cc.fields())
+}
+
+func (cc *CreditCard) Field(name string) (string, error) {
+	return fieldValue(cc.fields(), name)
+}
+
+// MaskedField возвращает значение поля name, скрывая номер и CVV.
+func (cc *CreditCard) MaskedField(name string) (string, error) {
+	return fieldValue(cc.maskedFields(), name)
+}
+
+func (cc *CreditCard) fields() []field {
+	return []field{
+		{name: "number", value: cc.Number},
+		{name: "brand", value: cc.Brand()},
+		{name: "holder", value: cc.Holder},
+		{name: "cvv", value: cc.CVV},
+		{name: "expiration", value: cc.Expiration},
+	}
+}
+
+// maskedFields возвращает поля карты со скрытыми номером и CVV.
+func (cc *CreditCard) maskedFields() []field {
+	return []field{
+		{name: "number", value: cc.Masked()},
+		{name: "brand", value: cc.Brand()},
+		{name: "holder", value: cc.Holder},
+		{name: "cvv", value: strings.Repeat("*", len(cc.CVV))},
+		{name: "expiration", value: cc.Expiration},
+	}
+}
+
+// normalizeCardNumber удаляет из номера карты пробелы и дефисы.
+func normalizeCardNumber(number string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(number)
+}
+
+// luhn проверяет контрольную цифру номера алгоритмом Луна.
+func luhn(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// detectCardBrand возвращает платежную систему по номеру карты или nil, если она не определена.
+func detectCardBrand(number string) *cardBrand {
+	for i, b := range cardBrands {
+		for _, p := range b.prefixes {
+			size := len(strconv.Itoa(p[0]))
+			if len(number) < size {
+				continue
+			}
+			prefix, err := strconv.Atoi(number[:size])
+			if err == nil && prefix >= p[0] && prefix <= p[1] {
+				return &cardBrands[i]
+			}
+		}
+	}
+	return nil
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package vault
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreditCardValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		number string
+		cvv    string
+		expiry string
+		brand  string
+		valid  bool
+	}{
+		{name: "visa", number: "4111111111111111", cvv: "123", expiry: "12/25", brand: BrandVisa, valid: true},
+		{name: "visa with spaces", number: "4111 1111 1111 1111", cvv: "123", expiry: "12/25", brand: BrandVisa, valid: true},
+		{name: "mastercard", number: "5555-5555-5555-4444", cvv: "123", expiry: "01/2030", brand: BrandMastercard, valid: true},
+		{name: "mastercard 2-series", number: "2221000000000009", expiry: "0130", brand: BrandMastercard, valid: true},
+		{name: "amex", number: "378282246310005", cvv: "1234", expiry: "06-27", brand: BrandAmex, valid: true},
+		{name: "mir", number: "2200000000000004", cvv: "123", expiry: "06/27", brand: BrandMir, valid: true},
+		{name: "unknown brand", number: "6011111111111117", cvv: "1234", expiry: "06/27", brand: BrandUnknown, valid: true},
+		{name: "wrong checksum", number: "4111111111111112", cvv: "123", expiry: "12/25", brand: BrandVisa},
+		{name: "wrong visa length", number: "400000000000006", cvv: "123", expiry: "12/25", brand: BrandVisa},
+		{name: "too short", number: "4111", expiry: "12/25", brand: BrandVisa},
+		{name: "not digits", number: "4111x11111111111", expiry: "12/25", brand: BrandVisa},
+		{name: "amex with short cvv", number: "378282246310005", cvv: "123", expiry: "06/27", brand: BrandAmex},
+		{name: "visa with long cvv", number: "4111111111111111", cvv: "1234", expiry: "12/25", brand: BrandVisa},
+		{name: "cvv not digits", number: "4111111111111111", cvv: "12a", expiry: "12/25", brand: BrandVisa},
+		{name: "wrong month", number: "4111111111111111", expiry: "13/25", brand: BrandVisa},
+		{name: "wrong year", number: "4111111111111111", expiry: "12/5", brand: BrandVisa},
+		{name: "without expiration", number: "4111111111111111", brand: BrandVisa},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := &CreditCard{Number: tt.number, CVV: tt.cvv, Expiration: tt.expiry}
+			assert.Equal(t, tt.brand, cc.Brand())
+			err := cc.Validate()
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidSecret)
+		})
+	}
+}
+
+func TestCreditCardExpires(t *testing.T) {
+	tests := []struct {
+		expiry string
+		want   time.Time
+	}{
+		{expiry: "12/25", want: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{expiry: "02/2030", want: time.Date(2030, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{expiry: "0627", want: time.Date(2027, time.July, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expiry, func(t *testing.T) {
+			cc := &CreditCard{Expiration: tt.expiry}
+			got, err := cc.Expires()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCreditCardRender(t *testing.T) {
+	cc := &CreditCard{Number: "4111111111111111", Holder: "IVAN IVANOV", CVV: "123", Expiration: "12/25"}
+	assert.Equal(t, "**** 1111", cc.Masked())
+	// данные карты не публикуются в открытых мета-данных
+	_, ok := interface{}(cc).(Describer)
+	assert.False(t, ok)
+
+	// номер и CVV скрываются, пока данные не запрошены явно
+	rendered := &strings.Builder{}
+	assert.NoError(t, cc.Render(rendered))
+	assert.NotContains(t, rendered.String(), cc.Number)
+	assert.NotContains(t, rendered.String(), cc.CVV)
+	revealed := &strings.Builder{}
+	assert.NoError(t, cc.Reveal(revealed))
+	assert.Contains(t, revealed.String(), cc.Number)
+	assert.Contains(t, revealed.String(), cc.CVV)
+}
@@ -0,0 +1,256 @@
+package vault
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	gkcrypto "github.com/k1nky/gophkeeper/internal/crypto"
+)
+
+const (
+	// AttrSubject атрибут мета-данных сертификата: субъект.
+	AttrSubject = "subject"
+	// AttrSANs атрибут мета-данных сертификата: альтернативные имена субъекта через запятую.
+	AttrSANs = "sans"
+	// AttrNotAfter атрибут мета-данных сертификата: окончание срока действия в формате RFC 3339.
+	AttrNotAfter = "not_after"
+)
+
+func init() {
+	RegisterType(Kind{
+		Type:        TypeCertificate,
+		Name:        "cert",
+		Title:       "CERTIFICATE",
+		Compression: gkcrypto.CompressNone,
+		New:         func() Secret { return &Certificate{} },
+	})
+}
+
+// Certificate секрет как "сертификат X.509": цепочка сертификатов и закрытый ключ в PEM.
+// Первым в цепочке идет сертификат, которому соответствует закрытый ключ.
+type Certificate struct {
+	Chain      string `json:"chain"`
+	PrivateKey string `json:"private_key,omitempty"`
+}
+
+// Prompt читает цепочку сертификатов из PEM файла по пути value. Если в файле нет закрытого ключа, то путь
+// к файлу ключа запрашивается у пользователя.
+func (c *Certificate) Prompt(value string) error {
+	if len(value) == 0 {
+		value = StringPrompt("certificate file")
+	}
+	b, err := os.ReadFile(value)
+	if err != nil {
+		return err
+	}
+	c.Chain, c.PrivateKey = splitPEM(b)
+	if len(c.PrivateKey) != 0 {
+		return nil
+	}
+	if path := StringPrompt("private key file (empty if none)"); len(path) != 0 {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, c.PrivateKey = splitPEM(b)
+	}
+	return nil
+}
+
+// Validate проверяет, что цепочка сертификатов разбирается, каждый сертификат подписан следующим за ним
+// и закрытый ключ соответствует первому сертификату.
+func (c *Certificate) Validate() error {
+	chain, err := c.Certificates()
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(chain); i++ {
+		if err := chain[i].CheckSignatureFrom(chain[i+1]); err != nil {
+			return fmt.Errorf("%w: certificate %d is not signed by the next one: %w", ErrInvalidSecret, i, err)
+		}
+	}
+	if len(c.PrivateKey) == 0 {
+		return nil
+	}
+	key, err := c.Signer()
+	if err != nil {
+		return err
+	}
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(chain[0].PublicKey) {
+		return fmt.Errorf("%w: private key does not match certificate", ErrInvalidSecret)
+	}
+	return nil
+}
+
+// Certificates возвращает разобранную цепочку сертификатов.
+func (c *Certificate) Certificates() ([]*x509.Certificate, error) {
+	chain := []*x509.Certificate{}
+	rest := []byte(c.Chain)
+	for {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSecret, err)
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%w: no certificates found", ErrInvalidSecret)
+	}
+	return chain, nil
+}
+
+// Signer возвращает закрытый ключ сертификата. Поддерживаются ключи PKCS #1, PKCS #8 и EC.
+func (c *Certificate) Signer() (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(c.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("%w: no private key found", ErrInvalidSecret)
+	}
+	var (
+		key interface{}
+		err error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSecret, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported private key", ErrInvalidSecret)
+	}
+	return signer, nil
+}
+
+func (c *Certificate) Encode() (io.ReadCloser, error) {
+	b, err := encodeJSON(c)
+	if err != nil {
+		return nil, err
+	}
+	return NewBytesBuffer(b), nil
+}
+
+func (c *Certificate) Decode(r io.Reader) error {
+	return json.NewDecoder(r).Decode(c)
+}
+
+// Render выводит сведения о сертификате без закрытого ключа.
+func (c *Certificate) Render(w io.Writer) error {
+	return renderFields(w, c.fields())
+}
+
+// Reveal выводит цепочку сертификатов и закрытый ключ в PEM.
+func (c *Certificate) Reveal(w io.Writer) error {
+	if _, err := io.WriteString(w, c.Chain); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, c.PrivateKey)
+	return err
+}
+
+func (c *Certificate) Fields() []string {
+	return fieldNames(c.fields())
+}
+
+func (c *Certificate) Field(name string) (string, error) {
+	return fieldValue(c.fields(), name)
+}
+
+// Attributes возвращает субъект, альтернативные имена и срок действия сертификата.
+func (c *Certificate) Attributes() map[string]string {
+	chain, err := c.Certificates()
+	if err != nil {
+		return nil
+	}
+	attrs := map[string]string{
+		AttrSubject:  chain[0].Subject.String(),
+		AttrNotAfter: chain[0].NotAfter.UTC().Format(time.RFC3339),
+	}
+	if sans := subjectAltNames(chain[0]); len(sans) != 0 {
+		attrs[AttrSANs] = strings.Join(sans, ",")
+	}
+	return attrs
+}
+
+func (c *Certificate) fields() []field {
+	chain, err := c.Certificates()
+	if err != nil {
+		return nil
+	}
+	cert := chain[0]
+	fingerprint := sha256.Sum256(cert.Raw)
+	return []field{
+		{name: AttrSubject, value: cert.Subject.String()},
+		{name: AttrSANs, value: strings.Join(subjectAltNames(cert), ",")},
+		{name: "issuer", value: cert.Issuer.String()},
+		{name: "serial", value: cert.SerialNumber.String()},
+		{name: "not_before", value: cert.NotBefore.UTC().Format(time.RFC3339)},
+		{name: AttrNotAfter, value: cert.NotAfter.UTC().Format(time.RFC3339)},
+		{name: "fingerprint", value: hex.EncodeToString(fingerprint[:])},
+	}
+}
+
+// CertificateNotAfter возвращает окончание срока действия сертификата по атрибутам его мета-данных.
+func CertificateNotAfter(m Meta) (time.Time, error) {
+	if m.Type != TypeCertificate {
+		return time.Time{}, fmt.Errorf("%w: %s is not a certificate", ErrUnknownType, m.Type)
+	}
+	value, ok := m.Attributes[AttrNotAfter]
+	if !ok {
+		return time.Time{}, errors.New("certificate expiration is unknown")
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// subjectAltNames возвращает альтернативные имена субъекта сертификата.
+func subjectAltNames(cert *x509.Certificate) []string {
+	names := append([]string{}, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
+
+// splitPEM разделяет PEM данные на сертификаты и закрытый ключ.
+func splitPEM(b []byte) (string, string) {
+	chain, key := strings.Builder{}, strings.Builder{}
+	for {
+		var block *pem.Block
+		if block, b = pem.Decode(b); block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			chain.Write(pem.EncodeToMemory(block))
+		} else if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			key.Write(pem.EncodeToMemory(block))
+		}
+	}
+	return chain.String(), key.String()
+}
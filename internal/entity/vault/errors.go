@@ -8,5 +8,18 @@ var (
 	ErrEmptyMetaID     = errors.New("meta id must be non empty")
 	ErrMetaNotExists   = errors.New("meta does not exist")
 	ErrConflictVersion = errors.New("conflict detected, secret could not be updated")
+	ErrOutdatedVersion = errors.New("secret version is older than the current one")
 	ErrNothingToUpdate = errors.New("nothing to update")
+	ErrNotInitialized  = errors.New("vault is not initialized")
+	ErrRekeyInProgress = errors.New("vault rekey is in progress, run rekey to complete it")
+	ErrSealedMeta      = errors.New("secret meta is sealed, vault key is required")
+	ErrWrongSecret     = errors.New("wrong vault secret")
+	ErrKeyfileRequired = errors.New("vault requires a keyfile")
+	ErrKeyfileNotUsed  = errors.New("vault does not use a keyfile")
+	ErrUnknownType     = errors.New("unknown secret type")
+	ErrUnknownField    = errors.New("unknown secret field")
+	ErrInvalidSecret   = errors.New("invalid secret")
+	ErrNoRevision      = errors.New("secret revision does not exist")
+	ErrVaultMismatch   = errors.New("local vault differs from the remote one, use an empty local vault to join it")
+	ErrVaultRekeyed    = errors.New("vault secret was changed on another device, run rekey with the new secret to apply it")
 )
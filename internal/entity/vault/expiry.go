@@ -0,0 +1,47 @@
+package vault
+
+import (
+	"sort"
+	"time"
+)
+
+// HasExpiry возвращает true, если у секрета задан срок действия.
+func (m Meta) HasExpiry() bool {
+	return m.ExpiresAt != 0
+}
+
+// Expires возвращает срок действия секрета в UTC. Если срок не задан, то возвращает нулевое время.
+func (m Meta) Expires() time.Time {
+	if !m.HasExpiry() {
+		return time.Time{}
+	}
+	return time.Unix(m.ExpiresAt, 0).UTC()
+}
+
+// SetExpires задает срок действия секрета t, нулевое время отменяет срок. Метка истечения срока сбрасывается.
+func (m *Meta) SetExpires(t time.Time) {
+	m.ExpiresAt, m.IsExpired = 0, false
+	if !t.IsZero() {
+		m.ExpiresAt = t.Unix()
+	}
+}
+
+// IsExpiredAt возвращает true, если на момент now срок действия секрета истек.
+func (m Meta) IsExpiredAt(now time.Time) bool {
+	return m.HasExpiry() && m.ExpiresAt <= now.Unix()
+}
+
+// Expiring возвращает неудаленные секреты, срок действия которых истекает в течение within от момента now
+// или уже истек, упорядоченные по сроку действия.
+func (l List) Expiring(now time.Time, within time.Duration) List {
+	list := List{}
+	for _, m := range l {
+		if !m.IsDeleted && m.IsExpiredAt(now.Add(within)) {
+			list = append(list, m)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].ExpiresAt < list[j].ExpiresAt
+	})
+	return list
+}
@@ -0,0 +1,189 @@
+package vault
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// FieldType тип пользовательского поля секрета.
+type FieldType string
+
+const (
+	// FieldText произвольный текст.
+	FieldText FieldType = "text"
+	// FieldHidden конфиденциальный текст, по умолчанию не выводится.
+	FieldHidden FieldType = "hidden"
+	// FieldURL абсолютный URL.
+	FieldURL FieldType = "url"
+	// FieldEmail адрес электронной почты.
+	FieldEmail FieldType = "email"
+	// FieldDate дата в формате YYYY-MM-DD.
+	FieldDate FieldType = "date"
+
+	// FieldDateLayout формат значения поля типа FieldDate.
+	FieldDateLayout = "2006-01-02"
+
+	// AttrFields атрибут мета-данных: имена открытых пользовательских полей через запятую.
+	AttrFields = "fields"
+)
+
+// payloadMagic начало данных секрета с пользовательскими полями. Нулевой байт не встречается в начале
+// текстовых секретов, поэтому данные без полей, сохраненные ранее, читаются как есть.
+const payloadMagic = "\x00gkfields\n"
+
+// CustomField пользовательское поле секрета. Хранится в зашифрованных данных секрета вместе с данными его типа.
+type CustomField struct {
+	Name  string    `json:"name"`
+	Type  FieldType `json:"type"`
+	Value string    `json:"value"`
+}
+
+// CustomFields пользовательские поля секрета.
+type CustomFields []CustomField
+
+// ParseCustomField разбирает поле из строки вида name[:type]=value. По умолчанию поле имеет тип FieldText.
+func ParseCustomField(s string) (CustomField, error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return CustomField{}, fmt.Errorf("%w: field %q must be in form name[:type]=value", ErrInvalidSecret, s)
+	}
+	f := CustomField{Name: name, Type: FieldText, Value: value}
+	if name, t, ok := strings.Cut(name, ":"); ok {
+		f.Name, f.Type = name, FieldType(t)
+	}
+	return f, f.Validate()
+}
+
+// Validate проверяет имя поля и соответствие значения его типу.
+func (f CustomField) Validate() error {
+	if len(f.Name) == 0 || strings.ContainsAny(f.Name, ",=: \t\n") {
+		return fmt.Errorf("%w: invalid field name %q", ErrInvalidSecret, f.Name)
+	}
+	var err error
+	switch f.Type {
+	case FieldText, FieldHidden:
+	case FieldURL:
+		var u *url.URL
+		if u, err = url.Parse(f.Value); err == nil && (!u.IsAbs() || len(u.Host) == 0) {
+			err = errors.New("url must be absolute")
+		}
+	case FieldEmail:
+		_, err = mail.ParseAddress(f.Value)
+	case FieldDate:
+		_, err = time.Parse(FieldDateLayout, f.Value)
+	default:
+		return fmt.Errorf("%w: unknown type %q of field %s", ErrInvalidSecret, f.Type, f.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: field %s: %v", ErrInvalidSecret, f.Name, err)
+	}
+	return nil
+}
+
+// Validate проверяет поля и уникальность их имен.
+func (fs CustomFields) Validate() error {
+	seen := map[string]bool{}
+	for _, f := range fs {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("%w: field %s is set twice", ErrInvalidSecret, f.Name)
+		}
+		seen[f.Name] = true
+	}
+	return nil
+}
+
+// Get возвращает поле с именем name.
+func (fs CustomFields) Get(name string) (CustomField, bool) {
+	for _, f := range fs {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return CustomField{}, false
+}
+
+// Names возвращает имена полей, кроме скрытых. Их можно хранить в открытых мета-данных.
+func (fs CustomFields) Names() []string {
+	names := []string{}
+	for _, f := range fs {
+		if f.Type != FieldHidden {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// Masked возвращает копию полей, в которой значения скрытых полей заменены звездочками, если reveal равен false.
+func (fs CustomFields) Masked(reveal bool) CustomFields {
+	if reveal || fs == nil {
+		return fs
+	}
+	masked := make(CustomFields, 0, len(fs))
+	for _, f := range fs {
+		if f.Type == FieldHidden {
+			f.Value = strings.Repeat("*", 8)
+		}
+		masked = append(masked, f)
+	}
+	return masked
+}
+
+// Render выводит поля по одному на строке. Значения скрытых полей выводятся только при reveal.
+func (fs CustomFields) Render(w io.Writer, reveal bool) error {
+	fields := make([]field, 0, len(fs))
+	for _, f := range fs.Masked(reveal) {
+		fields = append(fields, field{name: f.Name, value: f.Value})
+	}
+	return renderFields(w, fields)
+}
+
+// EncodePayload возвращает читатель данных секрета s вместе с пользовательскими полями fields.
+// Если полей нет, то данные секрета возвращаются без изменений.
+func EncodePayload(s Secret, fields CustomFields) (io.ReadCloser, error) {
+	data, err := s.Encode()
+	if err != nil || len(fields) == 0 {
+		return data, err
+	}
+	header := bytes.NewBufferString(payloadMagic)
+	if err := json.NewEncoder(header).Encode(fields); err != nil {
+		data.Close()
+		return nil, err
+	}
+	return payloadReader{Reader: io.MultiReader(header, data), Closer: data}, nil
+}
+
+// DecodePayload восстанавливает из r данные секрета s и возвращает его пользовательские поля.
+func DecodePayload(s Secret, r io.Reader) (CustomFields, error) {
+	br := bufio.NewReader(r)
+	var fields CustomFields
+	// короткие данные не могут содержать полей, ошибку чтения вернет декодер секрета
+	if magic, _ := br.Peek(len(payloadMagic)); string(magic) == payloadMagic {
+		if _, err := br.Discard(len(payloadMagic)); err != nil {
+			return nil, err
+		}
+		line, err := br.ReadBytes('\n')
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(line, &fields); err != nil {
+			return nil, err
+		}
+	}
+	return fields, s.Decode(br)
+}
+
+type payloadReader struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,104 @@
+package vault
+
+import (
+	"sort"
+	"strings"
+)
+
+// Filter условия отбора секретов. Пустые условия не ограничивают список.
+type Filter struct {
+	// Секрет должен иметь все указанные метки
+	Tags []string
+	// Секрет должен находиться в папке или в одной из ее вложенных папок
+	Folder string
+	// Секрет должен иметь один из указанных типов
+	Types []SecretType
+}
+
+// IsEmpty возвращает true, если фильтр не ограничивает список.
+func (f Filter) IsEmpty() bool {
+	return len(f.Tags) == 0 && len(NormalizeFolder(f.Folder)) == 0 && len(f.Types) == 0
+}
+
+// Match возвращает true, если мета-данные m удовлетворяют фильтру.
+func (f Filter) Match(m Meta) bool {
+	if len(f.Types) != 0 {
+		found := false
+		for _, t := range f.Types {
+			if t == m.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !InFolder(m.Folder, f.Folder) {
+		return false
+	}
+	for _, tag := range NormalizeTags(f.Tags) {
+		if !m.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter возвращает мета-данные списка, удовлетворяющие фильтру f.
+func (l List) Filter(f Filter) List {
+	if f.IsEmpty() {
+		return l
+	}
+	list := List{}
+	for _, m := range l {
+		if f.Match(m) {
+			list = append(list, m)
+		}
+	}
+	return list
+}
+
+// HasTag возвращает true, если секрет имеет метку tag.
+func (m Meta) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTags возвращает упорядоченные уникальные метки в нижнем регистре без пустых значений.
+func NormalizeTags(tags []string) []string {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if len(tag) == 0 || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// NormalizeFolder возвращает путь папки без пустых элементов и начального и конечного "/".
+func NormalizeFolder(folder string) string {
+	parts := []string{}
+	for _, p := range strings.Split(folder, "/") {
+		if p = strings.TrimSpace(p); len(p) != 0 {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// InFolder возвращает true, если папка folder совпадает с папкой parent или вложена в нее.
+func InFolder(folder string, parent string) bool {
+	folder, parent = NormalizeFolder(folder), NormalizeFolder(parent)
+	return len(parent) == 0 || folder == parent || strings.HasPrefix(folder, parent+"/")
+}
@@ -0,0 +1,67 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	assert.Equal(t, []string{"home", "work"}, NormalizeTags([]string{" Work", "home", "", "WORK "}))
+	assert.Equal(t, []string{}, NormalizeTags(nil))
+}
+
+func TestInFolder(t *testing.T) {
+	tests := []struct {
+		folder string
+		parent string
+		want   bool
+	}{
+		{folder: "web/mail", parent: "", want: true},
+		{folder: "web/mail", parent: "web", want: true},
+		{folder: "/web/mail/", parent: "web/", want: true},
+		{folder: "web/mail", parent: "web/mail", want: true},
+		{folder: "web", parent: "web/mail", want: false},
+		// папка с общим префиксом имени не вложена
+		{folder: "website", parent: "web", want: false},
+		{folder: "", parent: "web", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.folder+"|"+tt.parent, func(t *testing.T) {
+			assert.Equal(t, tt.want, InFolder(tt.folder, tt.parent))
+		})
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	list := List{
+		{ID: "1", Type: TypeLoginPassword, Tags: []string{"work"}, Folder: "web/mail"},
+		{ID: "2", Type: TypeLoginPassword, Tags: []string{"home", "work"}, Folder: "web"},
+		{ID: "3", Type: TypeCreditCard, Tags: []string{"home"}},
+		{ID: "4", Type: TypeText, Folder: "notes"},
+	}
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []MetaID
+	}{
+		{name: "empty", filter: Filter{}, want: []MetaID{"1", "2", "3", "4"}},
+		{name: "blank folder", filter: Filter{Folder: "/"}, want: []MetaID{"1", "2", "3", "4"}},
+		{name: "tag", filter: Filter{Tags: []string{"Work"}}, want: []MetaID{"1", "2"}},
+		{name: "all tags", filter: Filter{Tags: []string{"work", "home"}}, want: []MetaID{"2"}},
+		{name: "folder", filter: Filter{Folder: "web"}, want: []MetaID{"1", "2"}},
+		{name: "subfolder", filter: Filter{Folder: "web/mail"}, want: []MetaID{"1"}},
+		{name: "types", filter: Filter{Types: []SecretType{TypeCreditCard, TypeText}}, want: []MetaID{"3", "4"}},
+		{name: "combined", filter: Filter{Tags: []string{"home"}, Types: []SecretType{TypeLoginPassword}}, want: []MetaID{"2"}},
+		{name: "nothing", filter: Filter{Tags: []string{"unknown"}}, want: []MetaID{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []MetaID{}
+			for _, m := range list.Filter(tt.filter) {
+				got = append(got, m.ID)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
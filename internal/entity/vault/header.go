@@ -0,0 +1,55 @@
+package vault
+
+import (
+	"bytes"
+
+	"github.com/k1nky/gophkeeper/internal/crypto"
+)
+
+// Header заголовок хранилища секретов. Содержит параметры, общие для всех секретов хранилища.
+type Header struct {
+	// Параметры формирования ключа шифрования из пароля
+	KDF crypto.KDFParams
+	// Контрольное значение ключа, позволяет обнаружить неверный секрет до расшифровки данных
+	Check []byte
+	// Для открытия хранилища кроме пароля требуется ключевой файл
+	Keyfile bool
+	// Новый заголовок хранилища на время смены ключа. Служит журналом смены ключа: пока он задан,
+	// секреты хранилища могут быть зашифрованы как старым, так и новым ключом.
+	Pending *Header
+	// Заголовок опубликован в удаленном хранилище и используется всеми устройствами пользователя.
+	// Признак хранится только локально.
+	Shared bool
+	// Опубликованный заголовок, который после смены ключа хранилища заменяется этим заголовком. Пока задан, новый
+	// заголовок еще не опубликован в удаленном хранилище. Хранится только локально.
+	Replaces *Header
+}
+
+// SameVault возвращает true, если заголовки h и other принадлежат одному хранилищу, то есть дают одинаковый ключ
+// для одних и тех же учетных данных.
+func (h Header) SameVault(other Header) bool {
+	return h.KDF.Equal(other.KDF) && bytes.Equal(h.Check, other.Check) && h.Keyfile == other.Keyfile
+}
+
+// Published возвращает заголовок h без локальных признаков, в том виде, в котором он публикуется в удаленном хранилище.
+func (h Header) Published() Header {
+	return Header{KDF: h.KDF, Check: h.Check, Keyfile: h.Keyfile}
+}
+
+// Credentials учетные данные для открытия хранилища.
+type Credentials struct {
+	// Пароль
+	Secret string
+	// Хеш ключевого файла (crypto.ReadKeyfile), пустой, если ключевой файл не используется
+	Keyfile []byte
+}
+
+// Key возвращает ключ хранилища, полученный из учетных данных c с параметрами params.
+func (c Credentials) Key(params crypto.KDFParams) (*crypto.Key, error) {
+	return crypto.DeriveCompositeKey(c.Secret, c.Keyfile, params)
+}
+
+// HasKeyfile возвращает true, если учетные данные содержат ключевой файл.
+func (c Credentials) HasKeyfile() bool {
+	return len(c.Keyfile) != 0
+}
@@ -0,0 +1,90 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/k1nky/gophkeeper/internal/crypto"
+	"github.com/k1nky/gophkeeper/internal/passgen"
+)
+
+func init() {
+	RegisterType(Kind{
+		Type:        TypePasswordPolicy,
+		Name:        "policy",
+		Title:       "PASSWORD_POLICY",
+		Compression: crypto.CompressNone,
+		New:         func() Secret { return &PasswordPolicy{} },
+	})
+}
+
+// PolicyAliasPrefix префикс псевдонимов секретов с правилами формирования паролей. Префикс отделяет
+// имена правил от псевдонимов остальных секретов.
+const PolicyAliasPrefix = "policy:"
+
+// PasswordPolicy секрет как "именованные правила формирования паролей". Правила хранятся в хранилище,
+// чтобы их можно было использовать повторно и синхронизировать между клиентами.
+type PasswordPolicy struct {
+	passgen.Policy
+}
+
+// PolicyAlias возвращает псевдоним секрета с правилами name.
+func PolicyAlias(name string) string {
+	return PolicyAliasPrefix + name
+}
+
+// PolicyName возвращает имя правил по псевдониму секрета alias.
+func PolicyName(alias string) string {
+	return strings.TrimPrefix(alias, PolicyAliasPrefix)
+}
+
+// Prompt принимает в value правила в формате JSON. Если value не задано, то используются правила по умолчанию.
+func (p *PasswordPolicy) Prompt(value string) error {
+	if len(value) == 0 {
+		p.Policy = passgen.DefaultPolicy()
+		return nil
+	}
+	if err := json.Unmarshal([]byte(value), &p.Policy); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSecret, err)
+	}
+	return nil
+}
+
+func (p *PasswordPolicy) Validate() error {
+	if err := p.Policy.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSecret, err)
+	}
+	return nil
+}
+
+func (p *PasswordPolicy) Encode() (io.ReadCloser, error) {
+	b, err := encodeJSON(p)
+	if err != nil {
+		return nil, err
+	}
+	return NewBytesBuffer(b), nil
+}
+
+func (p *PasswordPolicy) Decode(r io.Reader) error {
+	return json.NewDecoder(r).Decode(p)
+}
+
+func (p *PasswordPolicy) Render(w io.Writer) error {
+	return renderFields(w, p.fields())
+}
+
+func (p *PasswordPolicy) Fields() []string {
+	return fieldNames(p.fields())
+}
+
+func (p *PasswordPolicy) Field(name string) (string, error) {
+	return fieldValue(p.fields(), name)
+}
+
+func (p *PasswordPolicy) fields() []field {
+	return []field{
+		{name: "policy", value: p.Policy.String()},
+	}
+}
@@ -0,0 +1,184 @@
+package vault
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/k1nky/gophkeeper/internal/crypto"
+	"github.com/k1nky/gophkeeper/internal/passgen"
+)
+
+// Secret данные секрета одного из зарегистрированных типов.
+type Secret interface {
+	// Prompt заполняет данные секрета из значения value, указанного в командной строке,
+	// и запрашивает у пользователя недостающие данные.
+	Prompt(value string) error
+	// Validate проверяет корректность данных секрета.
+	Validate() error
+	// Encode возвращает читатель сериализованных данных секрета. Читатель закрывает потребитель.
+	Encode() (io.ReadCloser, error)
+	// Decode восстанавливает данные секрета из сериализованных данных r.
+	Decode(r io.Reader) error
+	// Render выводит данные секрета в w в виде, удобном для чтения человеком.
+	Render(w io.Writer) error
+	// Fields возвращает имена полей данных секрета.
+	Fields() []string
+	// Field возвращает значение поля name данных секрета.
+	Field(name string) (string, error)
+}
+
+// Generator реализуют типы секретов, данные которых можно сформировать автоматически.
+type Generator interface {
+	// Generate формирует новые данные секрета с параметрами opts.
+	Generate(opts GenerateOptions) error
+}
+
+// Revealer реализуют типы секретов, которые при выводе по умолчанию скрывают чувствительные данные.
+type Revealer interface {
+	// Reveal выводит в w данные секрета полностью.
+	Reveal(w io.Writer) error
+}
+
+// Masker реализуют типы секретов, поля которых содержат чувствительные данные, скрываемые при выводе по умолчанию.
+type Masker interface {
+	// MaskedField возвращает значение поля name в том виде, в котором оно выводится по умолчанию.
+	MaskedField(name string) (string, error)
+}
+
+// Describer реализуют типы секретов, которые публикуют в мета-данных неконфиденциальные атрибуты своих данных.
+type Describer interface {
+	// Attributes возвращает атрибуты данных секрета для мета-данных.
+	Attributes() map[string]string
+}
+
+// GenerateOptions параметры формирования данных секрета.
+type GenerateOptions struct {
+	// Алгоритм формирования, зависит от типа секрета
+	Algorithm string
+	// Размер ключа в битах
+	Bits int
+	// Комментарий к секрету
+	Comment string
+	// Правила формирования пароля
+	Policy passgen.Policy
+}
+
+// Kind описание типа секрета в реестре типов.
+type Kind struct {
+	// Тип секрета, сохраняется в мета-данных и передается по сети, поэтому не должен меняться
+	Type SecretType
+	// Короткое имя типа для команд клиента
+	Name string
+	// Имя типа в хранилищах мета-данных и при выводе
+	Title string
+	// Режим сжатия данных секрета перед шифрованием
+	Compression crypto.Compression
+	// Конструктор пустых данных секрета
+	New func() Secret
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[SecretType]Kind{}
+)
+
+// RegisterType добавляет тип секрета в реестр. Паникует, если тип с таким же номером или именем уже
+// зарегистрирован, т.к. это ошибка программиста.
+func RegisterType(k Kind) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if k.New == nil || len(k.Name) == 0 || len(k.Title) == 0 {
+		panic(fmt.Sprintf("vault: invalid secret type %d", k.Type))
+	}
+	for _, v := range registry {
+		if v.Type == k.Type || v.Name == k.Name || v.Title == k.Title {
+			panic(fmt.Sprintf("vault: secret type %s registered twice", k.Name))
+		}
+	}
+	registry[k.Type] = k
+}
+
+// LookupType возвращает описание типа секрета t.
+func LookupType(t SecretType) (Kind, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	k, ok := registry[t]
+	return k, ok
+}
+
+// LookupTypeName возвращает описание типа секрета по его короткому имени или имени в хранилище.
+func LookupTypeName(name string) (Kind, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, k := range registry {
+		if strings.EqualFold(k.Name, name) || strings.EqualFold(k.Title, name) {
+			return k, true
+		}
+	}
+	return Kind{}, false
+}
+
+// Types возвращает все зарегистрированные типы секретов, упорядоченные по номеру.
+func Types() []Kind {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	kinds := make([]Kind, 0, len(registry))
+	for _, k := range registry {
+		kinds = append(kinds, k)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i].Type < kinds[j].Type
+	})
+	return kinds
+}
+
+// TypeNames возвращает короткие имена всех зарегистрированных типов секретов.
+func TypeNames() []string {
+	names := []string{}
+	for _, k := range Types() {
+		names = append(names, k.Name)
+	}
+	return names
+}
+
+// ParseSecretType возвращает тип секрета по его короткому имени или имени в хранилище.
+func ParseSecretType(name string) (SecretType, error) {
+	k, ok := LookupTypeName(name)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownType, name)
+	}
+	return k.Type, nil
+}
+
+// New возвращает пустые данные секрета типа t.
+func (t SecretType) New() (Secret, error) {
+	k, ok := LookupType(t)
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownType, t)
+	}
+	return k.New(), nil
+}
+
+// IsKnown возвращает true, если тип t зарегистрирован.
+func (t SecretType) IsKnown() bool {
+	_, ok := LookupType(t)
+	return ok
+}
+
+func (t SecretType) String() string {
+	if k, ok := LookupType(t); ok {
+		return k.Title
+	}
+	return "UNKNOWN"
+}
+
+// Compression возвращает режим сжатия данных секрета типа t перед шифрованием.
+func (t SecretType) Compression() crypto.Compression {
+	if k, ok := LookupType(t); ok {
+		return k.Compression
+	}
+	return crypto.CompressNone
+}
@@ -0,0 +1,111 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/k1nky/gophkeeper/internal/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypes(t *testing.T) {
+	// типы упорядочены по номеру
+	kinds := Types()
+	for i, k := range kinds {
+		if i > 0 {
+			assert.Less(t, kinds[i-1].Type, k.Type)
+		}
+		assert.NotNil(t, k.New)
+	}
+	// номера и имена основных типов не меняются, т.к. хранятся в мета-данных
+	names := map[SecretType]string{TypeText: "text", TypeLoginPassword: "login", TypeCreditCard: "card", TypeFile: "file"}
+	for i, want := range []SecretType{TypeText, TypeLoginPassword, TypeCreditCard, TypeFile} {
+		assert.Equal(t, SecretType(i), want)
+		k, ok := LookupType(want)
+		assert.True(t, ok)
+		assert.Equal(t, names[want], k.Name)
+	}
+}
+
+func TestParseSecretType(t *testing.T) {
+	tests := []struct {
+		name string
+		want SecretType
+		err  error
+	}{
+		{name: "text", want: TypeText},
+		{name: "LOGIN_PASSWORD", want: TypeLoginPassword},
+		{name: "Card", want: TypeCreditCard},
+		{name: "FILE", want: TypeFile},
+		{name: "unknown", err: ErrUnknownType},
+		{name: "", err: ErrUnknownType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSecretType(tt.name)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSecretTypeKind(t *testing.T) {
+	assert.True(t, TypeFile.IsKnown())
+	assert.Equal(t, "FILE", TypeFile.String())
+	assert.Equal(t, crypto.CompressAuto, TypeFile.Compression())
+	assert.Equal(t, crypto.CompressNone, TypeLoginPassword.Compression())
+	s, err := TypeLoginPassword.New()
+	assert.NoError(t, err)
+	assert.IsType(t, &LoginPassword{}, s)
+
+	unknown := SecretType(1000)
+	assert.False(t, unknown.IsKnown())
+	assert.Equal(t, "UNKNOWN", unknown.String())
+	assert.Equal(t, crypto.CompressNone, unknown.Compression())
+	_, err = unknown.New()
+	assert.ErrorIs(t, err, ErrUnknownType)
+}
+
+func TestRegisterTypeInvalid(t *testing.T) {
+	newText := func() Secret { return &Text{} }
+	tests := []struct {
+		name string
+		kind Kind
+	}{
+		{name: "without constructor", kind: Kind{Type: 100, Name: "new", Title: "NEW"}},
+		{name: "without name", kind: Kind{Type: 100, Title: "NEW", New: newText}},
+		{name: "without title", kind: Kind{Type: 100, Name: "new", New: newText}},
+		{name: "duplicate type", kind: Kind{Type: TypeText, Name: "new", Title: "NEW", New: newText}},
+		{name: "duplicate name", kind: Kind{Type: 100, Name: "text", Title: "NEW", New: newText}},
+		{name: "duplicate title", kind: Kind{Type: 100, Name: "new", Title: "TEXT", New: newText}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Panics(t, func() { RegisterType(tt.kind) })
+		})
+	}
+	assert.False(t, SecretType(100).IsKnown())
+}
+
+func TestLoginPasswordFields(t *testing.T) {
+	s, err := TypeLoginPassword.New()
+	assert.NoError(t, err)
+	lp := s.(*LoginPassword)
+	lp.Login, lp.Password = "user", "p@ssw0rd"
+	r, err := lp.Encode()
+	assert.NoError(t, err)
+	defer r.Close()
+
+	decoded := &LoginPassword{}
+	assert.NoError(t, decoded.Decode(r))
+	assert.Equal(t, lp, decoded)
+	assert.Equal(t, []string{"login", "password"}, decoded.Fields())
+	v, err := decoded.Field("password")
+	assert.NoError(t, err)
+	assert.Equal(t, "p@ssw0rd", v)
+	_, err = decoded.Field("unknown")
+	assert.ErrorIs(t, err, ErrUnknownField)
+}
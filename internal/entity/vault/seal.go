@@ -0,0 +1,75 @@
+package vault
+
+import (
+	"encoding/json"
+
+	"github.com/k1nky/gophkeeper/internal/crypto"
+)
+
+// sealedFields поля мета-данных, которые шифруются на клиенте.
+type sealedFields struct {
+	Alias      string            `json:"alias"`
+	Extra      string            `json:"extra"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Tags       []string          `json:"tags,omitempty"`
+	Folder     string            `json:"folder,omitempty"`
+}
+
+// AliasIndex возвращает слепой индекс псевдонима alias для ключа хранилища key. По индексу сервер находит секрет,
+// псевдоним и дополнительные данные которого зашифрованы.
+//
+// Ключи шифрования мета-данных и слепого индекса получаются из ключа хранилища, а он из заголовка, общего для всех
+// устройств пользователя (Header.Shared). Поэтому мета-данные, зашифрованные на одном устройстве, расшифровываются
+// на другом, а индекс псевдонима на всех устройствах совпадает. После смены ключа хранилища мета-данные и индексы
+// на сервере обновляются вместе с секретами, которые отправляются туда с новыми версиями.
+func AliasIndex(key *crypto.Key, alias string) (string, error) {
+	if len(alias) == 0 {
+		return "", nil
+	}
+	return crypto.BlindIndex(key, alias)
+}
+
+// IsSealed возвращает true, если псевдоним и дополнительные данные секрета зашифрованы.
+func (m Meta) IsSealed() bool {
+	return len(m.Sealed) != 0
+}
+
+// Seal возвращает копию мета-данных, в которой псевдоним и дополнительные данные зашифрованы ключом хранилища key,
+// а вместо псевдонима указан его слепой индекс. ИД секрета аутентифицируется вместе с зашифрованными данными,
+// поэтому их нельзя перенести в другой секрет.
+func (m Meta) Seal(key *crypto.Key) (Meta, error) {
+	if m.IsSealed() {
+		return m, nil
+	}
+	b, err := json.Marshal(sealedFields{Alias: m.Alias, Extra: m.Extra, Attributes: m.Attributes, Tags: m.Tags, Folder: m.Folder})
+	if err != nil {
+		return m, err
+	}
+	sealed, err := crypto.Seal(key, b, []byte(m.ID))
+	if err != nil {
+		return m, err
+	}
+	index, err := AliasIndex(key, m.Alias)
+	if err != nil {
+		return m, err
+	}
+	m.Alias, m.Extra, m.Attributes, m.Tags, m.Folder, m.Sealed = index, "", nil, nil, "", sealed
+	return m, nil
+}
+
+// Unseal возвращает копию мета-данных с псевдонимом и дополнительными данными, расшифрованными ключом хранилища key.
+func (m Meta) Unseal(key *crypto.Key) (Meta, error) {
+	if !m.IsSealed() {
+		return m, nil
+	}
+	b, err := crypto.Open(key, m.Sealed, []byte(m.ID))
+	if err != nil {
+		return m, err
+	}
+	f := sealedFields{}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return m, err
+	}
+	m.Alias, m.Extra, m.Attributes, m.Tags, m.Folder, m.Sealed = f.Alias, f.Extra, f.Attributes, f.Tags, f.Folder, nil
+	return m, nil
+}
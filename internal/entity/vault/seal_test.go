@@ -0,0 +1,99 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/k1nky/gophkeeper/internal/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+// testKey возвращает ключ хранилища, полученный из пароля secret с минимальными параметрами, чтобы не замедлять тесты.
+func testKey(secret string) *crypto.Key {
+	key, err := crypto.DeriveKey(secret, crypto.KDFParams{
+		Algorithm: crypto.KDFArgon2id,
+		Salt:      []byte("salt"),
+		Time:      1,
+		Memory:    64,
+		Threads:   1,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func TestSeal(t *testing.T) {
+	key := testKey("secret")
+	m := Meta{
+		ID:         "id#1",
+		Alias:      "alias",
+		Extra:      "extra",
+		DataID:     "data",
+		Type:       TypeText,
+		Attributes: map[string]string{"user": "admin"},
+		Tags:       []string{"work"},
+		Folder:     "personal",
+	}
+	sealed, err := m.Seal(key)
+	assert.NoError(t, err)
+	assert.True(t, sealed.IsSealed())
+	// вместо псевдонима слепой индекс, остальные конфиденциальные поля пустые
+	index, err := AliasIndex(key, "alias")
+	assert.NoError(t, err)
+	assert.Equal(t, index, sealed.Alias)
+	assert.Empty(t, sealed.Extra)
+	assert.Nil(t, sealed.Attributes)
+	assert.Nil(t, sealed.Tags)
+	assert.Empty(t, sealed.Folder)
+	// неконфиденциальные поля не меняются
+	assert.Equal(t, m.ID, sealed.ID)
+	assert.Equal(t, m.DataID, sealed.DataID)
+	assert.Equal(t, m.Type, sealed.Type)
+	// повторное шифрование ничего не меняет
+	again, err := sealed.Seal(key)
+	assert.NoError(t, err)
+	assert.Equal(t, sealed, again)
+
+	opened, err := sealed.Unseal(key)
+	assert.NoError(t, err)
+	assert.Equal(t, m, opened)
+	// расшифровка открытых мета-данных ничего не меняет
+	again, err = opened.Unseal(key)
+	assert.NoError(t, err)
+	assert.Equal(t, m, again)
+}
+
+func TestUnsealFailed(t *testing.T) {
+	key := testKey("secret")
+	sealed, err := Meta{ID: "id#1", Alias: "alias", Extra: "extra"}.Seal(key)
+	assert.NoError(t, err)
+
+	_, err = sealed.Unseal(testKey("other secret"))
+	assert.Error(t, err)
+	// зашифрованные данные нельзя перенести в другой секрет
+	moved := sealed
+	moved.ID = "id#2"
+	_, err = moved.Unseal(key)
+	assert.Error(t, err)
+}
+
+func TestAliasIndex(t *testing.T) {
+	key := testKey("secret")
+	index, err := AliasIndex(key, "")
+	assert.NoError(t, err)
+	assert.Empty(t, index)
+
+	i1, err := AliasIndex(key, "alias")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "alias", i1)
+	// индекс одинаковый для одного ключа и разный для разных ключей и псевдонимов
+	i2, err := AliasIndex(key, "alias")
+	assert.NoError(t, err)
+	assert.Equal(t, i1, i2)
+	i3, err := AliasIndex(testKey("other secret"), "alias")
+	assert.NoError(t, err)
+	assert.NotEqual(t, i1, i3)
+	i4, err := AliasIndex(key, "other alias")
+	assert.NoError(t, err)
+	assert.NotEqual(t, i1, i4)
+}
@@ -0,0 +1,195 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/k1nky/gophkeeper/internal/crypto"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// SSHKeyEd25519 ключ SSH Ed25519, используется по умолчанию.
+	SSHKeyEd25519 = "ed25519"
+	// SSHKeyRSA ключ SSH RSA.
+	SSHKeyRSA = "rsa"
+	// DefaultRSABits размер ключа RSA по умолчанию.
+	DefaultRSABits = 3072
+	// minRSABits минимальный размер ключа RSA, который можно сформировать.
+	minRSABits = 2048
+)
+
+func init() {
+	RegisterType(Kind{
+		Type:        TypeSSHKey,
+		Name:        "ssh",
+		Title:       "SSH_KEY",
+		Compression: crypto.CompressNone,
+		New:         func() Secret { return &SSHKey{} },
+	})
+}
+
+// SSHKey секрет как "пара ключей SSH". При выводе показывается открытый ключ, закрытый только по явному запросу.
+type SSHKey struct {
+	// Закрытый ключ в формате OpenSSH (PEM), зашифрован Passphrase, если она задана
+	PrivateKey string `json:"private_key"`
+	// Открытый ключ в формате authorized_keys
+	PublicKey  string `json:"public_key"`
+	Comment    string `json:"comment,omitempty"`
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+// Prompt импортирует ключ из файла OpenSSH по пути value. Если ключ защищен паролем, то он запрашивается у пользователя.
+// Комментарий берется из файла открытого ключа value.pub, если такой есть.
+func (k *SSHKey) Prompt(value string) error {
+	if len(value) == 0 {
+		value = StringPrompt("private key file")
+	}
+	b, err := os.ReadFile(value)
+	if err != nil {
+		return err
+	}
+	k.PrivateKey = string(b)
+	key, err := ssh.ParseRawPrivateKey(b)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		k.Passphrase = StringPrompt("passphrase")
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase(b, []byte(k.Passphrase))
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSecret, err)
+	}
+	if pub, err := os.ReadFile(value + ".pub"); err == nil {
+		if _, comment, _, _, err := ssh.ParseAuthorizedKey(pub); err == nil {
+			k.Comment = comment
+		}
+	}
+	return k.setPublicKey(key)
+}
+
+// Generate формирует новую пару ключей алгоритмом opts.Algorithm (ed25519 или rsa).
+func (k *SSHKey) Generate(opts GenerateOptions) error {
+	var (
+		key interface{}
+		err error
+	)
+	switch opts.Algorithm {
+	case "", SSHKeyEd25519:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case SSHKeyRSA:
+		bits := opts.Bits
+		if bits == 0 {
+			bits = DefaultRSABits
+		}
+		if bits < minRSABits {
+			return fmt.Errorf("%w: rsa key must be at least %d bits", ErrInvalidSecret, minRSABits)
+		}
+		key, err = rsa.GenerateKey(rand.Reader, bits)
+	default:
+		return fmt.Errorf("%w: unsupported ssh key type %s", ErrInvalidSecret, opts.Algorithm)
+	}
+	if err != nil {
+		return err
+	}
+	block, err := ssh.MarshalPrivateKey(key, opts.Comment)
+	if err != nil {
+		return err
+	}
+	k.PrivateKey = string(pem.EncodeToMemory(block))
+	k.Comment = opts.Comment
+	k.Passphrase = ""
+	return k.setPublicKey(key)
+}
+
+// Validate проверяет, что закрытый ключ читается и соответствует открытому.
+func (k *SSHKey) Validate() error {
+	key, err := k.RawPrivateKey()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSecret, err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSecret, err)
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k.PublicKey))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSecret, err)
+	}
+	if !bytes.Equal(pub.Marshal(), signer.PublicKey().Marshal()) {
+		return fmt.Errorf("%w: public key does not match private key", ErrInvalidSecret)
+	}
+	return nil
+}
+
+// RawPrivateKey возвращает закрытый ключ (*rsa.PrivateKey, *ed25519.PrivateKey и т.п.).
+func (k *SSHKey) RawPrivateKey() (interface{}, error) {
+	if len(k.Passphrase) != 0 {
+		return ssh.ParseRawPrivateKeyWithPassphrase([]byte(k.PrivateKey), []byte(k.Passphrase))
+	}
+	return ssh.ParseRawPrivateKey([]byte(k.PrivateKey))
+}
+
+func (k *SSHKey) Encode() (io.ReadCloser, error) {
+	b, err := encodeJSON(k)
+	if err != nil {
+		return nil, err
+	}
+	return NewBytesBuffer(b), nil
+}
+
+func (k *SSHKey) Decode(r io.Reader) error {
+	return json.NewDecoder(r).Decode(k)
+}
+
+// Render выводит открытый ключ.
+func (k *SSHKey) Render(w io.Writer) error {
+	_, err := fmt.Fprintln(w, k.PublicKey)
+	return err
+}
+
+// Reveal выводит закрытый ключ в том виде, в котором его можно сохранить в файл.
+func (k *SSHKey) Reveal(w io.Writer) error {
+	_, err := io.WriteString(w, k.PrivateKey)
+	return err
+}
+
+func (k *SSHKey) Fields() []string {
+	return fieldNames(k.fields())
+}
+
+func (k *SSHKey) Field(name string) (string, error) {
+	return fieldValue(k.fields(), name)
+}
+
+// fields возвращает поля секрета. Закрытый ключ и пароль к нему в поля не входят.
+func (k *SSHKey) fields() []field {
+	f := []field{
+		{name: "public_key", value: k.PublicKey},
+		{name: "comment", value: k.Comment},
+	}
+	if pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k.PublicKey)); err == nil {
+		f = append(f, field{name: "type", value: pub.Type()}, field{name: "fingerprint", value: ssh.FingerprintSHA256(pub)})
+	}
+	return f
+}
+
+func (k *SSHKey) setPublicKey(key interface{}) error {
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSecret, err)
+	}
+	k.PublicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	if len(k.Comment) != 0 {
+		k.PublicKey += " " + k.Comment
+	}
+	return nil
+}
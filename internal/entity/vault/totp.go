@@ -0,0 +1,120 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k1nky/gophkeeper/internal/crypto"
+	"github.com/k1nky/gophkeeper/internal/otp"
+)
+
+func init() {
+	RegisterType(Kind{
+		Type:        TypeTOTP,
+		Name:        "totp",
+		Title:       "TOTP",
+		Compression: crypto.CompressNone,
+		New:         func() Secret { return &TOTP{} },
+	})
+}
+
+// TOTP секрет как "генератор одноразовых паролей" (RFC 6238). При выводе вместо секрета показывается текущий код.
+type TOTP struct {
+	Secret    string `json:"secret"`
+	Issuer    string `json:"issuer,omitempty"`
+	Account   string `json:"account,omitempty"`
+	Algorithm string `json:"algorithm"`
+	Digits    int    `json:"digits"`
+	Period    int    `json:"period"`
+}
+
+// Prompt принимает в value URI otpauth:// или секрет в base32. Если value не задано, то запрашивает его у пользователя.
+func (t *TOTP) Prompt(value string) error {
+	if len(value) == 0 {
+		value = StringPrompt("otpauth URI or secret")
+	}
+	k := otp.NewKey(value)
+	if strings.HasPrefix(value, "otpauth://") {
+		var err error
+		if k, err = otp.ParseURI(value); err != nil {
+			return err
+		}
+	}
+	t.setKey(k)
+	return nil
+}
+
+func (t *TOTP) Validate() error {
+	if err := t.key().Validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSecret, err)
+	}
+	return nil
+}
+
+func (t *TOTP) Encode() (io.ReadCloser, error) {
+	b, err := encodeJSON(t)
+	if err != nil {
+		return nil, err
+	}
+	return NewBytesBuffer(b), nil
+}
+
+func (t *TOTP) Decode(r io.Reader) error {
+	return json.NewDecoder(r).Decode(t)
+}
+
+func (t *TOTP) Render(w io.Writer) error {
+	return renderFields(w, t.fields(time.Now()))
+}
+
+func (t *TOTP) Fields() []string {
+	return fieldNames(t.fields(time.Now()))
+}
+
+func (t *TOTP) Field(name string) (string, error) {
+	return fieldValue(t.fields(time.Now()), name)
+}
+
+// Code возвращает код, действующий в момент now, и время до его смены.
+func (t *TOTP) Code(now time.Time) (string, time.Duration, error) {
+	k := t.key()
+	code, err := k.Code(now)
+	if err != nil {
+		return "", 0, err
+	}
+	return code, k.Remaining(now), nil
+}
+
+// fields возвращает поля секрета в момент now. Сам секрет не выводится.
+func (t *TOTP) fields(now time.Time) []field {
+	code, remaining, _ := t.Code(now)
+	return []field{
+		{name: "code", value: code},
+		{name: "remaining", value: strconv.Itoa(int(remaining.Seconds()))},
+		{name: "issuer", value: t.Issuer},
+		{name: "account", value: t.Account},
+		{name: "algorithm", value: t.Algorithm},
+		{name: "digits", value: strconv.Itoa(t.Digits)},
+		{name: "period", value: strconv.Itoa(t.Period)},
+	}
+}
+
+func (t *TOTP) key() otp.Key {
+	return otp.Key{
+		Secret:    t.Secret,
+		Issuer:    t.Issuer,
+		Account:   t.Account,
+		Algorithm: t.Algorithm,
+		Digits:    t.Digits,
+		Period:    t.Period,
+	}
+}
+
+func (t *TOTP) setKey(k otp.Key) {
+	t.Secret, t.Issuer, t.Account = k.Secret, k.Issuer, k.Account
+	t.Algorithm, t.Digits, t.Period = k.Algorithm, k.Digits, k.Period
+}
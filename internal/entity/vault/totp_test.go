@@ -0,0 +1,84 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k1nky/gophkeeper/internal/otp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTPPrompt(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  TOTP
+		err   error
+	}{
+		{
+			name:  "secret",
+			value: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+			want:  TOTP{Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", Algorithm: otp.AlgorithmSHA1, Digits: otp.DefaultDigits, Period: otp.DefaultPeriod},
+		},
+		{
+			name:  "uri",
+			value: "otpauth://totp/ACME:john@example.com?secret=GEZDGNBVGY3TQOJQ&issuer=ACME&digits=8&period=60",
+			want:  TOTP{Secret: "GEZDGNBVGY3TQOJQ", Issuer: "ACME", Account: "john@example.com", Algorithm: otp.AlgorithmSHA1, Digits: 8, Period: 60},
+		},
+		{name: "invalid uri", value: "otpauth://totp/john", err: otp.ErrInvalidSecret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TOTP{}
+			err := got.Prompt(tt.value)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, got.Validate())
+		})
+	}
+}
+
+func TestTOTPValidate(t *testing.T) {
+	// ошибки параметров TOTP оборачиваются в ErrInvalidSecret, чтобы клиент обрабатывал их как любые неверные данные
+	tests := []struct {
+		name string
+		totp TOTP
+		err  error
+	}{
+		{name: "bad secret", totp: TOTP{Secret: "1111", Algorithm: otp.AlgorithmSHA1, Digits: 6, Period: 30}, err: otp.ErrInvalidSecret},
+		{name: "bad algorithm", totp: TOTP{Secret: "GEZDGNBVGY3TQOJQ", Algorithm: "MD5", Digits: 6, Period: 30}, err: otp.ErrInvalidAlgorithm},
+		{name: "bad digits", totp: TOTP{Secret: "GEZDGNBVGY3TQOJQ", Algorithm: otp.AlgorithmSHA1, Digits: 4, Period: 30}, err: otp.ErrInvalidDigits},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.totp.Validate()
+			assert.ErrorIs(t, err, ErrInvalidSecret)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestTOTPCode(t *testing.T) {
+	// тестовое значение из RFC 6238, приложение B
+	totp := TOTP{Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", Algorithm: otp.AlgorithmSHA1, Digits: 8, Period: 30}
+	code, remaining, err := totp.Code(time.Unix(59, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, "94287082", code)
+	assert.Equal(t, time.Second, remaining)
+
+	// секрет не выводится, а после сериализации код не меняется
+	assert.NotContains(t, totp.Fields(), "secret")
+	r, err := totp.Encode()
+	assert.NoError(t, err)
+	defer r.Close()
+	decoded := TOTP{}
+	assert.NoError(t, decoded.Decode(r))
+	assert.Equal(t, totp, decoded)
+	again, _, err := decoded.Code(time.Unix(59, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, code, again)
+}
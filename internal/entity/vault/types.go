@@ -5,11 +5,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+
+	"github.com/k1nky/gophkeeper/internal/crypto"
+	"github.com/k1nky/gophkeeper/internal/passgen"
 )
 
-// SecretType тип секрета.
+// SecretType тип секрета. Описание каждого типа хранится в реестре типов (см. RegisterType).
 type SecretType int
 
 const (
@@ -21,78 +25,268 @@ const (
 	TypeCreditCard
 	// Файл
 	TypeFile
+	// Секрет одноразовых паролей TOTP
+	TypeTOTP
+	// Пара ключей SSH
+	TypeSSHKey
+	// Сертификат X.509 с закрытым ключом
+	TypeCertificate
+	// Правила формирования паролей
+	TypePasswordPolicy
 )
 
+func init() {
+	RegisterType(Kind{
+		Type:        TypeText,
+		Name:        "text",
+		Title:       "TEXT",
+		Compression: crypto.CompressAuto,
+		New:         func() Secret { return &Text{} },
+	})
+	RegisterType(Kind{
+		Type:        TypeLoginPassword,
+		Name:        "login",
+		Title:       "LOGIN_PASSWORD",
+		Compression: crypto.CompressNone,
+		New:         func() Secret { return &LoginPassword{} },
+	})
+	RegisterType(Kind{
+		Type:        TypeCreditCard,
+		Name:        "card",
+		Title:       "CREDIT_CARD",
+		Compression: crypto.CompressNone,
+		New:         func() Secret { return &CreditCard{} },
+	})
+	RegisterType(Kind{
+		Type:        TypeFile,
+		Name:        "file",
+		Title:       "FILE",
+		Compression: crypto.CompressAuto,
+		New:         func() Secret { return &File{} },
+	})
+}
+
+// Text секрет как "простой текст"
+type Text struct {
+	value string
+	r     io.Reader
+}
+
+// File секрет как "файл". Данные файла не загружаются в память, а читаются потоком.
+type File struct {
+	Path string
+	r    io.Reader
+}
+
 // LoginPassword секрет как "логин-пароль"
 type LoginPassword struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
 }
 
-// CreditCard секрет как "банковская карта"
-type CreditCard struct {
-	Number     string `json:"number"`
-	Holder     string `json:"holder"`
-	CVV        string `json:"cvv"`
-	Expiration string `json:"expiration"`
+// field поле данных секрета.
+type field struct {
+	name  string
+	value string
 }
 
+// stdin общий буферизированный читатель стандартного ввода. Отдельный буфер на каждый запрос
+// терял бы прочитанные заранее строки, если ввод перенаправлен из файла или канала.
+var stdin = bufio.NewReader(os.Stdin)
+
 // StringPrompt запращивает ввод данных как строку от пользователя
 func StringPrompt(label string) string {
-	var s string
-	r := bufio.NewReader(os.Stdin)
+	var (
+		s   string
+		err error
+	)
 	for {
 		fmt.Fprint(os.Stderr, label+" ")
-		s, _ = r.ReadString('\n')
-		if s != "" {
+		s, err = stdin.ReadString('\n')
+		if s != "" || err != nil {
 			break
 		}
 	}
 	return strings.TrimSpace(s)
 }
 
+// Запрос от пользователя текста секрета, если он не указан в value.
+func (t *Text) Prompt(value string) error {
+	t.value = value
+	if len(t.value) == 0 {
+		t.value = StringPrompt("text")
+	}
+	return nil
+}
+
+func (t *Text) Validate() error {
+	return nil
+}
+
+func (t *Text) Encode() (io.ReadCloser, error) {
+	return NewBytesBuffer([]byte(t.value)), nil
+}
+
+// Decode запоминает читатель данных секрета, текст читается только при выводе.
+func (t *Text) Decode(r io.Reader) error {
+	t.r = r
+	return nil
+}
+
+func (t *Text) Render(w io.Writer) error {
+	return render(w, t.r, t.value)
+}
+
+func (t *Text) Fields() []string {
+	return nil
+}
+
+func (t *Text) Field(name string) (string, error) {
+	return "", fmt.Errorf("%w: %s", ErrUnknownField, name)
+}
+
+// Путь к файлу секрета указывается в value.
+func (f *File) Prompt(value string) error {
+	f.Path = value
+	return nil
+}
+
+func (f *File) Validate() error {
+	if len(f.Path) == 0 {
+		return fmt.Errorf("%w: file path must be non empty", ErrInvalidSecret)
+	}
+	return nil
+}
+
+func (f *File) Encode() (io.ReadCloser, error) {
+	return os.OpenFile(f.Path, os.O_RDONLY, 0660)
+}
+
+// Decode запоминает читатель данных секрета, файл читается только при выводе.
+func (f *File) Decode(r io.Reader) error {
+	f.r = r
+	return nil
+}
+
+func (f *File) Render(w io.Writer) error {
+	return render(w, f.r, "")
+}
+
+func (f *File) Fields() []string {
+	return nil
+}
+
+func (f *File) Field(name string) (string, error) {
+	return "", fmt.Errorf("%w: %s", ErrUnknownField, name)
+}
+
 // Запрос от пользователя логина-пароля для секрета.
-func (lp *LoginPassword) Prompt() error {
+func (lp *LoginPassword) Prompt(value string) error {
 	lp.Login = StringPrompt("login")
 	lp.Password = StringPrompt("password")
 	return nil
 }
 
+// Generate запрашивает у пользователя логин и формирует пароль по правилам opts.Policy
+// или по правилам по умолчанию, если они не заданы.
+func (lp *LoginPassword) Generate(opts GenerateOptions) error {
+	policy := opts.Policy
+	if policy == (passgen.Policy{}) {
+		policy = passgen.DefaultPolicy()
+	}
+	password, err := passgen.Generate(policy)
+	if err != nil {
+		return err
+	}
+	lp.Login = StringPrompt("login")
+	lp.Password = password
+	return nil
+}
+
+func (lp *LoginPassword) Validate() error {
+	if len(lp.Login) == 0 {
+		return fmt.Errorf("%w: login must be non empty", ErrInvalidSecret)
+	}
+	return nil
+}
+
 func (lp *LoginPassword) Bytes() ([]byte, error) {
-	buf := bytes.NewBuffer(nil)
-	if err := json.NewEncoder(buf).Encode(lp); err != nil {
+	return encodeJSON(lp)
+}
+
+func (lp *LoginPassword) Encode() (io.ReadCloser, error) {
+	b, err := lp.Bytes()
+	if err != nil {
 		return nil, err
 	}
-	return buf.Bytes(), nil
+	return NewBytesBuffer(b), nil
 }
 
-// Запрос от пользователя данных банковской карты для секрета.
-func (cc *CreditCard) Prompt() error {
-	cc.Number = StringPrompt("Number")
-	cc.Holder = StringPrompt("Holder")
-	cc.CVV = StringPrompt("CVV")
-	cc.Expiration = StringPrompt("Expiration")
-	return nil
+func (lp *LoginPassword) Decode(r io.Reader) error {
+	return json.NewDecoder(r).Decode(lp)
 }
 
-func (cc *CreditCard) Bytes() ([]byte, error) {
+func (lp *LoginPassword) Render(w io.Writer) error {
+	return renderFields(w, lp.fields())
+}
+
+func (lp *LoginPassword) Fields() []string {
+	return fieldNames(lp.fields())
+}
+
+func (lp *LoginPassword) Field(name string) (string, error) {
+	return fieldValue(lp.fields(), name)
+}
+
+func (lp *LoginPassword) fields() []field {
+	return []field{
+		{name: "login", value: lp.Login},
+		{name: "password", value: lp.Password},
+	}
+}
+
+// encodeJSON сериализует данные структурированного секрета v.
+func encodeJSON(v interface{}) ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
-	if err := json.NewEncoder(buf).Encode(cc); err != nil {
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
 		return nil, err
 	}
 	return buf.Bytes(), nil
 }
 
-func (t SecretType) String() string {
-	switch t {
-	case TypeText:
-		return "TEXT"
-	case TypeLoginPassword:
-		return "LOGIN_PASSWORD"
-	case TypeCreditCard:
-		return "CREDIT_CARD"
-	case TypeFile:
-		return "FILE"
+// render выводит в w данные секрета из читателя r, а если он не задан, то value.
+func render(w io.Writer, r io.Reader, value string) error {
+	if r == nil {
+		_, err := io.WriteString(w, value)
+		return err
+	}
+	_, err := io.Copy(w, r)
+	return err
+}
+
+// renderFields выводит в w поля данных секрета по одному на строке.
+func renderFields(w io.Writer, fields []field) error {
+	for _, f := range fields {
+		if _, err := fmt.Fprintf(w, "%s: %s\n", f.name, f.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fieldNames(fields []field) []string {
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+func fieldValue(fields []field, name string) (string, error) {
+	for _, f := range fields {
+		if f.name == name {
+			return f.value, nil
+		}
 	}
-	return "UNKNOWN"
+	return "", fmt.Errorf("%w: %s", ErrUnknownField, name)
 }
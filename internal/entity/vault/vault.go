@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"time"
 
@@ -45,10 +46,31 @@ type Meta struct {
 	IsDeleted bool
 	// Тип секрета
 	Type SecretType
-	// Версия секрета. Алгоритм повышения версии должен работать с учетом того, что клиенты могут быть на разных хостах.
+	// Номер версии секрета по гибридным логическим часам (см. NewRevision), уникален в пределах секрета.
 	Revision int64
+	// Вектор версий секрета, по которому изменения с разных устройств упорядочиваются без учета часов (см. Compare).
+	Version Version
 	// ИД пользователя владельца секрета
 	UserID user.ID
+	// Ключ данных секрета, зашифрованный мастер-ключом хранилища. Пустой для секретов,
+	// данные которых зашифрованы непосредственно мастер-ключом.
+	WrappedKey []byte
+	// Псевдоним и дополнительные данные, зашифрованные на клиенте. Если заданы, то Alias содержит
+	// слепой индекс псевдонима, а Extra, Attributes, Tags и Folder пустые.
+	Sealed []byte
+	// Неконфиденциальные атрибуты, которые определяются по данным секрета его типом
+	// (например, срок действия сертификата). Шифруются на клиенте вместе с псевдонимом.
+	Attributes map[string]string
+	// Метки секрета (см. NormalizeTags). Шифруются на клиенте вместе с псевдонимом.
+	Tags []string
+	// Папка секрета в виде пути через "/" (см. NormalizeFolder), пустая для корня.
+	// Шифруется на клиенте вместе с псевдонимом.
+	Folder string
+	// Срок действия или плановой смены секрета в секундах Unix, 0 если не задан. Не шифруется на клиенте,
+	// чтобы сервер мог отслеживать истекшие секреты.
+	ExpiresAt int64
+	// Метка истечения срока, устанавливается службой хранения секретов (см. ExpiresAt)
+	IsExpired bool
 }
 
 // Список мета-данных секретов.
@@ -75,6 +97,11 @@ func (d *DataReader) Read(p []byte) (n int, err error) {
 	return d.reader.Read(p)
 }
 
+// Peek возвращает следующие n байт данных, не продвигая читатель.
+func (d *DataReader) Peek(n int) ([]byte, error) {
+	return d.reader.Peek(n)
+}
+
 func (d *DataReader) WriteTo(w io.Writer) (n int64, err error) {
 	return d.reader.WriteTo(w)
 }
@@ -93,12 +120,30 @@ func NewMetaID() MetaID {
 }
 
 func (m Meta) String() string {
-	return fmt.Sprintf("%s %s %s %d", m.ID, m.Alias, m.Type, m.Revision)
+	s := fmt.Sprintf("%s %s %s %d", m.ID, m.Alias, m.Type, m.Revision)
+	names := make([]string, 0, len(m.Attributes))
+	for name := range m.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s += fmt.Sprintf(" %s=%s", name, m.Attributes[name])
+	}
+	if len(m.Folder) != 0 {
+		s += " folder=" + m.Folder
+	}
+	if len(m.Tags) != 0 {
+		s += " tags=" + strings.Join(m.Tags, ",")
+	}
+	if m.ExpiresAt != 0 {
+		s += " expires=" + m.Expires().Format(time.RFC3339)
+	}
+	return s
 }
 
-// CanUpdated возвращает true если секрет может быть обновлен секретом update.
+// CanUpdated возвращает true если секрет может быть обновлен секретом update, т.е. update новее секрета (см. Compare).
 func (m Meta) CanUpdated(update Meta) bool {
-	return m.ID == update.ID && update.Revision > m.Revision
+	return m.ID == update.ID && update.Compare(m) == Newer
 }
 
 // Equal возвращает true если идентификаторы и версии m и target равны.
@@ -106,13 +151,6 @@ func (m Meta) Equal(target Meta) bool {
 	return m.ID == target.ID && m.Revision == target.Revision
 }
 
-// NewRevision возвращает номер для новой версии секрета. Возможен конфликт, если несколько клиентов обновят секрет
-// с одним ИД с точностью до секунды. В рамках учебного проекта, данным фактом считаю можно пренебречь.
-func NewRevision() int64 {
-	// используем UTC чтобы не привязываться к верменной зоне клиента
-	return time.Now().UTC().Unix()
-}
-
 func (l List) String() string {
 	s := strings.Builder{}
 	for _, v := range l {
@@ -0,0 +1,163 @@
+package vault
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Order порядок двух версий секрета.
+type Order int
+
+const (
+	// Equal версии совпадают
+	Equal Order = iota
+	// Newer версия содержит все изменения другой версии и свои собственные
+	Newer
+	// Older версия предшествует другой версии
+	Older
+	// Concurrent версии изменены независимо друг от друга, например, на разных устройствах без синхронизации
+	Concurrent
+)
+
+func (o Order) String() string {
+	switch o {
+	case Equal:
+		return "equal"
+	case Newer:
+		return "newer"
+	case Older:
+		return "older"
+	}
+	return "concurrent"
+}
+
+const (
+	// количество младших бит номера версии, которые занимает логический счетчик
+	logicalBits = 16
+	// номера версий меньше этого значения назначены до перехода на гибридные часы и содержат время в секундах
+	legacyRevisionLimit = 1 << 40
+)
+
+// hlc последний номер версии, выданный или полученный гибридными логическими часами.
+var hlc struct {
+	sync.Mutex
+	last int64
+}
+
+// NewRevision возвращает номер для новой версии секрета по гибридным логическим часам: в старших битах время
+// в миллисекундах, а в младших счетчик, который увеличивается, если время не изменилось или отстает
+// от уже выданного номера. Поэтому номера, выданные одним процессом, не повторяются даже в пределах миллисекунды.
+func NewRevision() int64 {
+	// используем UTC чтобы не привязываться к верменной зоне клиента
+	physical := time.Now().UTC().UnixMilli() << logicalBits
+	hlc.Lock()
+	defer hlc.Unlock()
+	if physical > hlc.last {
+		hlc.last = physical
+	} else {
+		hlc.last++
+	}
+	return hlc.last
+}
+
+// NextRevision возвращает номер новой версии секрета, который гарантированно больше текущей версии current,
+// даже если current назначен на устройстве, часы которого спешат.
+func NextRevision(current int64) int64 {
+	hlc.Lock()
+	if current > hlc.last {
+		hlc.last = current
+	}
+	hlc.Unlock()
+	return NewRevision()
+}
+
+// RevisionTime возвращает время создания версии revision. Учитываются номера, назначенные до перехода
+// на гибридные часы.
+func RevisionTime(revision int64) time.Time {
+	if revision < legacyRevisionLimit {
+		return time.Unix(revision, 0).UTC()
+	}
+	return time.UnixMilli(revision >> logicalBits).UTC()
+}
+
+// NewDeviceID возвращает новый случайный ИД устройства для векторов версий.
+func NewDeviceID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// Version вектор версий секрета: количество изменений секрета, сделанных на каждом устройстве, по ИД устройства.
+// Пустой вектор у секретов, созданных до появления векторов версий.
+type Version map[string]uint64
+
+// Next возвращает вектор версий, следующий за v после изменения секрета на устройстве device.
+func (v Version) Next(device string) Version {
+	next := make(Version, len(v)+1)
+	for d, n := range v {
+		next[d] = n
+	}
+	next[device]++
+	return next
+}
+
+// Compare возвращает порядок вектора версий v относительно вектора w. Отсутствующее устройство
+// считается устройством без изменений.
+func (v Version) Compare(w Version) Order {
+	var newer, older bool
+	for d, n := range v {
+		if n > w[d] {
+			newer = true
+		}
+	}
+	for d, n := range w {
+		if n > v[d] {
+			older = true
+		}
+	}
+	switch {
+	case newer && older:
+		return Concurrent
+	case newer:
+		return Newer
+	case older:
+		return Older
+	}
+	return Equal
+}
+
+func (v Version) String() string {
+	devices := make([]string, 0, len(v))
+	for d := range v {
+		devices = append(devices, d)
+	}
+	sort.Strings(devices)
+	s := make([]string, 0, len(devices))
+	for _, d := range devices {
+		s = append(s, d+":"+strconv.FormatUint(v[d], 10))
+	}
+	return strings.Join(s, ",")
+}
+
+// Compare возвращает порядок версии секрета m относительно версии target. Версии упорядочиваются по векторам версий,
+// поэтому независимые изменения на разных устройствах определяются как Concurrent независимо от показаний часов.
+// Если векторы совпадают (например, у секретов без векторов версий), то версии сравниваются по номеру.
+func (m Meta) Compare(target Meta) Order {
+	if o := m.Version.Compare(target.Version); o != Equal {
+		return o
+	}
+	switch {
+	case m.Revision > target.Revision:
+		return Newer
+	case m.Revision < target.Revision:
+		return Older
+	}
+	return Equal
+}
@@ -0,0 +1,86 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		v    Version
+		w    Version
+		want Order
+	}{
+		{name: "empty", v: nil, w: Version{}, want: Equal},
+		{name: "equal", v: Version{"a": 1, "b": 2}, w: Version{"a": 1, "b": 2}, want: Equal},
+		{name: "newer", v: Version{"a": 2, "b": 2}, w: Version{"a": 1, "b": 2}, want: Newer},
+		{name: "newer with new device", v: Version{"a": 1, "b": 1}, w: Version{"a": 1}, want: Newer},
+		{name: "newer than legacy", v: Version{"a": 1}, w: nil, want: Newer},
+		{name: "older", v: Version{"a": 1}, w: Version{"a": 1, "b": 1}, want: Older},
+		{name: "concurrent", v: Version{"a": 2}, w: Version{"a": 1, "b": 1}, want: Concurrent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.v.Compare(tt.w))
+		})
+	}
+}
+
+func TestVersionNext(t *testing.T) {
+	v := Version{"a": 1}
+	next := v.Next("b")
+	assert.Equal(t, Version{"a": 1, "b": 1}, next)
+	assert.Equal(t, "a:1,b:1", next.String())
+	// исходный вектор не изменяется
+	assert.Equal(t, Version{"a": 1}, v)
+	assert.Equal(t, Version{"a": 1}, Version(nil).Next("a"))
+}
+
+func TestMetaCompare(t *testing.T) {
+	tests := []struct {
+		name   string
+		m      Meta
+		target Meta
+		want   Order
+	}{
+		// независимые изменения не упорядочиваются по показаниям часов
+		{name: "concurrent", m: Meta{Revision: 20, Version: Version{"a": 2}}, target: Meta{Revision: 10, Version: Version{"a": 1, "b": 1}}, want: Concurrent},
+		{name: "newer with smaller revision", m: Meta{Revision: 10, Version: Version{"a": 2}}, target: Meta{Revision: 20, Version: Version{"a": 1}}, want: Newer},
+		{name: "legacy newer", m: Meta{Revision: 20}, target: Meta{Revision: 10}, want: Newer},
+		{name: "legacy older", m: Meta{Revision: 10}, target: Meta{Revision: 20}, want: Older},
+		{name: "equal", m: Meta{Revision: 10, Version: Version{"a": 1}}, target: Meta{Revision: 10, Version: Version{"a": 1}}, want: Equal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.m.Compare(tt.target))
+		})
+	}
+}
+
+func TestNewRevision(t *testing.T) {
+	start := time.Now().Truncate(time.Millisecond)
+	// номера не повторяются даже в пределах одной миллисекунды
+	last := NewRevision()
+	for i := 0; i < 1000; i++ {
+		r := NewRevision()
+		assert.Greater(t, r, last)
+		last = r
+	}
+	assert.False(t, RevisionTime(last).Before(start))
+}
+
+func TestNextRevision(t *testing.T) {
+	// версия с устройства, часы которого спешат на час
+	ahead := time.Now().Add(time.Hour).UnixMilli() << logicalBits
+	next := NextRevision(ahead)
+	assert.Greater(t, next, ahead)
+	assert.Greater(t, NewRevision(), next)
+
+	// номер версии в секундах, назначенный до перехода на гибридные часы, всегда меньше нового
+	legacy := time.Now().Unix()
+	assert.Greater(t, NextRevision(legacy), legacy)
+	assert.Equal(t, time.Unix(legacy, 0).UTC(), RevisionTime(legacy))
+}
@@ -0,0 +1,180 @@
+// Package otp реализует формирование одноразовых паролей TOTP (RFC 6238).
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// AlgorithmSHA1 алгоритм HMAC-SHA1, используется большинством сервисов.
+	AlgorithmSHA1 = "SHA1"
+	// AlgorithmSHA256 алгоритм HMAC-SHA256.
+	AlgorithmSHA256 = "SHA256"
+	// AlgorithmSHA512 алгоритм HMAC-SHA512.
+	AlgorithmSHA512 = "SHA512"
+
+	// DefaultDigits количество цифр кода по умолчанию.
+	DefaultDigits = 6
+	// DefaultPeriod период действия кода по умолчанию в секундах.
+	DefaultPeriod = 30
+)
+
+var (
+	ErrInvalidSecret    = errors.New("invalid otp secret")
+	ErrInvalidAlgorithm = errors.New("unsupported otp algorithm")
+	ErrInvalidDigits    = errors.New("otp digits must be from 6 to 8")
+	ErrInvalidPeriod    = errors.New("otp period must be positive")
+	ErrInvalidURI       = errors.New("invalid otpauth uri")
+)
+
+// Key параметры формирования кодов TOTP.
+type Key struct {
+	// Общий секрет в base32
+	Secret string
+	// Сервис, выдавший секрет
+	Issuer string
+	// Учетная запись в сервисе
+	Account string
+	// Алгоритм HMAC
+	Algorithm string
+	// Количество цифр кода
+	Digits int
+	// Период действия кода в секундах
+	Period int
+}
+
+// NewKey возвращает параметры TOTP с секретом secret и остальными параметрами по умолчанию.
+func NewKey(secret string) Key {
+	return Key{
+		Secret:    secret,
+		Algorithm: AlgorithmSHA1,
+		Digits:    DefaultDigits,
+		Period:    DefaultPeriod,
+	}
+}
+
+// ParseURI разбирает URI вида otpauth://totp/Issuer:account?secret=...&issuer=...&algorithm=...&digits=...&period=...
+func ParseURI(uri string) (Key, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return Key{}, fmt.Errorf("%w: %w", ErrInvalidURI, err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" {
+		return Key{}, fmt.Errorf("%w: only otpauth://totp is supported", ErrInvalidURI)
+	}
+	q := u.Query()
+	k := NewKey(q.Get("secret"))
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		k.Issuer, k.Account = strings.TrimSpace(issuer), strings.TrimSpace(account)
+	} else {
+		k.Account = label
+	}
+	// параметр issuer имеет приоритет перед префиксом в метке
+	if issuer := q.Get("issuer"); len(issuer) != 0 {
+		k.Issuer = issuer
+	}
+	if algorithm := q.Get("algorithm"); len(algorithm) != 0 {
+		k.Algorithm = strings.ToUpper(algorithm)
+	}
+	if digits := q.Get("digits"); len(digits) != 0 {
+		if k.Digits, err = strconv.Atoi(digits); err != nil {
+			return Key{}, fmt.Errorf("%w: %w", ErrInvalidDigits, err)
+		}
+	}
+	if period := q.Get("period"); len(period) != 0 {
+		if k.Period, err = strconv.Atoi(period); err != nil {
+			return Key{}, fmt.Errorf("%w: %w", ErrInvalidPeriod, err)
+		}
+	}
+	if err := k.Validate(); err != nil {
+		return Key{}, err
+	}
+	return k, nil
+}
+
+// Validate проверяет корректность параметров TOTP.
+func (k Key) Validate() error {
+	if _, err := k.secret(); err != nil {
+		return err
+	}
+	if _, err := k.hash(); err != nil {
+		return err
+	}
+	if k.Digits < 6 || k.Digits > 8 {
+		return ErrInvalidDigits
+	}
+	if k.Period <= 0 {
+		return ErrInvalidPeriod
+	}
+	return nil
+}
+
+// Code возвращает код, действующий в момент t.
+func (k Key) Code(t time.Time) (string, error) {
+	if err := k.Validate(); err != nil {
+		return "", err
+	}
+	secret, _ := k.secret()
+	h, _ := k.hash()
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(t.Unix())/uint64(k.Period))
+	mac := hmac.New(h, secret)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+	// динамическое усечение (RFC 4226, раздел 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < k.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", k.Digits, value%mod), nil
+}
+
+// Remaining возвращает время, через которое код, действующий в момент t, сменится.
+func (k Key) Remaining(t time.Time) time.Duration {
+	if k.Period <= 0 {
+		return 0
+	}
+	period := int64(k.Period)
+	return time.Duration(period-t.Unix()%period) * time.Second
+}
+
+// secret возвращает общий секрет. Секреты часто записывают в нижнем регистре, с пробелами и без выравнивания.
+func (k Key) secret() ([]byte, error) {
+	s := strings.ToUpper(strings.ReplaceAll(k.Secret, " ", ""))
+	s = strings.TrimRight(s, "=")
+	if len(s) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	b, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSecret, err)
+	}
+	return b, nil
+}
+
+func (k Key) hash() (func() hash.Hash, error) {
+	switch k.Algorithm {
+	case AlgorithmSHA1:
+		return sha1.New, nil
+	case AlgorithmSHA256:
+		return sha256.New, nil
+	case AlgorithmSHA512:
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInvalidAlgorithm, k.Algorithm)
+}
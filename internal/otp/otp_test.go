@@ -0,0 +1,99 @@
+package otp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCode(t *testing.T) {
+	// тестовые значения из RFC 6238, приложение B
+	seeds := map[string]string{
+		AlgorithmSHA1:   "12345678901234567890",
+		AlgorithmSHA256: "12345678901234567890123456789012",
+		AlgorithmSHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	cases := []struct {
+		time      int64
+		algorithm string
+		expected  string
+	}{
+		{time: 59, algorithm: AlgorithmSHA1, expected: "94287082"},
+		{time: 59, algorithm: AlgorithmSHA256, expected: "46119246"},
+		{time: 59, algorithm: AlgorithmSHA512, expected: "90693936"},
+		{time: 1111111109, algorithm: AlgorithmSHA1, expected: "07081804"},
+		{time: 1111111109, algorithm: AlgorithmSHA256, expected: "68084774"},
+		{time: 1111111109, algorithm: AlgorithmSHA512, expected: "25091201"},
+		{time: 1234567890, algorithm: AlgorithmSHA1, expected: "89005924"},
+		{time: 1234567890, algorithm: AlgorithmSHA256, expected: "91819424"},
+		{time: 1234567890, algorithm: AlgorithmSHA512, expected: "93441116"},
+		{time: 20000000000, algorithm: AlgorithmSHA1, expected: "65353130"},
+	}
+	for _, tt := range cases {
+		k := NewKey(base32.StdEncoding.EncodeToString([]byte(seeds[tt.algorithm])))
+		k.Algorithm = tt.algorithm
+		k.Digits = 8
+		code, err := k.Code(time.Unix(tt.time, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, code, "time %d, algorithm %s", tt.time, tt.algorithm)
+	}
+}
+
+func TestCodeDigits(t *testing.T) {
+	k := NewKey(base32.StdEncoding.EncodeToString([]byte("12345678901234567890")))
+	code, err := k.Code(time.Unix(59, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, "287082", code)
+}
+
+func TestRemaining(t *testing.T) {
+	k := NewKey("GEZDGNBVGY3TQOJQ")
+	assert.Equal(t, 30*time.Second, k.Remaining(time.Unix(60, 0)))
+	assert.Equal(t, 1*time.Second, k.Remaining(time.Unix(89, 0)))
+	assert.Equal(t, 17*time.Second, k.Remaining(time.Unix(73, 0)))
+}
+
+func TestParseURI(t *testing.T) {
+	cases := []struct {
+		name     string
+		uri      string
+		expected Key
+		err      error
+	}{
+		{
+			name: "full",
+			uri:  "otpauth://totp/ACME%20Co:john@example.com?secret=HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ&issuer=ACME%20Co&algorithm=SHA256&digits=8&period=60",
+			expected: Key{
+				Secret: "HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ", Issuer: "ACME Co", Account: "john@example.com",
+				Algorithm: AlgorithmSHA256, Digits: 8, Period: 60,
+			},
+		},
+		{
+			name: "defaults",
+			uri:  "otpauth://totp/john?secret=hxdmvjecjjwsrb3h",
+			expected: Key{
+				Secret: "hxdmvjecjjwsrb3h", Account: "john",
+				Algorithm: AlgorithmSHA1, Digits: DefaultDigits, Period: DefaultPeriod,
+			},
+		},
+		{name: "hotp", uri: "otpauth://hotp/john?secret=HXDMVJECJJWSRB3H&counter=1", err: ErrInvalidURI},
+		{name: "no secret", uri: "otpauth://totp/john", err: ErrInvalidSecret},
+		{name: "bad secret", uri: "otpauth://totp/john?secret=1111", err: ErrInvalidSecret},
+		{name: "bad algorithm", uri: "otpauth://totp/john?secret=HXDMVJECJJWSRB3H&algorithm=MD5", err: ErrInvalidAlgorithm},
+		{name: "bad digits", uri: "otpauth://totp/john?secret=HXDMVJECJJWSRB3H&digits=4", err: ErrInvalidDigits},
+		{name: "bad period", uri: "otpauth://totp/john?secret=HXDMVJECJJWSRB3H&period=0", err: ErrInvalidPeriod},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := ParseURI(tt.uri)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, k)
+		})
+	}
+}
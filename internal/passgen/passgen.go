@@ -0,0 +1,235 @@
+// Package passgen реализует формирование случайных паролей и парольных фраз (diceware).
+package passgen
+
+import (
+	"bufio"
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// Наборы символов паролей.
+const (
+	Lower   = "abcdefghijklmnopqrstuvwxyz"
+	Upper   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	Digits  = "0123456789"
+	Symbols = "!#$%&()*+,-./:;<=>?@[]^_{}~"
+	// Ambiguous символы, которые легко перепутать при чтении или вводе вручную
+	Ambiguous = "Il1O0o"
+)
+
+const (
+	// DefaultLength длина пароля по умолчанию.
+	DefaultLength = 20
+	// DefaultWords количество слов парольной фразы по умолчанию.
+	DefaultWords = 6
+	// DefaultSeparator разделитель слов парольной фразы по умолчанию.
+	DefaultSeparator = "-"
+
+	maxLength = 1024
+	maxWords  = 64
+)
+
+var (
+	ErrInvalidLength = errors.New("invalid password length")
+	ErrInvalidWords  = errors.New("invalid number of passphrase words")
+	ErrNoCharClasses = errors.New("at least one character class is required")
+)
+
+// Список слов EFF для парольных фраз (https://www.eff.org/dice), по одному слову в строке.
+//
+//go:embed eff_large_wordlist.txt
+var wordlistData string
+
+var (
+	wordsOnce sync.Once
+	words     []string
+)
+
+// wordlist возвращает список слов для парольных фраз. Список разбирается при первом обращении.
+func wordlist() []string {
+	wordsOnce.Do(func() {
+		words = make([]string, 0, 7776)
+		s := bufio.NewScanner(strings.NewReader(wordlistData))
+		for s.Scan() {
+			if w := strings.TrimSpace(s.Text()); len(w) != 0 {
+				words = append(words, w)
+			}
+		}
+	})
+	return words
+}
+
+// Policy правила формирования пароля. Если задано количество слов Words, то формируется парольная фраза,
+// а длина и классы символов не используются.
+type Policy struct {
+	// Длина пароля
+	Length int `json:"length,omitempty"`
+	// Классы символов пароля, в пароле будет хотя бы один символ каждого класса
+	Lower   bool `json:"lower,omitempty"`
+	Upper   bool `json:"upper,omitempty"`
+	Digits  bool `json:"digits,omitempty"`
+	Symbols bool `json:"symbols,omitempty"`
+	// Исключить символы, которые легко перепутать
+	ExcludeAmbiguous bool `json:"exclude_ambiguous,omitempty"`
+	// Количество слов парольной фразы
+	Words int `json:"words,omitempty"`
+	// Разделитель слов парольной фразы
+	Separator string `json:"separator,omitempty"`
+}
+
+// DefaultPolicy возвращает правила по умолчанию: пароль длиной DefaultLength из символов всех классов.
+func DefaultPolicy() Policy {
+	return Policy{
+		Length:  DefaultLength,
+		Lower:   true,
+		Upper:   true,
+		Digits:  true,
+		Symbols: true,
+	}
+}
+
+// IsPassphrase возвращает true, если по правилам формируется парольная фраза.
+func (p Policy) IsPassphrase() bool {
+	return p.Words > 0
+}
+
+// classes возвращает наборы символов включенных классов.
+func (p Policy) classes() []string {
+	classes := []string{}
+	for _, c := range []struct {
+		enabled bool
+		chars   string
+	}{
+		{p.Lower, Lower}, {p.Upper, Upper}, {p.Digits, Digits}, {p.Symbols, Symbols},
+	} {
+		if !c.enabled {
+			continue
+		}
+		chars := c.chars
+		if p.ExcludeAmbiguous {
+			chars = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(Ambiguous, r) {
+					return -1
+				}
+				return r
+			}, chars)
+		}
+		classes = append(classes, chars)
+	}
+	return classes
+}
+
+// Validate проверяет корректность правил.
+func (p Policy) Validate() error {
+	if p.Words < 0 || p.Words > maxWords {
+		return fmt.Errorf("%w: must be from 1 to %d", ErrInvalidWords, maxWords)
+	}
+	if p.IsPassphrase() {
+		return nil
+	}
+	classes := p.classes()
+	if len(classes) == 0 {
+		return ErrNoCharClasses
+	}
+	// в пароль должен попасть хотя бы один символ каждого класса
+	if p.Length < len(classes) || p.Length > maxLength {
+		return fmt.Errorf("%w: must be from %d to %d", ErrInvalidLength, len(classes), maxLength)
+	}
+	return nil
+}
+
+func (p Policy) String() string {
+	if p.IsPassphrase() {
+		return fmt.Sprintf("passphrase: %d words separated by %q", p.Words, p.Separator)
+	}
+	names := []string{}
+	for _, c := range []struct {
+		enabled bool
+		name    string
+	}{
+		{p.Lower, "lower"}, {p.Upper, "upper"}, {p.Digits, "digits"}, {p.Symbols, "symbols"},
+	} {
+		if c.enabled {
+			names = append(names, c.name)
+		}
+	}
+	s := fmt.Sprintf("password: %d chars of %s", p.Length, strings.Join(names, ","))
+	if p.ExcludeAmbiguous {
+		s += ", no ambiguous"
+	}
+	return s
+}
+
+// Generate формирует пароль или парольную фразу по правилам p.
+func Generate(p Policy) (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	if p.IsPassphrase() {
+		return passphrase(p.Words, p.Separator)
+	}
+	classes := p.classes()
+	password := make([]byte, 0, p.Length)
+	// по одному символу каждого класса, остальные из объединения всех классов
+	for _, chars := range classes {
+		c, err := pick(chars)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	all := strings.Join(classes, "")
+	for len(password) < p.Length {
+		c, err := pick(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	// перемешиваем, чтобы символы обязательных классов не стояли в начале пароля
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+// passphrase возвращает парольную фразу из n случайных слов, разделенных sep.
+func passphrase(n int, sep string) (string, error) {
+	list := wordlist()
+	phrase := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		j, err := randomInt(len(list))
+		if err != nil {
+			return "", err
+		}
+		phrase = append(phrase, list[j])
+	}
+	return strings.Join(phrase, sep), nil
+}
+
+// pick возвращает случайный символ из chars.
+func pick(chars string) (byte, error) {
+	i, err := randomInt(len(chars))
+	if err != nil {
+		return 0, err
+	}
+	return chars[i], nil
+}
+
+// randomInt возвращает равномерно распределенное случайное число из [0, n).
+func randomInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
@@ -0,0 +1,89 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	cases := []struct {
+		name    string
+		policy  Policy
+		allowed string
+	}{
+		{name: "default", policy: DefaultPolicy(), allowed: Lower + Upper + Digits + Symbols},
+		{name: "no symbols", policy: Policy{Length: 20, Lower: true, Upper: true, Digits: true}, allowed: Lower + Upper + Digits},
+		{name: "digits only", policy: Policy{Length: 6, Digits: true}, allowed: Digits},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				got, err := Generate(tt.policy)
+				assert.NoError(t, err)
+				assert.Len(t, got, tt.policy.Length)
+				for _, r := range got {
+					assert.Contains(t, tt.allowed, string(r))
+				}
+				// в пароле есть символ каждого класса
+				for _, chars := range tt.policy.classes() {
+					assert.True(t, strings.ContainsAny(got, chars), "%s has no chars of %s", got, chars)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateExcludeAmbiguous(t *testing.T) {
+	p := Policy{Length: 64, Lower: true, Upper: true, Digits: true, ExcludeAmbiguous: true}
+	for i := 0; i < 100; i++ {
+		got, err := Generate(p)
+		assert.NoError(t, err)
+		assert.False(t, strings.ContainsAny(got, Ambiguous), got)
+	}
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	got, err := Generate(Policy{Words: 5, Separator: " "})
+	assert.NoError(t, err)
+	words := strings.Split(got, " ")
+	assert.Len(t, words, 5)
+	for _, w := range words {
+		assert.Contains(t, wordlist(), w)
+	}
+	assert.Len(t, wordlist(), 7776)
+}
+
+func TestPolicyValidate(t *testing.T) {
+	assert.ErrorIs(t, Policy{Length: 10}.Validate(), ErrNoCharClasses)
+	assert.ErrorIs(t, Policy{Length: 1, Lower: true, Digits: true}.Validate(), ErrInvalidLength)
+	assert.ErrorIs(t, Policy{Length: maxLength + 1, Lower: true}.Validate(), ErrInvalidLength)
+	assert.ErrorIs(t, Policy{Words: maxWords + 1}.Validate(), ErrInvalidWords)
+	assert.NoError(t, Policy{Words: 4}.Validate())
+	assert.NoError(t, DefaultPolicy().Validate())
+}
+
+func TestEntropy(t *testing.T) {
+	weak := []string{"", "password", "Password1", "qwerty123", "aaaaaaaa", "abcdef", "12345678", "zxcvbnm!"}
+	for _, p := range weak {
+		assert.Less(t, Entropy(p), 28.0, p)
+	}
+	generated, err := Generate(DefaultPolicy())
+	assert.NoError(t, err)
+	phrase, err := Generate(Policy{Words: DefaultWords, Separator: DefaultSeparator})
+	assert.NoError(t, err)
+	for _, p := range []string{generated, phrase} {
+		assert.GreaterOrEqual(t, Entropy(p), 60.0, p)
+	}
+	// словарное слово оценивается как одно слово из словаря, а не как набор букв
+	assert.Less(t, Entropy("abacus"), Entropy("xqzvbw"))
+}
+
+func TestStrength(t *testing.T) {
+	assert.Equal(t, StrengthVeryWeak, Strength(10))
+	assert.Equal(t, StrengthWeak, Strength(30))
+	assert.Equal(t, StrengthFair, Strength(50))
+	assert.Equal(t, StrengthStrong, Strength(70))
+	assert.Equal(t, StrengthVeryStrong, Strength(128))
+}
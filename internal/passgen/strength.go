@@ -0,0 +1,189 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Уровни стойкости пароля.
+const (
+	StrengthVeryWeak   = "very weak"
+	StrengthWeak       = "weak"
+	StrengthFair       = "fair"
+	StrengthStrong     = "strong"
+	StrengthVeryStrong = "very strong"
+)
+
+// keyboardRows ряды клавиатуры, последовательности из которых легко подобрать.
+var keyboardRows = []string{"qwertyuiop", "asdfghjkl", "zxcvbnm", "1234567890"}
+
+// commonPasswords самые распространенные пароли и их основы.
+var commonPasswords = []string{
+	"password", "passw0rd", "qwerty", "letmein", "welcome", "admin", "login", "dragon", "monkey",
+	"master", "shadow", "sunshine", "princess", "football", "baseball", "iloveyou", "trustno1",
+	"superman", "batman", "starwars", "secret", "hello", "freedom", "whatever", "michael", "charlie",
+	"access", "default", "changeme", "root", "test", "guest",
+}
+
+// Entropy возвращает оценку энтропии пароля в битах. Как и в zxcvbn, пароль разбивается на фрагменты:
+// слова из словаря, распространенные пароли, повторы, последовательности и ряды клавиатуры, и выбирается
+// разбиение, которое проще всего подобрать. Остальные символы оцениваются размером алфавита пароля.
+func Entropy(password string) float64 {
+	s := []rune(password)
+	lower := []rune(strings.ToLower(password))
+	// отдельный символ оценивается размером алфавита из всех классов символов пароля
+	charBits := math.Log2(float64(poolSize(s)))
+	// best[i] минимальная энтропия первых i символов
+	best := make([]float64, len(s)+1)
+	for i := 1; i <= len(s); i++ {
+		best[i] = best[i-1] + charBits
+		for j := 0; j <= i-3; j++ {
+			if bits, ok := fragmentEntropy(s[j:i], lower[j:i]); ok && best[j]+bits < best[i] {
+				best[i] = best[j] + bits
+			}
+		}
+	}
+	return best[len(s)]
+}
+
+// Strength возвращает уровень стойкости пароля с энтропией bits.
+func Strength(bits float64) string {
+	switch {
+	case bits < 28:
+		return StrengthVeryWeak
+	case bits < 36:
+		return StrengthWeak
+	case bits < 60:
+		return StrengthFair
+	case bits < 80:
+		return StrengthStrong
+	}
+	return StrengthVeryStrong
+}
+
+// fragmentEntropy возвращает энтропию фрагмента пароля s (lower - фрагмент в нижнем регистре),
+// если фрагмент подходит под один из легко подбираемых шаблонов.
+func fragmentEntropy(s []rune, lower []rune) (float64, bool) {
+	n := float64(len(s))
+	word := string(lower)
+	// прописные буквы в словарном слове добавляют немного энтропии
+	caseBits := 0.0
+	if string(s) != word {
+		caseBits = 1
+	}
+	for _, p := range commonPasswords {
+		if word == p {
+			return math.Log2(float64(len(commonPasswords))) + caseBits, true
+		}
+	}
+	if len(s) >= 4 && inWordlist(word) {
+		return math.Log2(float64(len(wordlist()))) + caseBits, true
+	}
+	if isRepeat(s) {
+		return math.Log2(float64(classSize(s[0]))) + math.Log2(n), true
+	}
+	if isSequence(lower) {
+		return math.Log2(float64(classSize(s[0]))) + math.Log2(n) + 1, true
+	}
+	if len(s) >= 4 {
+		for _, row := range keyboardRows {
+			if strings.Contains(row, word) || strings.Contains(reverse(row), word) {
+				return math.Log2(float64(len(keyboardRows)*len(row))) + math.Log2(n) + 1 + caseBits, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// inWordlist возвращает true, если слово word есть в списке слов парольных фраз.
+func inWordlist(word string) bool {
+	list := wordlist()
+	lo, hi := 0, len(list)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if list[mid] < word {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo < len(list) && list[lo] == word
+}
+
+// isRepeat возвращает true, если фрагмент состоит из одного повторяющегося символа.
+func isRepeat(s []rune) bool {
+	for _, r := range s[1:] {
+		if r != s[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// isSequence возвращает true, если символы фрагмента идут подряд по возрастанию или убыванию, например abc или 987.
+func isSequence(s []rune) bool {
+	step := s[1] - s[0]
+	if step != 1 && step != -1 {
+		return false
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i]-s[i-1] != step {
+			return false
+		}
+	}
+	return true
+}
+
+// classSize возвращает размер класса символа r.
+func classSize(r rune) int {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		return len(Lower)
+	case r >= '0' && r <= '9':
+		return len(Digits)
+	case r < unicode.MaxASCII:
+		return len(Symbols)
+	}
+	// символы других алфавитов встречаются в паролях реже, оцениваем их размером алфавита
+	return 64
+}
+
+// poolSize возвращает размер алфавита из всех классов символов s.
+func poolSize(s []rune) int {
+	var lower, upper, digits, symbols, other bool
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digits = true
+		case r < unicode.MaxASCII:
+			symbols = true
+		default:
+			other = true
+		}
+	}
+	size := 0
+	for _, c := range []struct {
+		present bool
+		size    int
+	}{
+		{lower, len(Lower)}, {upper, len(Upper)}, {digits, len(Digits)}, {symbols, len(Symbols)}, {other, 64},
+	} {
+		if c.present {
+			size += c.size
+		}
+	}
+	return size
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
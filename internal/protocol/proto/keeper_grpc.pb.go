@@ -19,10 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	Keeper_GetSecretMeta_FullMethodName = "/internal.protocol.proto.Keeper/GetSecretMeta"
-	Keeper_GetSecretData_FullMethodName = "/internal.protocol.proto.Keeper/GetSecretData"
-	Keeper_PutSecret_FullMethodName     = "/internal.protocol.proto.Keeper/PutSecret"
-	Keeper_ListSecrets_FullMethodName   = "/internal.protocol.proto.Keeper/ListSecrets"
+	Keeper_GetSecretMeta_FullMethodName  = "/internal.protocol.proto.Keeper/GetSecretMeta"
+	Keeper_GetSecretData_FullMethodName  = "/internal.protocol.proto.Keeper/GetSecretData"
+	Keeper_PutSecret_FullMethodName      = "/internal.protocol.proto.Keeper/PutSecret"
+	Keeper_ListSecrets_FullMethodName    = "/internal.protocol.proto.Keeper/ListSecrets"
+	Keeper_ListRevisions_FullMethodName  = "/internal.protocol.proto.Keeper/ListRevisions"
+	Keeper_ListExpiring_FullMethodName   = "/internal.protocol.proto.Keeper/ListExpiring"
+	Keeper_GetVaultHeader_FullMethodName = "/internal.protocol.proto.Keeper/GetVaultHeader"
+	Keeper_PutVaultHeader_FullMethodName = "/internal.protocol.proto.Keeper/PutVaultHeader"
 )
 
 // KeeperClient is the client API for Keeper service.
@@ -33,6 +37,10 @@ type KeeperClient interface {
 	GetSecretData(ctx context.Context, in *GetSecretDataRequest, opts ...grpc.CallOption) (Keeper_GetSecretDataClient, error)
 	PutSecret(ctx context.Context, opts ...grpc.CallOption) (Keeper_PutSecretClient, error)
 	ListSecrets(ctx context.Context, in *ListSecretRequest, opts ...grpc.CallOption) (*ListSecretResponse, error)
+	ListRevisions(ctx context.Context, in *ListRevisionsRequest, opts ...grpc.CallOption) (*ListSecretResponse, error)
+	ListExpiring(ctx context.Context, in *ListExpiringRequest, opts ...grpc.CallOption) (*ListSecretResponse, error)
+	GetVaultHeader(ctx context.Context, in *GetVaultHeaderRequest, opts ...grpc.CallOption) (*VaultHeader, error)
+	PutVaultHeader(ctx context.Context, in *VaultHeader, opts ...grpc.CallOption) (*VaultHeader, error)
 }
 
 type keeperClient struct {
@@ -127,6 +135,42 @@ func (c *keeperClient) ListSecrets(ctx context.Context, in *ListSecretRequest, o
 	return out, nil
 }
 
+func (c *keeperClient) ListRevisions(ctx context.Context, in *ListRevisionsRequest, opts ...grpc.CallOption) (*ListSecretResponse, error) {
+	out := new(ListSecretResponse)
+	err := c.cc.Invoke(ctx, Keeper_ListRevisions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) ListExpiring(ctx context.Context, in *ListExpiringRequest, opts ...grpc.CallOption) (*ListSecretResponse, error) {
+	out := new(ListSecretResponse)
+	err := c.cc.Invoke(ctx, Keeper_ListExpiring_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetVaultHeader(ctx context.Context, in *GetVaultHeaderRequest, opts ...grpc.CallOption) (*VaultHeader, error) {
+	out := new(VaultHeader)
+	err := c.cc.Invoke(ctx, Keeper_GetVaultHeader_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) PutVaultHeader(ctx context.Context, in *VaultHeader, opts ...grpc.CallOption) (*VaultHeader, error) {
+	out := new(VaultHeader)
+	err := c.cc.Invoke(ctx, Keeper_PutVaultHeader_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KeeperServer is the server API for Keeper service.
 // All implementations must embed UnimplementedKeeperServer
 // for forward compatibility
@@ -135,6 +179,10 @@ type KeeperServer interface {
 	GetSecretData(*GetSecretDataRequest, Keeper_GetSecretDataServer) error
 	PutSecret(Keeper_PutSecretServer) error
 	ListSecrets(context.Context, *ListSecretRequest) (*ListSecretResponse, error)
+	ListRevisions(context.Context, *ListRevisionsRequest) (*ListSecretResponse, error)
+	ListExpiring(context.Context, *ListExpiringRequest) (*ListSecretResponse, error)
+	GetVaultHeader(context.Context, *GetVaultHeaderRequest) (*VaultHeader, error)
+	PutVaultHeader(context.Context, *VaultHeader) (*VaultHeader, error)
 	mustEmbedUnimplementedKeeperServer()
 }
 
@@ -154,6 +202,18 @@ func (UnimplementedKeeperServer) PutSecret(Keeper_PutSecretServer) error {
 func (UnimplementedKeeperServer) ListSecrets(context.Context, *ListSecretRequest) (*ListSecretResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListSecrets not implemented")
 }
+func (UnimplementedKeeperServer) ListRevisions(context.Context, *ListRevisionsRequest) (*ListSecretResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRevisions not implemented")
+}
+func (UnimplementedKeeperServer) ListExpiring(context.Context, *ListExpiringRequest) (*ListSecretResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListExpiring not implemented")
+}
+func (UnimplementedKeeperServer) GetVaultHeader(context.Context, *GetVaultHeaderRequest) (*VaultHeader, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVaultHeader not implemented")
+}
+func (UnimplementedKeeperServer) PutVaultHeader(context.Context, *VaultHeader) (*VaultHeader, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutVaultHeader not implemented")
+}
 func (UnimplementedKeeperServer) mustEmbedUnimplementedKeeperServer() {}
 
 // UnsafeKeeperServer may be embedded to opt out of forward compatibility for this service.
@@ -250,6 +310,78 @@ func _Keeper_ListSecrets_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Keeper_ListRevisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRevisionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).ListRevisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_ListRevisions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).ListRevisions(ctx, req.(*ListRevisionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_ListExpiring_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListExpiringRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).ListExpiring(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_ListExpiring_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).ListExpiring(ctx, req.(*ListExpiringRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetVaultHeader_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVaultHeaderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetVaultHeader(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetVaultHeader_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetVaultHeader(ctx, req.(*GetVaultHeaderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_PutVaultHeader_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VaultHeader)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).PutVaultHeader(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_PutVaultHeader_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).PutVaultHeader(ctx, req.(*VaultHeader))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Keeper_ServiceDesc is the grpc.ServiceDesc for Keeper service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -265,6 +397,22 @@ var Keeper_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListSecrets",
 			Handler:    _Keeper_ListSecrets_Handler,
 		},
+		{
+			MethodName: "ListRevisions",
+			Handler:    _Keeper_ListRevisions_Handler,
+		},
+		{
+			MethodName: "ListExpiring",
+			Handler:    _Keeper_ListExpiring_Handler,
+		},
+		{
+			MethodName: "GetVaultHeader",
+			Handler:    _Keeper_GetVaultHeader_Handler,
+		},
+		{
+			MethodName: "PutVaultHeader",
+			Handler:    _Keeper_PutVaultHeader_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -2,6 +2,7 @@ package keeper
 
 import (
 	"context"
+	"time"
 
 	"github.com/k1nky/gophkeeper/internal/entity/user"
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
@@ -14,9 +15,21 @@ type storage interface {
 	GetSecretMetaByID(ctx context.Context, metaID vault.MetaID, userID user.ID) (*vault.Meta, error)
 	GetSecretMetaByAlias(ctx context.Context, alias string, userID user.ID) (*vault.Meta, error)
 	ListSecretsByUser(ctx context.Context, userID user.ID) (vault.List, error)
+	ListSecretsByFilter(ctx context.Context, userID user.ID, filter vault.Filter) (vault.List, error)
 	DeleteSecret(ctx context.Context, meta vault.Meta) error
 	UpdateSecret(ctx context.Context, meta vault.Meta, data *vault.DataReader) (*vault.Meta, error)
 	UpdateSecretMeta(ctx context.Context, meta vault.Meta) (*vault.Meta, error)
+	GetVaultHeader(ctx context.Context) (*vault.Header, error)
+	PutVaultHeader(ctx context.Context, h vault.Header) error
+	GetUserVaultHeader(ctx context.Context, userID user.ID) (*vault.Header, error)
+	PutUserVaultHeader(ctx context.Context, userID user.ID, h vault.Header) error
+	ListSecretRevisions(ctx context.Context, metaID vault.MetaID, userID user.ID) (vault.List, error)
+	GetSecretRevisionMeta(ctx context.Context, metaID vault.MetaID, revision int64, userID user.ID) (*vault.Meta, error)
+	GetSecretRevisionData(ctx context.Context, metaID vault.MetaID, revision int64, userID user.ID) (*vault.DataReader, error)
+	UpdateSecretRevisionMeta(ctx context.Context, meta vault.Meta) error
+	DeleteSecretRevision(ctx context.Context, meta vault.Meta) error
+	ListExpiredSecrets(ctx context.Context, now time.Time) (vault.List, error)
+	GetDeviceID(ctx context.Context) (string, error)
 }
 
 type logger interface {
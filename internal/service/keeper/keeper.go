@@ -3,6 +3,9 @@ package keeper
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/k1nky/gophkeeper/internal/entity/user"
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
@@ -17,6 +20,11 @@ import (
 type Service struct {
 	store storage
 	log   logger
+	// ИД устройства для векторов версий, загружается из хранилища при первом изменении секрета
+	deviceMu sync.Mutex
+	device   string
+	// ИД устройства задан явно (SetDeviceID) и не загружается из хранилища
+	deviceSet bool
 }
 
 // New возвращает новый экземпляр сервиса с хранилищем store и логгером log.
@@ -28,6 +36,16 @@ func New(store storage, log logger) *Service {
 	return s
 }
 
+// SetDeviceID задает ИД устройства id, которое добавляется в векторы версий измененных секретов, вместо ИД
+// из хранилища. С пустым ИД сервис работает без устройства, как на сервере: секреты изменяются только клиентами,
+// а сервер лишь принимает их векторы версий и не добавляет в них свою запись.
+func (s *Service) SetDeviceID(id string) {
+	s.deviceMu.Lock()
+	defer s.deviceMu.Unlock()
+	s.device = id
+	s.deviceSet = true
+}
+
 // GetSecretData возвращает данные секрета с ИД metaID для пользователя определенного в контексте или локального пользователя.
 func (s *Service) GetSecretData(ctx context.Context, metaID vault.MetaID) (*vault.DataReader, error) {
 	uid := user.LocalUserID
@@ -77,6 +95,8 @@ func (s *Service) PutSecret(ctx context.Context, meta vault.Meta, data *vault.Da
 	if meta.Revision == 0 {
 		meta.Revision = vault.NewRevision()
 	}
+	// метка истечения срока соответствует сроку действия сохраняемой версии
+	meta.IsExpired = meta.IsExpiredAt(time.Now())
 	if m == nil {
 		// добавляем новый секрет
 		return s.store.PutSecret(ctx, meta, data)
@@ -86,6 +106,54 @@ func (s *Service) PutSecret(ctx context.Context, meta vault.Meta, data *vault.Da
 	return s.store.UpdateSecret(ctx, meta, data)
 }
 
+// NewVersion назначает мета-данным meta новую версию секрета, измененного на этом устройстве: номер версии
+// больше номера текущей версии (см. vault.NextRevision), а вектор версий следует за вектором текущей версии.
+// Вызывается потребителем сервиса перед сохранением измененного секрета через PutSecret.
+func (s *Service) NewVersion(ctx context.Context, meta *vault.Meta) error {
+	current, err := s.GetSecretMeta(ctx, meta.ID)
+	if err != nil {
+		return err
+	}
+	return s.nextVersion(ctx, meta, current)
+}
+
+// nextVersion назначает мета-данным meta версию, следующую за версией current (nil для нового секрета).
+func (s *Service) nextVersion(ctx context.Context, meta *vault.Meta, current *vault.Meta) error {
+	device, err := s.deviceID(ctx)
+	if err != nil {
+		return err
+	}
+	var (
+		revision int64
+		version  vault.Version
+	)
+	if current != nil {
+		revision, version = current.Revision, current.Version
+	}
+	meta.Revision = vault.NextRevision(revision)
+	meta.Version = version
+	// сервис без ИД устройства (сервер) только принимает версии от клиентов
+	if len(device) != 0 {
+		meta.Version = version.Next(device)
+	}
+	return nil
+}
+
+// deviceID возвращает ИД устройства хранилища.
+func (s *Service) deviceID(ctx context.Context) (string, error) {
+	s.deviceMu.Lock()
+	defer s.deviceMu.Unlock()
+	if s.deviceSet || len(s.device) != 0 {
+		return s.device, nil
+	}
+	device, err := s.store.GetDeviceID(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.device = device
+	return device, nil
+}
+
 // DeleteSecret удалет секрет с мета-данным meta. Фактически данный вызов ничего не удаляет,
 // а просто помечает что секрет должен быть удален.
 func (s *Service) DeleteSecret(ctx context.Context, meta vault.Meta) error {
@@ -97,7 +165,9 @@ func (s *Service) DeleteSecret(ctx context.Context, meta vault.Meta) error {
 		return nil
 	}
 	meta.IsDeleted = true
-	meta.Revision = vault.NewRevision()
+	if err := s.nextVersion(ctx, &meta, m); err != nil {
+		return err
+	}
 	_, err = s.store.UpdateSecretMeta(ctx, meta)
 	return err
 }
@@ -111,3 +181,111 @@ func (s *Service) ListSecretsByUser(ctx context.Context) (vault.List, error) {
 	}
 	return s.store.ListSecretsByUser(ctx, uid)
 }
+
+// ListSecretsByFilter возвращает список мета-данных секретов, удовлетворяющих фильтру filter.
+// У мета-данных, зашифрованных на клиенте, метки и папка недоступны, поэтому по ним такие секреты не отбираются.
+func (s *Service) ListSecretsByFilter(ctx context.Context, filter vault.Filter) (vault.List, error) {
+	uid := user.LocalUserID
+	claims, ok := user.GetEffectiveUser(ctx)
+	if ok {
+		uid = claims.ID
+	}
+	return s.store.ListSecretsByFilter(ctx, uid, filter)
+}
+
+// ListExpiringSecrets возвращает список неудаленных секретов, срок действия которых истекает в течение within
+// или уже истек, упорядоченный по сроку действия.
+func (s *Service) ListExpiringSecrets(ctx context.Context, within time.Duration) (vault.List, error) {
+	list, err := s.ListSecretsByUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return list.Expiring(time.Now(), within), nil
+}
+
+// MarkExpiredSecrets помечает секреты всех пользователей, срок действия которых истек на момент now.
+// Версия секрета при этом не меняется, чтобы метка не приводила к конфликтам при синхронизации.
+// Возвращает мета-данные помеченных секретов.
+func (s *Service) MarkExpiredSecrets(ctx context.Context, now time.Time) (vault.List, error) {
+	list, err := s.store.ListExpiredSecrets(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	marked := vault.List{}
+	for _, m := range list {
+		m.IsExpired = true
+		if _, err := s.store.UpdateSecretMeta(ctx, m); err != nil {
+			s.log.Errorf("mark expired secret %s: %v", m.ID, err)
+			continue
+		}
+		marked = append(marked, m)
+	}
+	return marked, nil
+}
+
+// ListSecretRevisions возвращает мета-данные предыдущих версий секрета с ИД metaID, начиная с последней.
+func (s *Service) ListSecretRevisions(ctx context.Context, metaID vault.MetaID) (vault.List, error) {
+	uid := user.LocalUserID
+	claims, ok := user.GetEffectiveUser(ctx)
+	if ok {
+		uid = claims.ID
+	}
+	return s.store.ListSecretRevisions(ctx, metaID, uid)
+}
+
+// GetSecretRevision возвращает мета-данные версии revision секрета с ИД metaID, в т.ч. текущей.
+func (s *Service) GetSecretRevision(ctx context.Context, metaID vault.MetaID, revision int64) (*vault.Meta, error) {
+	uid := user.LocalUserID
+	claims, ok := user.GetEffectiveUser(ctx)
+	if ok {
+		uid = claims.ID
+	}
+	return s.store.GetSecretRevisionMeta(ctx, metaID, revision, uid)
+}
+
+// GetSecretRevisionData возвращает данные версии revision секрета с ИД metaID, в т.ч. текущей.
+func (s *Service) GetSecretRevisionData(ctx context.Context, metaID vault.MetaID, revision int64) (*vault.DataReader, error) {
+	uid := user.LocalUserID
+	claims, ok := user.GetEffectiveUser(ctx)
+	if ok {
+		uid = claims.ID
+	}
+	return s.store.GetSecretRevisionData(ctx, metaID, revision, uid)
+}
+
+// RestoreSecret делает версию revision секрета с ИД metaID текущей. Восстанавливаются данные секрета,
+// а псевдоним, метки и папка остаются текущими. Текущая версия сохраняется в истории.
+func (s *Service) RestoreSecret(ctx context.Context, metaID vault.MetaID, revision int64) (*vault.Meta, error) {
+	current, err := s.GetSecretMeta(ctx, metaID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, vault.ErrMetaNotExists
+	}
+	if current.Revision == revision {
+		return nil, vault.ErrNothingToUpdate
+	}
+	prev, err := s.GetSecretRevision(ctx, metaID, revision)
+	if err != nil {
+		return nil, err
+	}
+	if prev == nil {
+		return nil, fmt.Errorf("%w: %d", vault.ErrNoRevision, revision)
+	}
+	data, err := s.GetSecretRevisionData(ctx, metaID, revision)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("%w: %d", vault.ErrNoRevision, revision)
+	}
+	defer data.Close()
+	restored := *current
+	restored.Type, restored.WrappedKey, restored.Attributes = prev.Type, prev.WrappedKey, prev.Attributes
+	restored.IsDeleted = false
+	if err := s.nextVersion(ctx, &restored, current); err != nil {
+		return nil, err
+	}
+	return s.store.UpdateSecret(ctx, restored, data)
+}
@@ -5,8 +5,11 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/k1nky/gophkeeper/internal/crypto"
+	"github.com/k1nky/gophkeeper/internal/entity/user"
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
 	log "github.com/k1nky/gophkeeper/internal/logger"
 	"github.com/k1nky/gophkeeper/internal/service/keeper/mock"
@@ -19,6 +22,9 @@ type keeperServiceTestSuite struct {
 	svc   *Service
 }
 
+// ИД устройства локального хранилища в тестах
+const testDevice = "device"
+
 func TestKeeperService(t *testing.T) {
 	suite.Run(t, new(keeperServiceTestSuite))
 }
@@ -26,6 +32,7 @@ func TestKeeperService(t *testing.T) {
 func (suite *keeperServiceTestSuite) SetupTest() {
 	ctrl := gomock.NewController(suite.Suite.T())
 	suite.store = mock.NewMockstorage(ctrl)
+	suite.store.EXPECT().GetDeviceID(gomock.Any()).Return(testDevice, nil).AnyTimes()
 	suite.svc = New(suite.store, &log.Blackhole{})
 }
 
@@ -126,3 +133,580 @@ func (suite *keeperServiceTestSuite) TestListSecretsByUser() {
 	suite.NoError(err)
 	suite.ElementsMatch(expected, got)
 }
+
+func (suite *keeperServiceTestSuite) TestUnlockVaultNew() {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(nil, nil)
+	suite.store.EXPECT().PutVaultHeader(gomock.Any(), testHeader("secret", params)).Return(nil)
+	key, err := suite.svc.UnlockVault(context.TODO(), vault.Credentials{Secret: "secret"}, params)
+	suite.NoError(err)
+	expected, _ := crypto.DeriveKey("secret", params)
+	suite.Equal(expected, key)
+}
+
+func (suite *keeperServiceTestSuite) TestUnlockVaultExisting() {
+	stored := crypto.KDFParams{Algorithm: crypto.KDFScrypt, Salt: []byte("stored salt"), N: 16, R: 8, P: 1}
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	h := testHeader("secret", stored)
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&h, nil)
+	key, err := suite.svc.UnlockVault(context.TODO(), vault.Credentials{Secret: "secret"}, params)
+	suite.NoError(err)
+	// ключ должен быть получен с параметрами из заголовка хранилища
+	expected, _ := crypto.DeriveKey("secret", stored)
+	suite.Equal(expected, key)
+}
+
+func (suite *keeperServiceTestSuite) TestUnlockVaultWrongSecret() {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	h := testHeader("secret", params)
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&h, nil)
+	_, err := suite.svc.UnlockVault(context.TODO(), vault.Credentials{Secret: "wrong secret"}, params)
+	suite.ErrorIs(err, vault.ErrWrongSecret)
+}
+
+func (suite *keeperServiceTestSuite) TestUnlockVaultWithoutCheck() {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	// для хранилища без контрольного значения оно сохраняется при первой разблокировке
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&vault.Header{KDF: params}, nil)
+	suite.store.EXPECT().PutVaultHeader(gomock.Any(), testHeader("secret", params)).Return(nil)
+	_, err := suite.svc.UnlockVault(context.TODO(), vault.Credentials{Secret: "secret"}, params)
+	suite.NoError(err)
+}
+
+func (suite *keeperServiceTestSuite) TestCheckSecret() {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	h := testHeader("secret", params)
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&h, nil).Times(2)
+	suite.NoError(suite.svc.CheckSecret(context.TODO(), vault.Credentials{Secret: "secret"}))
+	suite.ErrorIs(suite.svc.CheckSecret(context.TODO(), vault.Credentials{Secret: "wrong secret"}), vault.ErrWrongSecret)
+
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(nil, nil)
+	suite.ErrorIs(suite.svc.CheckSecret(context.TODO(), vault.Credentials{Secret: "secret"}), vault.ErrNotInitialized)
+}
+
+func (suite *keeperServiceTestSuite) TestUnlockVaultWithError() {
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(nil, errors.New("unexpected error"))
+	key, err := suite.svc.UnlockVault(context.TODO(), vault.Credentials{Secret: "secret"}, crypto.KDFParams{})
+	suite.Error(err)
+	suite.Nil(key)
+}
+
+func (suite *keeperServiceTestSuite) TestSecretKey() {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	master, _ := crypto.DeriveKey("secret", params)
+	dataKey, _ := crypto.NewDataKey()
+	wrapped, _ := crypto.WrapKey(master, dataKey)
+
+	got, err := SecretKey(master, vault.Meta{WrappedKey: wrapped})
+	suite.NoError(err)
+	suite.Equal(dataKey, got)
+	// без ключа данных используется мастер-ключ
+	got, err = SecretKey(master, vault.Meta{})
+	suite.NoError(err)
+	suite.Equal(master, got)
+
+	other, _ := crypto.DeriveKey("other secret", params)
+	_, err = SecretKey(other, vault.Meta{WrappedKey: wrapped})
+	suite.ErrorIs(err, crypto.ErrInvalidWrappedKey)
+	suite.ErrorIs(err, vault.ErrWrongSecret)
+}
+
+func (suite *keeperServiceTestSuite) TestDecryptSecret() {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	master, _ := crypto.DeriveKey("secret", params)
+	other, _ := crypto.DeriveKey("other secret", params)
+	expected := []byte("secret data")
+	enc, _ := crypto.NewEncryptReader(master, bytes.NewReader(expected))
+	ciphertext := bytes.Buffer{}
+	ciphertext.ReadFrom(enc)
+
+	dec, err := DecryptSecret(master, vault.Meta{}, bytes.NewReader(ciphertext.Bytes()))
+	suite.NoError(err)
+	buf := bytes.Buffer{}
+	_, err = buf.ReadFrom(dec)
+	suite.NoError(err)
+	suite.Equal(expected, buf.Bytes())
+
+	// неверный ключ обнаруживается до расшифровки данных
+	dec, err = DecryptSecret(other, vault.Meta{}, bytes.NewReader(ciphertext.Bytes()))
+	suite.ErrorIs(err, vault.ErrWrongSecret)
+	suite.Nil(dec)
+}
+
+func (suite *keeperServiceTestSuite) TestUnlockVaultRekeyInProgress() {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&vault.Header{KDF: params, Pending: &vault.Header{KDF: params}}, nil)
+	key, err := suite.svc.UnlockVault(context.TODO(), vault.Credentials{Secret: "secret"}, params)
+	suite.ErrorIs(err, vault.ErrRekeyInProgress)
+	suite.Nil(key)
+}
+
+func (suite *keeperServiceTestSuite) TestRekey() {
+	oldParams := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("old salt"), Time: 1, Memory: 64, Threads: 1}
+	newParams := crypto.KDFParams{Algorithm: crypto.KDFScrypt, Salt: []byte("new salt"), N: 16, R: 8, P: 1}
+	oldKey, _ := crypto.DeriveKey("old", oldParams)
+	newKey, _ := crypto.DeriveKey("new", newParams)
+	dataKey, _ := crypto.NewDataKey()
+	wrapped, _ := crypto.WrapKey(oldKey, dataKey)
+	m := vault.Meta{ID: vault.NewMetaID(), Revision: 1, WrappedKey: wrapped}
+	next := testHeader("new", newParams)
+	// предыдущие версии тоже переводятся на новый ключ, а версии без ключа данных удаляются
+	prev := vault.Meta{ID: m.ID, Revision: 0, WrappedKey: wrapped}
+	legacy := vault.Meta{ID: m.ID, Revision: -1}
+
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&vault.Header{KDF: oldParams}, nil)
+	suite.store.EXPECT().ListSecretsByUser(gomock.Any(), gomock.Any()).Return(vault.List{m}, nil)
+	// до изменений проверяются ключи данных предыдущих версий
+	suite.store.EXPECT().ListSecretRevisions(gomock.Any(), m.ID, gomock.Any()).Return(vault.List{prev, legacy}, nil)
+	gomock.InOrder(
+		suite.store.EXPECT().PutVaultHeader(gomock.Any(), vault.Header{KDF: oldParams, Pending: &next}).Return(nil),
+		suite.store.EXPECT().UpdateSecretMeta(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, got vault.Meta) (*vault.Meta, error) {
+			suite.Greater(got.Revision, m.Revision)
+			key, err := crypto.UnwrapKey(newKey, got.WrappedKey)
+			suite.NoError(err)
+			suite.Equal(dataKey, key)
+			return &got, nil
+		}),
+		suite.store.EXPECT().ListSecretRevisions(gomock.Any(), m.ID, gomock.Any()).Return(vault.List{prev, legacy}, nil),
+		suite.store.EXPECT().UpdateSecretRevisionMeta(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, got vault.Meta) error {
+			suite.Equal(prev.Revision, got.Revision)
+			key, err := crypto.UnwrapKey(newKey, got.WrappedKey)
+			suite.NoError(err)
+			suite.Equal(dataKey, key)
+			return nil
+		}),
+		suite.store.EXPECT().DeleteSecretRevision(gomock.Any(), legacy).Return(nil),
+		suite.store.EXPECT().PutVaultHeader(gomock.Any(), next).Return(nil),
+	)
+	dropped, err := suite.svc.Rekey(context.TODO(), vault.Credentials{Secret: "old"}, vault.Credentials{Secret: "new"}, newParams)
+	suite.NoError(err)
+	// об удаленных версиях сообщается вызывающему
+	suite.Equal(vault.List{legacy}, dropped)
+}
+
+func (suite *keeperServiceTestSuite) TestRekeyResume() {
+	oldParams := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("old salt"), Time: 1, Memory: 64, Threads: 1}
+	newParams := crypto.KDFParams{Algorithm: crypto.KDFScrypt, Salt: []byte("new salt"), N: 16, R: 8, P: 1}
+	oldKey, _ := crypto.DeriveKey("old", oldParams)
+	newKey, _ := crypto.DeriveKey("new", newParams)
+	dataKey, _ := crypto.NewDataKey()
+	migrated, _ := crypto.WrapKey(newKey, dataKey)
+	wrapped, _ := crypto.WrapKey(oldKey, dataKey)
+	done := vault.Meta{ID: vault.NewMetaID(), WrappedKey: migrated}
+	m := vault.Meta{ID: vault.NewMetaID(), WrappedKey: wrapped}
+
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&vault.Header{KDF: oldParams, Pending: &vault.Header{KDF: newParams}}, nil)
+	suite.store.EXPECT().ListSecretsByUser(gomock.Any(), gomock.Any()).Return(vault.List{done, m}, nil)
+	// уже переведенный секрет не изменяется
+	suite.store.EXPECT().UpdateSecretMeta(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, got vault.Meta) (*vault.Meta, error) {
+		suite.Equal(m.ID, got.ID)
+		return &got, nil
+	})
+	suite.store.EXPECT().ListSecretRevisions(gomock.Any(), gomock.Any(), gomock.Any()).Return(vault.List{}, nil).Times(4)
+	suite.store.EXPECT().PutVaultHeader(gomock.Any(), vault.Header{KDF: newParams}).Return(nil)
+	// при продолжении используются параметры из журнала
+	dropped, err := suite.svc.Rekey(context.TODO(), vault.Credentials{Secret: "old"}, vault.Credentials{Secret: "new"}, crypto.KDFParams{})
+	suite.NoError(err)
+	suite.Empty(dropped)
+}
+
+func (suite *keeperServiceTestSuite) TestRekeyWrongSecret() {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	oldKey, _ := crypto.DeriveKey("old", params)
+	dataKey, _ := crypto.NewDataKey()
+	wrapped, _ := crypto.WrapKey(oldKey, dataKey)
+
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&vault.Header{KDF: params}, nil)
+	suite.store.EXPECT().ListSecretsByUser(gomock.Any(), gomock.Any()).Return(vault.List{{ID: vault.NewMetaID(), WrappedKey: wrapped}}, nil)
+	// хранилище не должно изменяться
+	_, err := suite.svc.Rekey(context.TODO(), vault.Credentials{Secret: "wrong"}, vault.Credentials{Secret: "new"}, params)
+	suite.ErrorIs(err, crypto.ErrInvalidWrappedKey)
+}
+
+func (suite *keeperServiceTestSuite) TestRekeyUnreadableRevision() {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	oldKey, _ := crypto.DeriveKey("old", params)
+	otherKey, _ := crypto.DeriveKey("other", params)
+	dataKey, _ := crypto.NewDataKey()
+	wrapped, _ := crypto.WrapKey(oldKey, dataKey)
+	foreign, _ := crypto.WrapKey(otherKey, dataKey)
+	m := vault.Meta{ID: vault.NewMetaID(), Revision: 2, WrappedKey: wrapped}
+
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&vault.Header{KDF: params}, nil)
+	suite.store.EXPECT().ListSecretsByUser(gomock.Any(), gomock.Any()).Return(vault.List{m}, nil)
+	suite.store.EXPECT().ListSecretRevisions(gomock.Any(), m.ID, gomock.Any()).Return(vault.List{
+		{ID: m.ID, Revision: 1, WrappedKey: foreign},
+	}, nil)
+	// версия, которую не удается перевести на новый ключ, не должна остаться зашифрованной старым ключом,
+	// поэтому хранилище не изменяется
+	_, err := suite.svc.Rekey(context.TODO(), vault.Credentials{Secret: "old"}, vault.Credentials{Secret: "new"}, params)
+	suite.ErrorIs(err, crypto.ErrInvalidWrappedKey)
+}
+
+func (suite *keeperServiceTestSuite) TestRekeySharedVault() {
+	oldParams := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("old salt"), Time: 1, Memory: 64, Threads: 1}
+	newParams := crypto.KDFParams{Algorithm: crypto.KDFScrypt, Salt: []byte("new salt"), N: 16, R: 8, P: 1}
+	oldKey, _ := crypto.DeriveKey("old", oldParams)
+	newKey, _ := crypto.DeriveKey("new", newParams)
+	dataKey, _ := crypto.NewDataKey()
+	wrapped, _ := crypto.WrapKey(oldKey, dataKey)
+	m, _ := vault.Meta{ID: vault.NewMetaID(), Revision: 1, Alias: "alias", WrappedKey: wrapped}.Seal(oldKey)
+	published := testHeader("old", oldParams)
+	h := published
+	h.Shared = true
+	next := testHeader("new", newParams)
+	committed := next
+	committed.Replaces = &published
+
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&h, nil)
+	suite.store.EXPECT().ListSecretsByUser(gomock.Any(), gomock.Any()).Return(vault.List{m}, nil)
+	suite.store.EXPECT().ListSecretRevisions(gomock.Any(), m.ID, gomock.Any()).Return(vault.List{}, nil).Times(2)
+	pending := h
+	pending.Pending = &next
+	suite.store.EXPECT().PutVaultHeader(gomock.Any(), pending).Return(nil)
+	suite.store.EXPECT().UpdateSecretMeta(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, got vault.Meta) (*vault.Meta, error) {
+		// версия повышается, чтобы секрет был отправлен в удаленное хранилище
+		suite.Greater(got.Revision, m.Revision)
+		// зашифрованные мета-данные переводятся на новый ключ
+		opened, err := got.Unseal(newKey)
+		suite.NoError(err)
+		suite.Equal("alias", opened.Alias)
+		index, _ := vault.AliasIndex(newKey, "alias")
+		suite.Equal(index, got.Alias)
+		return &got, nil
+	})
+	// новый заголовок заменит опубликованный при синхронизации
+	suite.store.EXPECT().PutVaultHeader(gomock.Any(), committed).Return(nil)
+	_, err := suite.svc.Rekey(context.TODO(), vault.Credentials{Secret: "old"}, vault.Credentials{Secret: "new"}, newParams)
+	suite.NoError(err)
+}
+
+func (suite *keeperServiceTestSuite) TestRejoinVault() {
+	oldParams := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("old salt"), Time: 1, Memory: 64, Threads: 1}
+	newParams := crypto.KDFParams{Algorithm: crypto.KDFScrypt, Salt: []byte("new salt"), N: 16, R: 8, P: 1}
+	oldKey, _ := crypto.DeriveKey("old", oldParams)
+	newKey, _ := crypto.DeriveKey("new", newParams)
+	dataKey, _ := crypto.NewDataKey()
+	wrapped, _ := crypto.WrapKey(oldKey, dataKey)
+	m := vault.Meta{ID: vault.NewMetaID(), Revision: 1, Version: vault.Version{"device": 1}, WrappedKey: wrapped}
+	h := testHeader("old", oldParams)
+	h.Shared = true
+	remote := testHeader("new", newParams)
+	joined := remote
+	joined.Shared = true
+
+	// новые учетные данные должны подходить к опубликованному заголовку
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&h, nil)
+	_, err := suite.svc.RejoinVault(context.TODO(), vault.Credentials{Secret: "old"}, vault.Credentials{Secret: "other"}, remote)
+	suite.ErrorIs(err, vault.ErrWrongSecret)
+
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&h, nil)
+	suite.store.EXPECT().ListSecretsByUser(gomock.Any(), gomock.Any()).Return(vault.List{m}, nil)
+	suite.store.EXPECT().ListSecretRevisions(gomock.Any(), m.ID, gomock.Any()).Return(vault.List{}, nil).Times(2)
+	pending := h
+	pending.Pending = &joined
+	suite.store.EXPECT().PutVaultHeader(gomock.Any(), pending).Return(nil)
+	suite.store.EXPECT().UpdateSecretMeta(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, got vault.Meta) (*vault.Meta, error) {
+		// секреты с новым ключом уже есть в удаленном хранилище, поэтому версия не меняется
+		suite.Equal(m.Revision, got.Revision)
+		suite.Equal(m.Version, got.Version)
+		key, err := crypto.UnwrapKey(newKey, got.WrappedKey)
+		suite.NoError(err)
+		suite.Equal(dataKey, key)
+		return &got, nil
+	})
+	suite.store.EXPECT().PutVaultHeader(gomock.Any(), joined).Return(nil)
+	_, err = suite.svc.RejoinVault(context.TODO(), vault.Credentials{Secret: "old"}, vault.Credentials{Secret: "new"}, remote)
+	suite.NoError(err)
+}
+
+func (suite *keeperServiceTestSuite) TestRekeyNotInitialized() {
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(nil, nil)
+	_, err := suite.svc.Rekey(context.TODO(), vault.Credentials{Secret: "old"}, vault.Credentials{Secret: "new"}, crypto.KDFParams{})
+	suite.ErrorIs(err, vault.ErrNotInitialized)
+}
+
+func (suite *keeperServiceTestSuite) TestRekeyWithoutDataKey() {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	oldKey, _ := crypto.DeriveKey("old", params)
+	newKey, _ := crypto.DeriveKey("new", params)
+	expected := []byte("secret data")
+	encrypt := func() *vault.DataReader {
+		enc, _ := crypto.NewEncryptReader(oldKey, bytes.NewReader(expected))
+		return vault.NewDataReader(enc)
+	}
+	m := vault.Meta{ID: vault.NewMetaID(), Revision: 1}
+
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&vault.Header{KDF: params}, nil)
+	suite.store.EXPECT().ListSecretsByUser(gomock.Any(), gomock.Any()).Return(vault.List{m}, nil)
+	suite.store.EXPECT().GetSecretData(gomock.Any(), m.ID, gomock.Any()).DoAndReturn(func(context.Context, vault.MetaID, interface{}) (*vault.DataReader, error) {
+		return encrypt(), nil
+	}).Times(2)
+	suite.store.EXPECT().PutVaultHeader(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	suite.store.EXPECT().ListSecretRevisions(gomock.Any(), m.ID, gomock.Any()).Return(vault.List{}, nil).Times(2)
+	suite.store.EXPECT().UpdateSecret(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, got vault.Meta, data *vault.DataReader) (*vault.Meta, error) {
+		suite.Greater(got.Revision, m.Revision)
+		key, err := SecretKey(newKey, got)
+		suite.NoError(err)
+		dec, _ := crypto.NewDecryptReader(key, data)
+		buf := bytes.Buffer{}
+		_, err = buf.ReadFrom(dec)
+		suite.NoError(err)
+		suite.Equal(expected, buf.Bytes())
+		return &got, nil
+	})
+	// прежняя версия совпадает с перешифрованной, поэтому в истории не остается
+	suite.store.EXPECT().GetSecretRevisionMeta(gomock.Any(), m.ID, m.Revision, gomock.Any()).Return(&m, nil)
+	suite.store.EXPECT().DeleteSecretRevision(gomock.Any(), m).Return(nil)
+	dropped, err := suite.svc.Rekey(context.TODO(), vault.Credentials{Secret: "old"}, vault.Credentials{Secret: "new"}, params)
+	suite.NoError(err)
+	suite.Empty(dropped)
+}
+
+func (suite *keeperServiceTestSuite) TestRekeyResumeWrongNewSecret() {
+	oldParams := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("old salt"), Time: 1, Memory: 64, Threads: 1}
+	newParams := crypto.KDFParams{Algorithm: crypto.KDFScrypt, Salt: []byte("new salt"), N: 16, R: 8, P: 1}
+	next := testHeader("new", newParams)
+	h := testHeader("old", oldParams)
+	h.Pending = &next
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&h, nil).Times(2)
+	// прерванную смену ключа нельзя продолжить с другим новым секретом
+	_, err := suite.svc.Rekey(context.TODO(), vault.Credentials{Secret: "old"}, vault.Credentials{Secret: "other"}, newParams)
+	suite.ErrorIs(err, vault.ErrWrongSecret)
+	_, err = suite.svc.Rekey(context.TODO(), vault.Credentials{Secret: "wrong"}, vault.Credentials{Secret: "new"}, newParams)
+	suite.ErrorIs(err, vault.ErrWrongSecret)
+}
+
+func (suite *keeperServiceTestSuite) TestJoinVault() {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	other := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("other salt"), Time: 1, Memory: 64, Threads: 1}
+	remote := testHeader("secret", params)
+	joined := remote
+	joined.Shared = true
+	empty := testHeader("secret", other)
+
+	// новое устройство получает заголовок из удаленного хранилища
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(nil, nil)
+	suite.store.EXPECT().PutVaultHeader(gomock.Any(), joined).Return(nil)
+	suite.NoError(suite.svc.JoinVault(context.TODO(), remote))
+
+	// заголовок того же хранилища только помечается опубликованным
+	local := testHeader("secret", params)
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&local, nil)
+	suite.store.EXPECT().PutVaultHeader(gomock.Any(), joined).Return(nil)
+	suite.NoError(suite.svc.JoinVault(context.TODO(), remote))
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&joined, nil)
+	suite.NoError(suite.svc.JoinVault(context.TODO(), remote))
+
+	// опубликованный после смены ключа заголовок больше не требует замены
+	rekeyed := testHeader("secret", params)
+	rekeyed.Replaces = &empty
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&rekeyed, nil)
+	suite.store.EXPECT().PutVaultHeader(gomock.Any(), joined).Return(nil)
+	suite.NoError(suite.svc.JoinVault(context.TODO(), remote))
+
+	// ключ опубликованного хранилища сменен на другом устройстве
+	shared := testHeader("secret", other)
+	shared.Shared = true
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&shared, nil)
+	suite.ErrorIs(suite.svc.JoinVault(context.TODO(), remote), vault.ErrVaultRekeyed)
+
+	// пустое локальное хранилище с другим заголовком заменяется
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&empty, nil)
+	suite.store.EXPECT().ListSecretsByUser(gomock.Any(), gomock.Any()).Return(vault.List{}, nil)
+	suite.store.EXPECT().PutVaultHeader(gomock.Any(), joined).Return(nil)
+	suite.NoError(suite.svc.JoinVault(context.TODO(), remote))
+
+	// локальные секреты другого хранилища не расшифровать ключом удаленного
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&empty, nil)
+	suite.store.EXPECT().ListSecretsByUser(gomock.Any(), gomock.Any()).Return(vault.List{{ID: vault.NewMetaID()}}, nil)
+	suite.ErrorIs(suite.svc.JoinVault(context.TODO(), remote), vault.ErrVaultMismatch)
+
+	pending := testHeader("secret", params)
+	pending.Pending = &empty
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&pending, nil)
+	suite.ErrorIs(suite.svc.JoinVault(context.TODO(), remote), vault.ErrRekeyInProgress)
+}
+
+func (suite *keeperServiceTestSuite) TestPutUserVaultHeader() {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	other := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("other salt"), Time: 1, Memory: 64, Threads: 1}
+	ctx := user.NewContextWithClaims(context.TODO(), user.PrivateClaims{ID: 1, Login: "u"})
+	h := testHeader("secret", params)
+
+	// локальные признаки заголовка не публикуются
+	local := h
+	local.Shared = true
+	suite.store.EXPECT().GetUserVaultHeader(gomock.Any(), user.ID(1)).Return(nil, nil)
+	suite.store.EXPECT().PutUserVaultHeader(gomock.Any(), user.ID(1), h).Return(nil)
+	got, err := suite.svc.PutUserVaultHeader(ctx, local)
+	suite.NoError(err)
+	suite.Equal(h, *got)
+
+	suite.store.EXPECT().GetUserVaultHeader(gomock.Any(), user.ID(1)).Return(&h, nil)
+	got, err = suite.svc.PutUserVaultHeader(ctx, h)
+	suite.NoError(err)
+	suite.Equal(h, *got)
+
+	// опубликованный заголовок не заменяется заголовком другого хранилища
+	suite.store.EXPECT().GetUserVaultHeader(gomock.Any(), user.ID(1)).Return(&h, nil)
+	_, err = suite.svc.PutUserVaultHeader(ctx, testHeader("secret", other))
+	suite.ErrorIs(err, vault.ErrVaultMismatch)
+
+	// и заменяется заголовком, полученным сменой ключа опубликованного хранилища
+	rekeyed := testHeader("new secret", other)
+	rekeyed.Replaces = &h
+	suite.store.EXPECT().GetUserVaultHeader(gomock.Any(), user.ID(1)).Return(&h, nil)
+	suite.store.EXPECT().PutUserVaultHeader(gomock.Any(), user.ID(1), testHeader("new secret", other)).Return(nil)
+	got, err = suite.svc.PutUserVaultHeader(ctx, rekeyed)
+	suite.NoError(err)
+	suite.Equal(testHeader("new secret", other), *got)
+
+	// но не заголовком, который заменяет другое хранилище
+	stale := testHeader("secret", other)
+	rekeyed.Replaces = &stale
+	suite.store.EXPECT().GetUserVaultHeader(gomock.Any(), user.ID(1)).Return(&h, nil)
+	_, err = suite.svc.PutUserVaultHeader(ctx, rekeyed)
+	suite.ErrorIs(err, vault.ErrVaultMismatch)
+}
+
+func testHeader(secret string, params crypto.KDFParams) vault.Header {
+	key, _ := crypto.DeriveKey(secret, params)
+	check, _ := crypto.KeyCheckValue(key)
+	return vault.Header{KDF: params, Check: check}
+}
+
+func (suite *keeperServiceTestSuite) TestUnlockVaultWithKeyfile() {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	keyfile, _ := crypto.ReadKeyfile(bytes.NewReader([]byte("keyfile")))
+	cred := vault.Credentials{Secret: "secret", Keyfile: keyfile}
+	key, _ := cred.Key(params)
+	check, _ := crypto.KeyCheckValue(key)
+	h := vault.Header{KDF: params, Check: check, Keyfile: true}
+
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(nil, nil)
+	suite.store.EXPECT().PutVaultHeader(gomock.Any(), h).Return(nil)
+	got, err := suite.svc.UnlockVault(context.TODO(), cred, params)
+	suite.NoError(err)
+	suite.Equal(key, got)
+
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&h, nil).AnyTimes()
+	got, err = suite.svc.UnlockVault(context.TODO(), cred, params)
+	suite.NoError(err)
+	suite.Equal(key, got)
+	// хранилище с ключевым файлом не открывается без него
+	_, err = suite.svc.UnlockVault(context.TODO(), vault.Credentials{Secret: "secret"}, params)
+	suite.ErrorIs(err, vault.ErrKeyfileRequired)
+	other, _ := crypto.ReadKeyfile(bytes.NewReader([]byte("other keyfile")))
+	_, err = suite.svc.UnlockVault(context.TODO(), vault.Credentials{Secret: "secret", Keyfile: other}, params)
+	suite.ErrorIs(err, vault.ErrWrongSecret)
+}
+
+func (suite *keeperServiceTestSuite) TestUnlockVaultKeyfileNotUsed() {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	h := testHeader("secret", params)
+	keyfile, _ := crypto.ReadKeyfile(bytes.NewReader([]byte("keyfile")))
+	suite.store.EXPECT().GetVaultHeader(gomock.Any()).Return(&h, nil)
+	_, err := suite.svc.UnlockVault(context.TODO(), vault.Credentials{Secret: "secret", Keyfile: keyfile}, params)
+	suite.ErrorIs(err, vault.ErrKeyfileNotUsed)
+}
+
+func (suite *keeperServiceTestSuite) TestRestoreSecret() {
+	current := vault.Meta{ID: vault.NewMetaID(), Alias: "mail", Revision: 20, WrappedKey: []byte("current key"), Tags: []string{"work"}}
+	prev := vault.Meta{ID: current.ID, Alias: "old mail", Revision: 10, WrappedKey: []byte("previous key"), Type: vault.TypeLoginPassword}
+	data := vault.NewDataReader(vault.NewBytesBuffer([]byte("previous data")))
+	suite.store.EXPECT().GetSecretMetaByID(gomock.Any(), current.ID, gomock.Any()).Return(&current, nil)
+	suite.store.EXPECT().GetSecretRevisionMeta(gomock.Any(), current.ID, prev.Revision, gomock.Any()).Return(&prev, nil)
+	suite.store.EXPECT().GetSecretRevisionData(gomock.Any(), current.ID, prev.Revision, gomock.Any()).Return(data, nil)
+	suite.store.EXPECT().UpdateSecret(gomock.Any(), gomock.Any(), data).DoAndReturn(func(_ context.Context, got vault.Meta, _ *vault.DataReader) (*vault.Meta, error) {
+		// восстанавливаются данные секрета, а псевдоним и метки остаются текущими
+		suite.Greater(got.Revision, current.Revision)
+		suite.Equal(prev.WrappedKey, got.WrappedKey)
+		suite.Equal(prev.Type, got.Type)
+		suite.Equal(current.Alias, got.Alias)
+		suite.Equal(current.Tags, got.Tags)
+		suite.Equal(vault.Version{testDevice: 1}, got.Version)
+		return &got, nil
+	})
+	_, err := suite.svc.RestoreSecret(context.TODO(), current.ID, prev.Revision)
+	suite.NoError(err)
+}
+
+func (suite *keeperServiceTestSuite) TestRestoreSecretNoRevision() {
+	current := vault.Meta{ID: vault.NewMetaID(), Revision: 20}
+	suite.store.EXPECT().GetSecretMetaByID(gomock.Any(), current.ID, gomock.Any()).Return(&current, nil).Times(2)
+	suite.store.EXPECT().GetSecretRevisionMeta(gomock.Any(), current.ID, int64(5), gomock.Any()).Return(nil, nil)
+	_, err := suite.svc.RestoreSecret(context.TODO(), current.ID, 5)
+	suite.ErrorIs(err, vault.ErrNoRevision)
+	_, err = suite.svc.RestoreSecret(context.TODO(), current.ID, current.Revision)
+	suite.ErrorIs(err, vault.ErrNothingToUpdate)
+}
+
+func (suite *keeperServiceTestSuite) TestListExpiringSecrets() {
+	now := time.Now()
+	list := vault.List{
+		{ID: "1", ExpiresAt: now.Add(48 * time.Hour).Unix()},
+		{ID: "2", ExpiresAt: now.Add(-time.Hour).Unix()},
+		{ID: "3", ExpiresAt: now.Add(90 * 24 * time.Hour).Unix()},
+		{ID: "4"},
+		{ID: "5", ExpiresAt: now.Add(-time.Hour).Unix(), IsDeleted: true},
+	}
+	suite.store.EXPECT().ListSecretsByUser(gomock.Any(), gomock.Any()).Return(list, nil)
+	got, err := suite.svc.ListExpiringSecrets(context.TODO(), 30*24*time.Hour)
+	suite.NoError(err)
+	suite.Equal(vault.List{list[1], list[0]}, got)
+}
+
+func (suite *keeperServiceTestSuite) TestMarkExpiredSecrets() {
+	now := time.Now()
+	list := vault.List{
+		{ID: "1", Revision: 10, ExpiresAt: now.Add(-time.Hour).Unix()},
+		{ID: "2", Revision: 20, ExpiresAt: now.Add(-time.Minute).Unix()},
+	}
+	suite.store.EXPECT().ListExpiredSecrets(gomock.Any(), now).Return(list, nil)
+	suite.store.EXPECT().UpdateSecretMeta(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m vault.Meta) (*vault.Meta, error) {
+		// метка не меняет версию секрета
+		suite.True(m.IsExpired)
+		suite.Equal(list[0].Revision, m.Revision)
+		return &m, nil
+	})
+	suite.store.EXPECT().UpdateSecretMeta(gomock.Any(), gomock.Any()).Return(nil, errors.New("unexpected error"))
+	marked, err := suite.svc.MarkExpiredSecrets(context.TODO(), now)
+	suite.NoError(err)
+	suite.Len(marked, 1)
+	suite.Equal(list[0].ID, marked[0].ID)
+}
+
+func (suite *keeperServiceTestSuite) TestNewVersion() {
+	created := vault.Meta{ID: vault.NewMetaID()}
+	suite.store.EXPECT().GetSecretMetaByID(gomock.Any(), created.ID, gomock.Any()).Return(nil, nil)
+	suite.NoError(suite.svc.NewVersion(context.TODO(), &created))
+	suite.NotZero(created.Revision)
+	suite.Equal(vault.Version{testDevice: 1}, created.Version)
+
+	// версия следует за текущей, даже если ее номер назначен на устройстве, часы которого спешат
+	current := vault.Meta{ID: vault.NewMetaID(), Revision: vault.NewRevision() + 1<<32, Version: vault.Version{"other": 2, testDevice: 1}}
+	updated := vault.Meta{ID: current.ID}
+	suite.store.EXPECT().GetSecretMetaByID(gomock.Any(), current.ID, gomock.Any()).Return(&current, nil)
+	suite.NoError(suite.svc.NewVersion(context.TODO(), &updated))
+	suite.Greater(updated.Revision, current.Revision)
+	suite.Equal(vault.Version{"other": 2, testDevice: 2}, updated.Version)
+	suite.Equal(vault.Newer, updated.Compare(current))
+	suite.Equal(vault.Version{"other": 2, testDevice: 1}, current.Version)
+}
+
+func (suite *keeperServiceTestSuite) TestNewVersionWithoutDevice() {
+	// сервер не добавляет свою запись в векторы версий, даже если хранилище выдает ИД устройства
+	suite.svc.SetDeviceID("")
+	current := vault.Meta{ID: vault.NewMetaID(), Revision: vault.NewRevision(), Version: vault.Version{"client": 3}}
+	updated := vault.Meta{ID: current.ID, IsDeleted: true}
+	suite.store.EXPECT().GetSecretMetaByID(gomock.Any(), current.ID, gomock.Any()).Return(&current, nil)
+	suite.NoError(suite.svc.NewVersion(context.TODO(), &updated))
+	suite.Greater(updated.Revision, current.Revision)
+	suite.Equal(current.Version, updated.Version)
+
+	created := vault.Meta{ID: vault.NewMetaID()}
+	suite.store.EXPECT().GetSecretMetaByID(gomock.Any(), created.ID, gomock.Any()).Return(nil, nil)
+	suite.NoError(suite.svc.NewVersion(context.TODO(), &created))
+	suite.Empty(created.Version)
+}
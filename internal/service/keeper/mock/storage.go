@@ -7,6 +7,7 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	user "github.com/k1nky/gophkeeper/internal/entity/user"
@@ -50,6 +51,35 @@ func (mr *MockstorageMockRecorder) DeleteSecret(ctx, meta interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecret", reflect.TypeOf((*Mockstorage)(nil).DeleteSecret), ctx, meta)
 }
 
+// DeleteSecretRevision mocks base method.
+func (m *Mockstorage) DeleteSecretRevision(ctx context.Context, meta vault.Meta) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSecretRevision", ctx, meta)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSecretRevision indicates an expected call of DeleteSecretRevision.
+func (mr *MockstorageMockRecorder) DeleteSecretRevision(ctx, meta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecretRevision", reflect.TypeOf((*Mockstorage)(nil).DeleteSecretRevision), ctx, meta)
+}
+
+// GetDeviceID mocks base method.
+func (m *Mockstorage) GetDeviceID(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceID", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceID indicates an expected call of GetDeviceID.
+func (mr *MockstorageMockRecorder) GetDeviceID(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceID", reflect.TypeOf((*Mockstorage)(nil).GetDeviceID), ctx)
+}
+
 // GetSecretData mocks base method.
 func (m *Mockstorage) GetSecretData(ctx context.Context, metaID vault.MetaID, userID user.ID) (*vault.DataReader, error) {
 	m.ctrl.T.Helper()
@@ -95,6 +125,111 @@ func (mr *MockstorageMockRecorder) GetSecretMetaByID(ctx, metaID, userID interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretMetaByID", reflect.TypeOf((*Mockstorage)(nil).GetSecretMetaByID), ctx, metaID, userID)
 }
 
+// GetSecretRevisionData mocks base method.
+func (m *Mockstorage) GetSecretRevisionData(ctx context.Context, metaID vault.MetaID, revision int64, userID user.ID) (*vault.DataReader, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecretRevisionData", ctx, metaID, revision, userID)
+	ret0, _ := ret[0].(*vault.DataReader)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecretRevisionData indicates an expected call of GetSecretRevisionData.
+func (mr *MockstorageMockRecorder) GetSecretRevisionData(ctx, metaID, revision, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretRevisionData", reflect.TypeOf((*Mockstorage)(nil).GetSecretRevisionData), ctx, metaID, revision, userID)
+}
+
+// GetSecretRevisionMeta mocks base method.
+func (m *Mockstorage) GetSecretRevisionMeta(ctx context.Context, metaID vault.MetaID, revision int64, userID user.ID) (*vault.Meta, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecretRevisionMeta", ctx, metaID, revision, userID)
+	ret0, _ := ret[0].(*vault.Meta)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecretRevisionMeta indicates an expected call of GetSecretRevisionMeta.
+func (mr *MockstorageMockRecorder) GetSecretRevisionMeta(ctx, metaID, revision, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretRevisionMeta", reflect.TypeOf((*Mockstorage)(nil).GetSecretRevisionMeta), ctx, metaID, revision, userID)
+}
+
+// GetUserVaultHeader mocks base method.
+func (m *Mockstorage) GetUserVaultHeader(ctx context.Context, userID user.ID) (*vault.Header, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserVaultHeader", ctx, userID)
+	ret0, _ := ret[0].(*vault.Header)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserVaultHeader indicates an expected call of GetUserVaultHeader.
+func (mr *MockstorageMockRecorder) GetUserVaultHeader(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserVaultHeader", reflect.TypeOf((*Mockstorage)(nil).GetUserVaultHeader), ctx, userID)
+}
+
+// GetVaultHeader mocks base method.
+func (m *Mockstorage) GetVaultHeader(ctx context.Context) (*vault.Header, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVaultHeader", ctx)
+	ret0, _ := ret[0].(*vault.Header)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVaultHeader indicates an expected call of GetVaultHeader.
+func (mr *MockstorageMockRecorder) GetVaultHeader(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVaultHeader", reflect.TypeOf((*Mockstorage)(nil).GetVaultHeader), ctx)
+}
+
+// ListExpiredSecrets mocks base method.
+func (m *Mockstorage) ListExpiredSecrets(ctx context.Context, now time.Time) (vault.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpiredSecrets", ctx, now)
+	ret0, _ := ret[0].(vault.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpiredSecrets indicates an expected call of ListExpiredSecrets.
+func (mr *MockstorageMockRecorder) ListExpiredSecrets(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredSecrets", reflect.TypeOf((*Mockstorage)(nil).ListExpiredSecrets), ctx, now)
+}
+
+// ListSecretRevisions mocks base method.
+func (m *Mockstorage) ListSecretRevisions(ctx context.Context, metaID vault.MetaID, userID user.ID) (vault.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSecretRevisions", ctx, metaID, userID)
+	ret0, _ := ret[0].(vault.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSecretRevisions indicates an expected call of ListSecretRevisions.
+func (mr *MockstorageMockRecorder) ListSecretRevisions(ctx, metaID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecretRevisions", reflect.TypeOf((*Mockstorage)(nil).ListSecretRevisions), ctx, metaID, userID)
+}
+
+// ListSecretsByFilter mocks base method.
+func (m *Mockstorage) ListSecretsByFilter(ctx context.Context, userID user.ID, filter vault.Filter) (vault.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSecretsByFilter", ctx, userID, filter)
+	ret0, _ := ret[0].(vault.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSecretsByFilter indicates an expected call of ListSecretsByFilter.
+func (mr *MockstorageMockRecorder) ListSecretsByFilter(ctx, userID, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecretsByFilter", reflect.TypeOf((*Mockstorage)(nil).ListSecretsByFilter), ctx, userID, filter)
+}
+
 // ListSecretsByUser mocks base method.
 func (m *Mockstorage) ListSecretsByUser(ctx context.Context, userID user.ID) (vault.List, error) {
 	m.ctrl.T.Helper()
@@ -125,6 +260,34 @@ func (mr *MockstorageMockRecorder) PutSecret(ctx, meta, data interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutSecret", reflect.TypeOf((*Mockstorage)(nil).PutSecret), ctx, meta, data)
 }
 
+// PutUserVaultHeader mocks base method.
+func (m *Mockstorage) PutUserVaultHeader(ctx context.Context, userID user.ID, h vault.Header) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutUserVaultHeader", ctx, userID, h)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutUserVaultHeader indicates an expected call of PutUserVaultHeader.
+func (mr *MockstorageMockRecorder) PutUserVaultHeader(ctx, userID, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutUserVaultHeader", reflect.TypeOf((*Mockstorage)(nil).PutUserVaultHeader), ctx, userID, h)
+}
+
+// PutVaultHeader mocks base method.
+func (m *Mockstorage) PutVaultHeader(ctx context.Context, h vault.Header) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutVaultHeader", ctx, h)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutVaultHeader indicates an expected call of PutVaultHeader.
+func (mr *MockstorageMockRecorder) PutVaultHeader(ctx, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutVaultHeader", reflect.TypeOf((*Mockstorage)(nil).PutVaultHeader), ctx, h)
+}
+
 // UpdateSecret mocks base method.
 func (m *Mockstorage) UpdateSecret(ctx context.Context, meta vault.Meta, data *vault.DataReader) (*vault.Meta, error) {
 	m.ctrl.T.Helper()
@@ -155,6 +318,20 @@ func (mr *MockstorageMockRecorder) UpdateSecretMeta(ctx, meta interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSecretMeta", reflect.TypeOf((*Mockstorage)(nil).UpdateSecretMeta), ctx, meta)
 }
 
+// UpdateSecretRevisionMeta mocks base method.
+func (m *Mockstorage) UpdateSecretRevisionMeta(ctx context.Context, meta vault.Meta) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSecretRevisionMeta", ctx, meta)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSecretRevisionMeta indicates an expected call of UpdateSecretRevisionMeta.
+func (mr *MockstorageMockRecorder) UpdateSecretRevisionMeta(ctx, meta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSecretRevisionMeta", reflect.TypeOf((*Mockstorage)(nil).UpdateSecretRevisionMeta), ctx, meta)
+}
+
 // Mocklogger is a mock of logger interface.
 type Mocklogger struct {
 	ctrl     *gomock.Controller
@@ -0,0 +1,519 @@
+package keeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/k1nky/gophkeeper/internal/crypto"
+	"github.com/k1nky/gophkeeper/internal/entity/user"
+	"github.com/k1nky/gophkeeper/internal/entity/vault"
+)
+
+// UnlockVault возвращает ключ шифрования хранилища, полученный из учетных данных c с параметрами из заголовка хранилища.
+// Если хранилище еще не инициализировано, то создается заголовок с параметрами формирования ключа params.
+// Если секрет не подходит к хранилищу, то возвращается vault.ErrWrongSecret. Хранилище, созданное с ключевым
+// файлом, не открывается без него.
+func (s *Service) UnlockVault(ctx context.Context, c vault.Credentials, params crypto.KDFParams) (*crypto.Key, error) {
+	h, err := s.store.GetVaultHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if h != nil {
+		if h.Pending != nil {
+			return nil, vault.ErrRekeyInProgress
+		}
+		key, err := deriveKey(c, *h)
+		if err != nil {
+			return nil, err
+		}
+		if len(h.Check) != 0 {
+			return key, checkKey(key, *h)
+		}
+		// хранилище создано до появления контрольного значения ключа, сохраняем его для текущего секрета
+		if h.Check, err = crypto.KeyCheckValue(key); err != nil {
+			return nil, err
+		}
+		if err := s.store.PutVaultHeader(ctx, *h); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	key, header, err := newHeader(c, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.PutVaultHeader(ctx, header); err != nil {
+		return nil, err
+	}
+	s.log.Debugf("keeper: vault initialized with %s", params.Algorithm)
+	return key, nil
+}
+
+// CheckSecret проверяет, что учетные данные c подходят к хранилищу. Иначе возвращает vault.ErrWrongSecret.
+// Во время смены ключа проверяются текущие учетные данные хранилища.
+func (s *Service) CheckSecret(ctx context.Context, c vault.Credentials) error {
+	h, err := s.store.GetVaultHeader(ctx)
+	if err != nil {
+		return err
+	}
+	if h == nil {
+		return vault.ErrNotInitialized
+	}
+	key, err := deriveKey(c, *h)
+	if err != nil {
+		return err
+	}
+	return checkKey(key, *h)
+}
+
+// GetVaultHeader возвращает заголовок локального хранилища или nil, если хранилище еще не инициализировано.
+func (s *Service) GetVaultHeader(ctx context.Context) (*vault.Header, error) {
+	return s.store.GetVaultHeader(ctx)
+}
+
+// JoinVault делает локальное хранилище частью хранилища с заголовком h, опубликованным в удаленном хранилище.
+// Тогда ключ хранилища на всех устройствах пользователя получается одинаковым, и секреты, зашифрованные на одном
+// устройстве, расшифровываются на другом. Локальное хранилище с другим заголовком заменяется, только если в нем
+// нет секретов, иначе возвращается vault.ErrVaultMismatch. Если опубликованный заголовок заменен после смены ключа
+// на другом устройстве, то возвращается vault.ErrVaultRekeyed.
+func (s *Service) JoinVault(ctx context.Context, h vault.Header) error {
+	local, err := s.store.GetVaultHeader(ctx)
+	if err != nil {
+		return err
+	}
+	if local != nil {
+		if local.Pending != nil {
+			return vault.ErrRekeyInProgress
+		}
+		if local.SameVault(h) {
+			if local.Shared && local.Replaces == nil {
+				return nil
+			}
+			local.Shared, local.Replaces = true, nil
+			return s.store.PutVaultHeader(ctx, *local)
+		}
+		if local.Shared || local.Replaces != nil {
+			// опубликованный заголовок заменен после смены ключа на другом устройстве (см. RejoinVault)
+			return vault.ErrVaultRekeyed
+		}
+		list, err := s.ListSecretsByUser(ctx)
+		if err != nil {
+			return err
+		}
+		if len(list) != 0 {
+			return vault.ErrVaultMismatch
+		}
+		s.log.Debugf("keeper: empty local vault is replaced by the remote one")
+	}
+	h.Pending = nil
+	h.Shared = true
+	return s.store.PutVaultHeader(ctx, h)
+}
+
+// GetUserVaultHeader возвращает опубликованный заголовок хранилища пользователя, определенного в контексте,
+// или nil, если заголовок еще не опубликован.
+func (s *Service) GetUserVaultHeader(ctx context.Context) (*vault.Header, error) {
+	uid := user.LocalUserID
+	if claims, ok := user.GetEffectiveUser(ctx); ok {
+		uid = claims.ID
+	}
+	return s.store.GetUserVaultHeader(ctx, uid)
+}
+
+// PutUserVaultHeader публикует заголовок хранилища h пользователя, определенного в контексте, и возвращает
+// опубликованный заголовок. Опубликованный заголовок заменяется, только если h получен сменой ключа этого хранилища,
+// то есть h.Replaces совпадает с ним. Иначе возвращается vault.ErrVaultMismatch.
+func (s *Service) PutUserVaultHeader(ctx context.Context, h vault.Header) (*vault.Header, error) {
+	uid := user.LocalUserID
+	if claims, ok := user.GetEffectiveUser(ctx); ok {
+		uid = claims.ID
+	}
+	current, err := s.store.GetUserVaultHeader(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		if current.SameVault(h) {
+			return current, nil
+		}
+		if h.Replaces == nil || !current.SameVault(*h.Replaces) {
+			return nil, vault.ErrVaultMismatch
+		}
+		s.log.Debugf("keeper: vault header of user %d is replaced after rekey", uid)
+	}
+	h = h.Published()
+	if err := s.store.PutUserVaultHeader(ctx, uid, h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// deriveKey возвращает ключ, полученный из учетных данных c с параметрами из заголовка хранилища h.
+// Наличие ключевого файла должно соответствовать заголовку.
+func deriveKey(c vault.Credentials, h vault.Header) (*crypto.Key, error) {
+	if h.Keyfile && !c.HasKeyfile() {
+		return nil, vault.ErrKeyfileRequired
+	}
+	if !h.Keyfile && c.HasKeyfile() {
+		return nil, vault.ErrKeyfileNotUsed
+	}
+	return c.Key(h.KDF)
+}
+
+// newHeader возвращает ключ, полученный из учетных данных c с параметрами params, и заголовок хранилища для него.
+func newHeader(c vault.Credentials, params crypto.KDFParams) (*crypto.Key, vault.Header, error) {
+	h := vault.Header{KDF: params, Keyfile: c.HasKeyfile()}
+	key, err := c.Key(params)
+	if err != nil {
+		return nil, h, err
+	}
+	if h.Check, err = crypto.KeyCheckValue(key); err != nil {
+		return nil, h, err
+	}
+	return key, h, nil
+}
+
+// checkKey проверяет ключ key по контрольному значению из заголовка h. Для заголовков без контрольного значения
+// проверка невозможна, и ошибка не возвращается.
+func checkKey(key *crypto.Key, h vault.Header) error {
+	if len(h.Check) == 0 {
+		return nil
+	}
+	if err := crypto.VerifyKeyCheckValue(key, h.Check); err != nil {
+		if errors.Is(err, crypto.ErrWrongKey) {
+			return vault.ErrWrongSecret
+		}
+		return err
+	}
+	return nil
+}
+
+// SecretKey возвращает ключ, которым зашифрованы данные секрета meta. Ключ данных секрета расшифровывается
+// мастер-ключом хранилища master. Данные секретов, созданных до появления ключей данных, зашифрованы
+// непосредственно мастер-ключом.
+func SecretKey(master *crypto.Key, meta vault.Meta) (*crypto.Key, error) {
+	if len(meta.WrappedKey) == 0 {
+		return master, nil
+	}
+	key, err := crypto.UnwrapKey(master, meta.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", vault.ErrWrongSecret, err)
+	}
+	return key, nil
+}
+
+// DecryptSecret возвращает читатель для расшифровки данных r секрета meta мастер-ключом хранилища master.
+// Ключ проверяется по заголовку данных до того, как будут расшифрованы сами данные. Если данные зашифрованы
+// другим ключом, то возвращается vault.ErrWrongSecret.
+func DecryptSecret(master *crypto.Key, meta vault.Meta, r io.Reader) (*crypto.DecryptReader, error) {
+	key, err := SecretKey(master, meta)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := crypto.NewDecryptReader(key, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := dec.Verify(); err != nil {
+		if errors.Is(err, crypto.ErrWrongKey) {
+			return nil, fmt.Errorf("%w: %w", vault.ErrWrongSecret, err)
+		}
+		return nil, err
+	}
+	return dec, nil
+}
+
+// Rekey переводит хранилище с учетных данных oldCred на учетные данные newCred с параметрами формирования ключа params.
+// Так можно сменить пароль, а также добавить, заменить или убрать ключевой файл.
+// Ключи данных секретов перешифровываются новым мастер-ключом, а данные секретов без ключа данных перешифровываются
+// полностью. Версии измененных секретов повышаются, чтобы их можно было отправить в удаленное хранилище.
+// Метод возвращает удаленные предыдущие версии секретов, которые не удалось перевести на новый ключ (см. rekeyRevisions).
+//
+// Перед изменениями новый заголовок сохраняется в заголовке хранилища (Header.Pending) и служит журналом. Пока смена
+// ключа не завершена, хранилище не открывается, а прерванную смену ключа можно продолжить повторным вызовом
+// с теми же учетными данными. В этом случае используются параметры из журнала, а уже переведенные секреты пропускаются.
+// Если какой-либо секрет или его предыдущую версию не удается перевести на новый ключ, то хранилище не изменяется.
+//
+// Если заголовок хранилища опубликован в удаленном хранилище (Header.Shared), то новый заголовок запоминает его
+// (Header.Replaces) и заменяет при следующей синхронизации заголовков. Остальные устройства переходят на новый
+// заголовок через RejoinVault. Предыдущие версии секретов в удаленном хранилище остаются зашифрованы старым ключом.
+func (s *Service) Rekey(ctx context.Context, oldCred vault.Credentials, newCred vault.Credentials, params crypto.KDFParams) (vault.List, error) {
+	return s.rekey(ctx, oldCred, newCred, params, nil)
+}
+
+// RejoinVault переводит локальное хранилище с учетных данных oldCred на учетные данные newCred заголовка h, который
+// опубликован в удаленном хранилище после смены ключа на другом устройстве. Секреты переводятся на новый ключ так же,
+// как в Rekey, но их версии не повышаются: в удаленном хранилище уже есть секреты, переведенные на новый ключ.
+// Повышаются только версии секретов без ключа данных, данные которых перешифровываются. Если новые учетные данные
+// не подходят к h, то возвращается vault.ErrWrongSecret.
+func (s *Service) RejoinVault(ctx context.Context, oldCred vault.Credentials, newCred vault.Credentials, h vault.Header) (vault.List, error) {
+	return s.rekey(ctx, oldCred, newCred, h.KDF, &h)
+}
+
+// rekey переводит хранилище с учетных данных oldCred на учетные данные newCred. Если задан опубликованный заголовок
+// target, то хранилище переводится на него, иначе создается новый заголовок с параметрами params.
+func (s *Service) rekey(ctx context.Context, oldCred vault.Credentials, newCred vault.Credentials, params crypto.KDFParams, target *vault.Header) (vault.List, error) {
+	h, err := s.store.GetVaultHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return nil, vault.ErrNotInitialized
+	}
+	oldKey, err := deriveKey(oldCred, *h)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKey(oldKey, *h); err != nil {
+		return nil, err
+	}
+	resume := h.Pending != nil
+	if resume {
+		params = h.Pending.KDF
+		// журнал перехода на опубликованный заголовок отмечен как общий, так прерванный переход продолжается
+		// тем же способом, каким был начат
+		if h.Pending.Shared {
+			target = h.Pending
+		} else {
+			target = nil
+		}
+		s.log.Debugf("keeper: resuming vault rekey")
+	}
+	newKey, next, err := newHeader(newCred, params)
+	if err != nil {
+		return nil, err
+	}
+	if target != nil {
+		// новые учетные данные должны подходить к опубликованному заголовку
+		if next.Keyfile != target.Keyfile {
+			return nil, vault.ErrWrongSecret
+		}
+		if err := checkKey(newKey, *target); err != nil {
+			return nil, err
+		}
+		next = target.Published()
+		next.Shared = true
+	}
+	if resume && target == nil {
+		// продолжить смену ключа можно только с теми же новыми учетными данными
+		if next.Keyfile != h.Pending.Keyfile {
+			return nil, vault.ErrWrongSecret
+		}
+		if err := checkKey(newKey, *h.Pending); err != nil {
+			return nil, err
+		}
+	}
+	list, err := s.ListSecretsByUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// прежде чем что-то менять, убеждаемся, что все секреты можно расшифровать
+	pending := make(vault.List, 0, len(list))
+	for _, m := range list {
+		done, err := s.isRekeyed(ctx, m, oldKey, newKey)
+		if err != nil {
+			return nil, fmt.Errorf("rekey %s: %w", m.ID, err)
+		}
+		if !done {
+			pending = append(pending, m)
+		}
+		if err := s.checkRevisions(ctx, m, oldKey, newKey); err != nil {
+			return nil, fmt.Errorf("rekey revisions of %s: %w", m.ID, err)
+		}
+	}
+	if !resume {
+		h.Pending = &next
+		if err := s.store.PutVaultHeader(ctx, *h); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range pending {
+		if err := s.rekeySecret(ctx, m, oldKey, newKey, target == nil); err != nil {
+			return nil, fmt.Errorf("rekey %s: %w", m.ID, err)
+		}
+		s.log.Debugf("keeper: rekey %s", m)
+	}
+	dropped := make(vault.List, 0)
+	for _, m := range list {
+		d, err := s.rekeyRevisions(ctx, m, oldKey, newKey)
+		if err != nil {
+			return nil, fmt.Errorf("rekey revisions of %s: %w", m.ID, err)
+		}
+		dropped = append(dropped, d...)
+	}
+	// все секреты переведены на новый ключ, фиксируем новый заголовок
+	committed := *h.Pending
+	if target == nil && (h.Shared || h.Replaces != nil) {
+		// в удаленном хранилище все еще опубликован заголовок, с которого началась смена ключа
+		replaces := h.Published()
+		if h.Replaces != nil {
+			replaces = *h.Replaces
+		}
+		committed.Replaces = &replaces
+	}
+	return dropped, s.store.PutVaultHeader(ctx, committed)
+}
+
+// isRekeyed возвращает true, если секрет meta уже переведен на новый мастер-ключ newKey.
+// Если секрет не удается расшифровать старым ключом oldKey, то возвращается ошибка.
+func (s *Service) isRekeyed(ctx context.Context, meta vault.Meta, oldKey *crypto.Key, newKey *crypto.Key) (bool, error) {
+	if len(meta.WrappedKey) != 0 {
+		if _, err := crypto.UnwrapKey(newKey, meta.WrappedKey); err == nil {
+			return true, nil
+		}
+		_, err := crypto.UnwrapKey(oldKey, meta.WrappedKey)
+		return false, err
+	}
+	// данные секрета зашифрованы мастер-ключом, проверяем, что первый сегмент расшифровывается старым ключом
+	data, err := s.store.GetSecretData(ctx, meta.ID, meta.UserID)
+	if err != nil {
+		return false, err
+	}
+	defer data.Close()
+	dec, err := crypto.NewDecryptReader(oldKey, data)
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.ReadFull(dec, make([]byte, 1)); err != nil && err != io.EOF {
+		return false, err
+	}
+	return false, nil
+}
+
+// checkRevisions проверяет, что ключи данных и зашифрованные мета-данные всех предыдущих версий секрета meta
+// расшифровываются старым мастер-ключом oldKey или уже переведены на новый newKey. Версии без ключа данных
+// при смене ключа удаляются и не проверяются.
+func (s *Service) checkRevisions(ctx context.Context, meta vault.Meta, oldKey *crypto.Key, newKey *crypto.Key) error {
+	revisions, err := s.store.ListSecretRevisions(ctx, meta.ID, meta.UserID)
+	if err != nil {
+		return err
+	}
+	for _, r := range revisions {
+		if len(r.WrappedKey) == 0 {
+			continue
+		}
+		if _, err := crypto.UnwrapKey(newKey, r.WrappedKey); err == nil {
+			continue
+		}
+		if _, err := crypto.UnwrapKey(oldKey, r.WrappedKey); err != nil {
+			return fmt.Errorf("revision %d: %w", r.Revision, err)
+		}
+		if _, err := r.Unseal(oldKey); err != nil {
+			return fmt.Errorf("revision %d: %w", r.Revision, err)
+		}
+	}
+	return nil
+}
+
+// rekeyRevisions переводит предыдущие версии секрета meta со старого мастер-ключа oldKey на новый newKey
+// и возвращает удаленные версии. Версии без ключа данных зашифрованы непосредственно мастер-ключом, перевести их
+// можно только перешифровав данные, поэтому они удаляются.
+func (s *Service) rekeyRevisions(ctx context.Context, meta vault.Meta, oldKey *crypto.Key, newKey *crypto.Key) (vault.List, error) {
+	revisions, err := s.store.ListSecretRevisions(ctx, meta.ID, meta.UserID)
+	if err != nil {
+		return nil, err
+	}
+	dropped := make(vault.List, 0)
+	for _, r := range revisions {
+		if len(r.WrappedKey) == 0 {
+			s.log.Debugf("keeper: rekey drops revision %d of %s without data key", r.Revision, r.ID)
+			if err := s.store.DeleteSecretRevision(ctx, r); err != nil {
+				return nil, err
+			}
+			dropped = append(dropped, r)
+			continue
+		}
+		if _, err := crypto.UnwrapKey(newKey, r.WrappedKey); err == nil {
+			continue
+		}
+		key, err := crypto.UnwrapKey(oldKey, r.WrappedKey)
+		if err != nil {
+			return nil, fmt.Errorf("revision %d: %w", r.Revision, err)
+		}
+		if r.WrappedKey, err = crypto.WrapKey(newKey, key); err != nil {
+			return nil, err
+		}
+		if r, err = reseal(r, oldKey, newKey); err != nil {
+			return nil, fmt.Errorf("revision %d: %w", r.Revision, err)
+		}
+		if err := s.store.UpdateSecretRevisionMeta(ctx, r); err != nil {
+			return nil, err
+		}
+	}
+	return dropped, nil
+}
+
+// reseal перешифровывает зашифрованные мета-данные meta со старого мастер-ключа oldKey на новый newKey, в т.ч.
+// заменяет слепой индекс псевдонима. Открытые мета-данные не меняются.
+func reseal(meta vault.Meta, oldKey *crypto.Key, newKey *crypto.Key) (vault.Meta, error) {
+	if !meta.IsSealed() {
+		return meta, nil
+	}
+	m, err := meta.Unseal(oldKey)
+	if err != nil {
+		return meta, err
+	}
+	return m.Seal(newKey)
+}
+
+// rekeySecret переводит секрет meta со старого мастер-ключа oldKey на новый newKey. Если bump равен true, то версия
+// секрета повышается, чтобы переведенный секрет можно было отправить в удаленное хранилище.
+func (s *Service) rekeySecret(ctx context.Context, meta vault.Meta, oldKey *crypto.Key, newKey *crypto.Key, bump bool) error {
+	current := meta
+	var err error
+	if meta, err = reseal(meta, oldKey, newKey); err != nil {
+		return err
+	}
+	if len(meta.WrappedKey) != 0 {
+		if bump {
+			if err := s.nextVersion(ctx, &meta, &current); err != nil {
+				return err
+			}
+		}
+		key, err := crypto.UnwrapKey(oldKey, meta.WrappedKey)
+		if err != nil {
+			return err
+		}
+		if meta.WrappedKey, err = crypto.WrapKey(newKey, key); err != nil {
+			return err
+		}
+		_, err = s.store.UpdateSecretMeta(ctx, meta)
+		return err
+	}
+	// у секрета нет ключа данных, поэтому перешифровываем данные новым ключом данных, а данные новой версии
+	// хранятся отдельно от текущей, так что версия повышается всегда
+	if err := s.nextVersion(ctx, &meta, &current); err != nil {
+		return err
+	}
+	data, err := s.store.GetSecretData(ctx, meta.ID, meta.UserID)
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+	dec, err := crypto.NewDecryptReader(oldKey, data)
+	if err != nil {
+		return err
+	}
+	key, err := crypto.NewDataKey()
+	if err != nil {
+		return err
+	}
+	if meta.WrappedKey, err = crypto.WrapKey(newKey, key); err != nil {
+		return err
+	}
+	enc, err := crypto.NewEncryptReaderWithCompression(key, dec, meta.Type.Compression())
+	if err != nil {
+		return err
+	}
+	if _, err = s.store.UpdateSecret(ctx, meta, vault.NewDataReader(enc)); err != nil {
+		return err
+	}
+	// прежняя версия попала в историю зашифрованной старым ключом, но ее данные совпадают с перешифрованными
+	prev, err := s.store.GetSecretRevisionMeta(ctx, meta.ID, current.Revision, meta.UserID)
+	if err != nil || prev == nil {
+		return err
+	}
+	return s.store.DeleteSecretRevision(ctx, *prev)
+}
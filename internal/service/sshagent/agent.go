@@ -0,0 +1,363 @@
+// Пакет sshagent реализует агент SSH, который выдает ключи SSH, хранящиеся в хранилище секретов.
+// Ключи расшифровываются только в памяти и никогда не записываются на диск.
+package sshagent
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/k1nky/gophkeeper/internal/crypto"
+	"github.com/k1nky/gophkeeper/internal/entity/vault"
+	"github.com/k1nky/gophkeeper/internal/service/keeper"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	ErrLocked      = errors.New("agent is locked")
+	ErrNotLocked   = errors.New("agent is not locked")
+	ErrReadOnly    = errors.New("agent keys are managed by the vault")
+	ErrDenied      = errors.New("key usage is denied")
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+// UnlockFunc возвращает ключ хранилища по паролю passphrase.
+type UnlockFunc func(passphrase []byte) (*crypto.Key, error)
+
+// ConfirmFunc запрашивает у пользователя разрешение на использование ключа секрета alias.
+type ConfirmFunc func(alias string) bool
+
+// entry ключ SSH, выдаваемый агентом.
+type entry struct {
+	alias  string
+	signer ssh.Signer
+	// открытый ключ в формате протокола, по нему ищется ключ для подписи
+	blob    []byte
+	comment string
+}
+
+// Agent агент SSH. Реализует agent.ExtendedAgent.
+type Agent struct {
+	storage storage
+	log     logger
+	unlock  UnlockFunc
+	confirm ConfirmFunc
+	// псевдонимы секретов, ключи которых выдает агент; если пустой, то выдаются все ключи
+	allow map[string]bool
+	// время бездействия, после которого агент блокируется
+	idle time.Duration
+
+	// последовательные запросы подтверждения, чтобы они не перемешивались
+	confirmMu sync.Mutex
+	mu        sync.Mutex
+	ctx       context.Context
+	keys      []entry
+	locked    bool
+	timer     *time.Timer
+}
+
+var _ agent.ExtendedAgent = (*Agent)(nil)
+
+// New возвращает новый экземпляр агента. Функция unlock используется для разблокировки агента
+// паролем хранилища (ssh-add -X).
+func New(storage storage, unlock UnlockFunc, log logger) *Agent {
+	return &Agent{
+		storage: storage,
+		unlock:  unlock,
+		log:     log,
+		locked:  true,
+	}
+}
+
+// SetConfirm задает функцию подтверждения использования ключей. Если не задана, то подтверждение не запрашивается.
+func (a *Agent) SetConfirm(confirm ConfirmFunc) {
+	a.confirm = confirm
+}
+
+// SetAllowList ограничивает ключи агента ключами секретов с псевдонимами aliases.
+func (a *Agent) SetAllowList(aliases []string) {
+	a.allow = make(map[string]bool, len(aliases))
+	for _, alias := range aliases {
+		a.allow[alias] = true
+	}
+}
+
+// SetIdleTimeout задает время бездействия, после которого агент блокируется. При d <= 0 агент не блокируется.
+func (a *Agent) SetIdleTimeout(d time.Duration) {
+	a.idle = d
+}
+
+// Open загружает в агент ключи SSH из хранилища, расшифровывая их ключом хранилища master.
+func (a *Agent) Open(ctx context.Context, master *crypto.Key) error {
+	keys, err := a.load(ctx, master)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.ctx = ctx
+	a.keys = keys
+	a.locked = false
+	a.touch()
+	return nil
+}
+
+// Close блокирует агент и останавливает таймер бездействия.
+func (a *Agent) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lock()
+	return nil
+}
+
+// Serve обслуживает подключения к агенту через l, пока не будет отменен контекст ctx.
+func (a *Agent) Serve(ctx context.Context, l net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			if err := agent.ServeAgent(a, conn); err != nil && !errors.Is(err, io.EOF) {
+				a.log.Debugf("ssh-agent: %v", err)
+			}
+		}()
+	}
+}
+
+// load расшифровывает ключи SSH из хранилища. Ключ, который не удалось расшифровать, пропускается.
+func (a *Agent) load(ctx context.Context, master *crypto.Key) ([]entry, error) {
+	list, err := a.storage.ListSecretsByUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := []entry{}
+	for _, meta := range list {
+		if meta.IsDeleted || meta.Type != vault.TypeSSHKey || !a.allowed(meta.Alias) {
+			continue
+		}
+		k, err := a.loadKey(ctx, master, meta)
+		if err != nil {
+			a.log.Errorf("ssh-agent: load key %s: %v", meta.Alias, err)
+			continue
+		}
+		keys = append(keys, *k)
+		a.log.Debugf("ssh-agent: loaded key %s", meta.Alias)
+	}
+	return keys, nil
+}
+
+func (a *Agent) loadKey(ctx context.Context, master *crypto.Key, meta vault.Meta) (*entry, error) {
+	data, err := a.storage.GetSecretData(ctx, meta.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer data.Close()
+	dec, err := keeper.DecryptSecret(master, meta, data)
+	if err != nil {
+		return nil, err
+	}
+	secret := &vault.SSHKey{}
+	if _, err := vault.DecodePayload(secret, dec); err != nil {
+		return nil, err
+	}
+	raw, err := secret.RawPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.NewSignerFromKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	comment := secret.Comment
+	if len(comment) == 0 {
+		comment = meta.Alias
+	}
+	return &entry{
+		alias:   meta.Alias,
+		signer:  signer,
+		blob:    signer.PublicKey().Marshal(),
+		comment: comment,
+	}, nil
+}
+
+func (a *Agent) allowed(alias string) bool {
+	return len(a.allow) == 0 || a.allow[alias]
+}
+
+// touch откладывает блокировку по бездействию. Вызывается под a.mu.
+func (a *Agent) touch() {
+	if a.idle <= 0 {
+		return
+	}
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+	var t *time.Timer
+	t = time.AfterFunc(a.idle, func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		// таймер мог сработать одновременно с новым обращением к агенту
+		if a.timer != t {
+			return
+		}
+		a.log.Debugf("ssh-agent: locked after %s of inactivity", a.idle)
+		a.lock()
+	})
+	a.timer = t
+}
+
+// lock удаляет расшифрованные ключи из агента. Вызывается под a.mu.
+func (a *Agent) lock() {
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	a.keys = nil
+	a.locked = true
+}
+
+// List возвращает открытые ключи агента. Заблокированный агент возвращает пустой список.
+func (a *Agent) List() ([]*agent.Key, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	keys := []*agent.Key{}
+	if a.locked {
+		return keys, nil
+	}
+	a.touch()
+	for _, k := range a.keys {
+		keys = append(keys, &agent.Key{
+			Format:  k.signer.PublicKey().Type(),
+			Blob:    k.blob,
+			Comment: k.comment,
+		})
+	}
+	return keys, nil
+}
+
+func (a *Agent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, data, 0)
+}
+
+// SignWithFlags подписывает данные data ключом key. Если задана функция подтверждения, то перед подписью
+// запрашивается разрешение пользователя.
+func (a *Agent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	k, err := a.find(key)
+	if err != nil {
+		return nil, err
+	}
+	if a.confirm != nil {
+		a.confirmMu.Lock()
+		ok := a.confirm(k.alias)
+		a.confirmMu.Unlock()
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrDenied, k.alias)
+		}
+	}
+	a.log.Debugf("ssh-agent: sign with key %s", k.alias)
+	if s, ok := k.signer.(ssh.AlgorithmSigner); ok && k.signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		switch {
+		case flags&agent.SignatureFlagRsaSha256 != 0:
+			return s.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA256)
+		case flags&agent.SignatureFlagRsaSha512 != 0:
+			return s.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA512)
+		}
+	}
+	return k.signer.Sign(rand.Reader, data)
+}
+
+// find возвращает ключ агента по открытому ключу key.
+func (a *Agent) find(key ssh.PublicKey) (*entry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.locked {
+		return nil, ErrLocked
+	}
+	a.touch()
+	blob := key.Marshal()
+	for _, k := range a.keys {
+		if bytes.Equal(k.blob, blob) {
+			return &k, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// Signers возвращает ключи агента. Если требуется подтверждение использования ключей, то ключи не выдаются.
+func (a *Agent) Signers() ([]ssh.Signer, error) {
+	if a.confirm != nil {
+		return nil, ErrDenied
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.locked {
+		return nil, ErrLocked
+	}
+	signers := []ssh.Signer{}
+	for _, k := range a.keys {
+		signers = append(signers, k.signer)
+	}
+	return signers, nil
+}
+
+// Lock блокирует агент. Расшифрованные ключи удаляются из памяти, поэтому разблокировать агент
+// можно только паролем хранилища, а не passphrase.
+func (a *Agent) Lock(passphrase []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.locked {
+		return ErrLocked
+	}
+	a.lock()
+	return nil
+}
+
+// Unlock разблокирует агент паролем хранилища passphrase и заново загружает ключи из хранилища.
+func (a *Agent) Unlock(passphrase []byte) error {
+	a.mu.Lock()
+	locked, ctx := a.locked, a.ctx
+	a.mu.Unlock()
+	if !locked {
+		return ErrNotLocked
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	master, err := a.unlock(passphrase)
+	if err != nil {
+		return err
+	}
+	return a.Open(ctx, master)
+}
+
+func (a *Agent) Add(key agent.AddedKey) error {
+	return ErrReadOnly
+}
+
+func (a *Agent) Remove(key ssh.PublicKey) error {
+	return ErrReadOnly
+}
+
+func (a *Agent) RemoveAll() error {
+	return ErrReadOnly
+}
+
+func (a *Agent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
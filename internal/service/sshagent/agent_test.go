@@ -0,0 +1,208 @@
+package sshagent
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/k1nky/gophkeeper/internal/crypto"
+	"github.com/k1nky/gophkeeper/internal/entity/vault"
+	log "github.com/k1nky/gophkeeper/internal/logger"
+	"github.com/k1nky/gophkeeper/internal/service/sshagent/mock"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+type agentTestSuite struct {
+	suite.Suite
+	store  *mock.Mockstorage
+	master *crypto.Key
+	agent  *Agent
+	// зашифрованные данные секретов по ИД
+	data map[vault.MetaID][]byte
+	list vault.List
+	keys map[string]*vault.SSHKey
+}
+
+func TestAgent(t *testing.T) {
+	suite.Run(t, new(agentTestSuite))
+}
+
+func (suite *agentTestSuite) SetupTest() {
+	ctrl := gomock.NewController(suite.T())
+	suite.store = mock.NewMockstorage(ctrl)
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1}
+	var err error
+	suite.master, err = crypto.DeriveKey("secret", params)
+	suite.Require().NoError(err)
+	suite.agent = New(suite.store, func(passphrase []byte) (*crypto.Key, error) {
+		if string(passphrase) != "secret" {
+			return nil, vault.ErrWrongSecret
+		}
+		return suite.master, nil
+	}, &log.Blackhole{})
+	suite.data = map[vault.MetaID][]byte{}
+	suite.list = vault.List{}
+	suite.keys = map[string]*vault.SSHKey{}
+	suite.addKey("k1", vault.SSHKeyEd25519)
+	suite.addKey("k2", vault.SSHKeyRSA)
+	suite.addSecret(vault.Meta{ID: vault.NewMetaID(), Alias: "text", Type: vault.TypeText}, []byte("not a key"))
+	suite.store.EXPECT().ListSecretsByUser(gomock.Any()).Return(suite.list, nil).AnyTimes()
+	suite.store.EXPECT().GetSecretData(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, id vault.MetaID) (*vault.DataReader, error) {
+		return vault.NewDataReader(vault.NewBytesBuffer(suite.data[id])), nil
+	}).AnyTimes()
+}
+
+func (suite *agentTestSuite) addKey(alias string, algorithm string) {
+	k := &vault.SSHKey{}
+	suite.Require().NoError(k.Generate(vault.GenerateOptions{Algorithm: algorithm, Bits: 2048, Comment: alias + "@test"}))
+	r, err := k.Encode()
+	suite.Require().NoError(err)
+	b, err := io.ReadAll(r)
+	suite.Require().NoError(err)
+	suite.keys[alias] = k
+	suite.addSecret(vault.Meta{ID: vault.NewMetaID(), Alias: alias, Type: vault.TypeSSHKey}, b)
+}
+
+func (suite *agentTestSuite) addSecret(meta vault.Meta, plaintext []byte) {
+	key, err := crypto.NewDataKey()
+	suite.Require().NoError(err)
+	meta.WrappedKey, err = crypto.WrapKey(suite.master, key)
+	suite.Require().NoError(err)
+	enc, err := crypto.NewEncryptReader(key, bytes.NewReader(plaintext))
+	suite.Require().NoError(err)
+	suite.data[meta.ID], err = io.ReadAll(enc)
+	suite.Require().NoError(err)
+	suite.list = append(suite.list, meta)
+}
+
+// client возвращает клиента, подключенного к агенту.
+func (suite *agentTestSuite) client() agent.ExtendedAgent {
+	c, s := net.Pipe()
+	go agent.ServeAgent(suite.agent, s)
+	suite.T().Cleanup(func() {
+		c.Close()
+	})
+	return agent.NewClient(c)
+}
+
+func (suite *agentTestSuite) publicKey(alias string) ssh.PublicKey {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(suite.keys[alias].PublicKey))
+	suite.Require().NoError(err)
+	return pub
+}
+
+func (suite *agentTestSuite) TestListAndSign() {
+	suite.NoError(suite.agent.Open(context.TODO(), suite.master))
+	client := suite.client()
+	keys, err := client.List()
+	suite.NoError(err)
+	suite.Len(keys, 2)
+	comments := []string{}
+	for _, k := range keys {
+		comments = append(comments, k.Comment)
+	}
+	suite.ElementsMatch([]string{"k1@test", "k2@test"}, comments)
+
+	data := []byte("session data")
+	for _, alias := range []string{"k1", "k2"} {
+		pub := suite.publicKey(alias)
+		sig, err := client.Sign(pub, data)
+		suite.NoError(err)
+		suite.NoError(pub.Verify(data, sig))
+	}
+	// ключи RSA подписываются алгоритмом, запрошенным клиентом
+	sig, err := client.SignWithFlags(suite.publicKey("k2"), data, agent.SignatureFlagRsaSha256)
+	suite.NoError(err)
+	suite.Equal(ssh.KeyAlgoRSASHA256, sig.Format)
+}
+
+func (suite *agentTestSuite) TestSignUnknownKey() {
+	suite.NoError(suite.agent.Open(context.TODO(), suite.master))
+	other := &vault.SSHKey{}
+	suite.NoError(other.Generate(vault.GenerateOptions{}))
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(other.PublicKey))
+	suite.NoError(err)
+	_, err = suite.agent.Sign(pub, []byte("data"))
+	suite.ErrorIs(err, ErrKeyNotFound)
+}
+
+func (suite *agentTestSuite) TestAllowList() {
+	suite.agent.SetAllowList([]string{"k2"})
+	suite.NoError(suite.agent.Open(context.TODO(), suite.master))
+	keys, err := suite.agent.List()
+	suite.NoError(err)
+	suite.Len(keys, 1)
+	suite.Equal("k2@test", keys[0].Comment)
+	_, err = suite.agent.Sign(suite.publicKey("k1"), []byte("data"))
+	suite.ErrorIs(err, ErrKeyNotFound)
+}
+
+func (suite *agentTestSuite) TestConfirm() {
+	asked := []string{}
+	allow := false
+	suite.agent.SetConfirm(func(alias string) bool {
+		asked = append(asked, alias)
+		return allow
+	})
+	suite.NoError(suite.agent.Open(context.TODO(), suite.master))
+	pub := suite.publicKey("k1")
+	_, err := suite.agent.Sign(pub, []byte("data"))
+	suite.ErrorIs(err, ErrDenied)
+	allow = true
+	sig, err := suite.agent.Sign(pub, []byte("data"))
+	suite.NoError(err)
+	suite.NoError(pub.Verify([]byte("data"), sig))
+	suite.Equal([]string{"k1", "k1"}, asked)
+	// без подтверждения ключи не выдаются
+	_, err = suite.agent.Signers()
+	suite.ErrorIs(err, ErrDenied)
+}
+
+func (suite *agentTestSuite) TestLockUnlock() {
+	suite.NoError(suite.agent.Open(context.TODO(), suite.master))
+	client := suite.client()
+	suite.NoError(client.Lock([]byte("any")))
+	keys, err := client.List()
+	suite.NoError(err)
+	suite.Empty(keys)
+	_, err = suite.agent.Sign(suite.publicKey("k1"), []byte("data"))
+	suite.ErrorIs(err, ErrLocked)
+	// разблокировать агент можно только паролем хранилища
+	suite.Error(client.Unlock([]byte("any")))
+	suite.NoError(client.Unlock([]byte("secret")))
+	keys, err = client.List()
+	suite.NoError(err)
+	suite.Len(keys, 2)
+}
+
+func (suite *agentTestSuite) TestIdleLock() {
+	suite.agent.SetIdleTimeout(50 * time.Millisecond)
+	suite.NoError(suite.agent.Open(context.TODO(), suite.master))
+	keys, err := suite.agent.List()
+	suite.NoError(err)
+	suite.Len(keys, 2)
+	time.Sleep(200 * time.Millisecond)
+	keys, err = suite.agent.List()
+	suite.NoError(err)
+	suite.Empty(keys)
+	suite.NoError(suite.agent.Unlock([]byte("secret")))
+	keys, err = suite.agent.List()
+	suite.NoError(err)
+	suite.Len(keys, 2)
+}
+
+func (suite *agentTestSuite) TestReadOnly() {
+	suite.NoError(suite.agent.Open(context.TODO(), suite.master))
+	client := suite.client()
+	suite.Error(client.RemoveAll())
+	suite.Error(client.Remove(suite.publicKey("k1")))
+	keys, err := client.List()
+	suite.NoError(err)
+	suite.Len(keys, 2)
+}
@@ -0,0 +1,18 @@
+package sshagent
+
+import (
+	"context"
+
+	"github.com/k1nky/gophkeeper/internal/entity/vault"
+)
+
+//go:generate mockgen -source=contract.go -destination=mock/storage.go -package=mock storage
+type storage interface {
+	GetSecretData(ctx context.Context, id vault.MetaID) (*vault.DataReader, error)
+	ListSecretsByUser(ctx context.Context) (vault.List, error)
+}
+
+type logger interface {
+	Errorf(template string, args ...interface{})
+	Debugf(template string, args ...interface{})
+}
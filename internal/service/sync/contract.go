@@ -3,6 +3,7 @@ package sync
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
 )
@@ -14,13 +15,20 @@ type storage interface {
 	GetSecretMetaByAlias(ctx context.Context, alias string) (*vault.Meta, error)
 	ListSecretsByUser(ctx context.Context) (vault.List, error)
 	PutSecret(ctx context.Context, meta vault.Meta, data *vault.DataReader) (*vault.Meta, error)
+	GetVaultHeader(ctx context.Context) (*vault.Header, error)
+	JoinVault(ctx context.Context, h vault.Header) error
 }
 
 type client interface {
-	ListSecrets(ctx context.Context) (vault.List, error)
+	ListSecrets(ctx context.Context, filter vault.Filter) (vault.List, error)
+	ListRevisions(ctx context.Context, id vault.MetaID) (vault.List, error)
+	ListExpiring(ctx context.Context, within time.Duration) (vault.List, error)
 	GetSecretMeta(ctx context.Context, id vault.MetaID) (*vault.Meta, error)
+	GetSecretMetaByAlias(ctx context.Context, alias string) (*vault.Meta, error)
 	GetSecretData(ctx context.Context, id vault.MetaID, w io.Writer) error
 	PutSecret(ctx context.Context, meta vault.Meta, r io.Reader) (*vault.Meta, error)
+	GetVaultHeader(ctx context.Context) (*vault.Header, error)
+	PutVaultHeader(ctx context.Context, h vault.Header) error
 }
 
 type logger interface {
@@ -8,6 +8,7 @@ import (
 	context "context"
 	io "io"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	vault "github.com/k1nky/gophkeeper/internal/entity/vault"
@@ -81,6 +82,35 @@ func (mr *MockstorageMockRecorder) GetSecretMetaByAlias(ctx, alias interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretMetaByAlias", reflect.TypeOf((*Mockstorage)(nil).GetSecretMetaByAlias), ctx, alias)
 }
 
+// GetVaultHeader mocks base method.
+func (m *Mockstorage) GetVaultHeader(ctx context.Context) (*vault.Header, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVaultHeader", ctx)
+	ret0, _ := ret[0].(*vault.Header)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVaultHeader indicates an expected call of GetVaultHeader.
+func (mr *MockstorageMockRecorder) GetVaultHeader(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVaultHeader", reflect.TypeOf((*Mockstorage)(nil).GetVaultHeader), ctx)
+}
+
+// JoinVault mocks base method.
+func (m *Mockstorage) JoinVault(ctx context.Context, h vault.Header) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JoinVault", ctx, h)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// JoinVault indicates an expected call of JoinVault.
+func (mr *MockstorageMockRecorder) JoinVault(ctx, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JoinVault", reflect.TypeOf((*Mockstorage)(nil).JoinVault), ctx, h)
+}
+
 // ListSecretsByUser mocks base method.
 func (m *Mockstorage) ListSecretsByUser(ctx context.Context) (vault.List, error) {
 	m.ctrl.T.Helper()
@@ -163,19 +193,79 @@ func (mr *MockclientMockRecorder) GetSecretMeta(ctx, id interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretMeta", reflect.TypeOf((*Mockclient)(nil).GetSecretMeta), ctx, id)
 }
 
+// GetSecretMetaByAlias mocks base method.
+func (m *Mockclient) GetSecretMetaByAlias(ctx context.Context, alias string) (*vault.Meta, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecretMetaByAlias", ctx, alias)
+	ret0, _ := ret[0].(*vault.Meta)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecretMetaByAlias indicates an expected call of GetSecretMetaByAlias.
+func (mr *MockclientMockRecorder) GetSecretMetaByAlias(ctx, alias interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretMetaByAlias", reflect.TypeOf((*Mockclient)(nil).GetSecretMetaByAlias), ctx, alias)
+}
+
+// GetVaultHeader mocks base method.
+func (m *Mockclient) GetVaultHeader(ctx context.Context) (*vault.Header, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVaultHeader", ctx)
+	ret0, _ := ret[0].(*vault.Header)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVaultHeader indicates an expected call of GetVaultHeader.
+func (mr *MockclientMockRecorder) GetVaultHeader(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVaultHeader", reflect.TypeOf((*Mockclient)(nil).GetVaultHeader), ctx)
+}
+
+// ListExpiring mocks base method.
+func (m *Mockclient) ListExpiring(ctx context.Context, within time.Duration) (vault.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpiring", ctx, within)
+	ret0, _ := ret[0].(vault.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpiring indicates an expected call of ListExpiring.
+func (mr *MockclientMockRecorder) ListExpiring(ctx, within interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiring", reflect.TypeOf((*Mockclient)(nil).ListExpiring), ctx, within)
+}
+
+// ListRevisions mocks base method.
+func (m *Mockclient) ListRevisions(ctx context.Context, id vault.MetaID) (vault.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevisions", ctx, id)
+	ret0, _ := ret[0].(vault.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRevisions indicates an expected call of ListRevisions.
+func (mr *MockclientMockRecorder) ListRevisions(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevisions", reflect.TypeOf((*Mockclient)(nil).ListRevisions), ctx, id)
+}
+
 // ListSecrets mocks base method.
-func (m *Mockclient) ListSecrets(ctx context.Context) (vault.List, error) {
+func (m *Mockclient) ListSecrets(ctx context.Context, filter vault.Filter) (vault.List, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListSecrets", ctx)
+	ret := m.ctrl.Call(m, "ListSecrets", ctx, filter)
 	ret0, _ := ret[0].(vault.List)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListSecrets indicates an expected call of ListSecrets.
-func (mr *MockclientMockRecorder) ListSecrets(ctx interface{}) *gomock.Call {
+func (mr *MockclientMockRecorder) ListSecrets(ctx, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecrets", reflect.TypeOf((*Mockclient)(nil).ListSecrets), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecrets", reflect.TypeOf((*Mockclient)(nil).ListSecrets), ctx, filter)
 }
 
 // PutSecret mocks base method.
@@ -193,6 +283,20 @@ func (mr *MockclientMockRecorder) PutSecret(ctx, meta, r interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutSecret", reflect.TypeOf((*Mockclient)(nil).PutSecret), ctx, meta, r)
 }
 
+// PutVaultHeader mocks base method.
+func (m *Mockclient) PutVaultHeader(ctx context.Context, h vault.Header) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutVaultHeader", ctx, h)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutVaultHeader indicates an expected call of PutVaultHeader.
+func (mr *MockclientMockRecorder) PutVaultHeader(ctx, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutVaultHeader", reflect.TypeOf((*Mockclient)(nil).PutVaultHeader), ctx, h)
+}
+
 // Mocklogger is a mock of logger interface.
 type Mocklogger struct {
 	ctrl     *gomock.Controller
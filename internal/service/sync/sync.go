@@ -2,11 +2,15 @@
 package sync
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
+	"time"
 
+	"github.com/k1nky/gophkeeper/internal/crypto"
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
+	"github.com/k1nky/gophkeeper/internal/service/keeper"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -15,6 +19,10 @@ type Service struct {
 	client  client
 	storage storage
 	log     logger
+	// ключ хранилища для шифрования псевдонима и дополнительных данных секретов
+	key *crypto.Key
+	// если true, то псевдоним и дополнительные данные шифруются перед отправкой в удаленное хранилище
+	seal bool
 }
 
 // New возвращает новый экземпляр сервиса.
@@ -26,22 +34,153 @@ func New(client client, storage storage, log logger) *Service {
 	}
 }
 
+// SetMetaKey задает ключ хранилища key, которым расшифровываются псевдоним и дополнительные данные секретов,
+// полученных из удаленного хранилища. Если seal равен true, то перед отправкой в удаленное хранилище они шифруются,
+// и сервер видит только зашифрованные данные и слепой индекс псевдонима.
+func (s *Service) SetMetaKey(key *crypto.Key, seal bool) {
+	s.key = key
+	s.seal = seal
+}
+
+// SyncHeader согласует заголовок локального хранилища с заголовком, опубликованным в удаленном хранилище, чтобы
+// ключ хранилища на всех устройствах пользователя получался одинаковым. Если заголовок уже опубликован, то локальное
+// хранилище присоединяется к нему (см. keeper.JoinVault). Иначе публикуется заголовок локального хранилища.
+// Заголовок, полученный сменой ключа опубликованного хранилища (Header.Replaces), заменяет опубликованный.
+// Вызывается до получения ключа хранилища, а также после него, чтобы опубликовать только что созданный заголовок.
+func (s *Service) SyncHeader(ctx context.Context) error {
+	local, err := s.storage.GetVaultHeader(ctx)
+	if err != nil {
+		return err
+	}
+	if local != nil && local.Pending == nil && local.Replaces != nil {
+		if err := s.client.PutVaultHeader(ctx, *local); err != nil {
+			return err
+		}
+		s.log.Debugf("sync: vault header replaced after rekey")
+		return s.storage.JoinVault(ctx, *local)
+	}
+	remote, err := s.client.GetVaultHeader(ctx)
+	if err != nil {
+		return err
+	}
+	if remote != nil {
+		return s.storage.JoinVault(ctx, *remote)
+	}
+	if local == nil {
+		// хранилище еще не создано, заголовок будет опубликован после получения ключа
+		return nil
+	}
+	if local.Pending != nil {
+		return vault.ErrRekeyInProgress
+	}
+	if err := s.client.PutVaultHeader(ctx, *local); err != nil {
+		return err
+	}
+	s.log.Debugf("sync: vault header published")
+	return s.storage.JoinVault(ctx, *local)
+}
+
+// GetVaultHeader возвращает заголовок хранилища, опубликованный в удаленном хранилище, или nil, если заголовок
+// еще не опубликован.
+func (s *Service) GetVaultHeader(ctx context.Context) (*vault.Header, error) {
+	return s.client.GetVaultHeader(ctx)
+}
+
+// unseal расшифровывает псевдоним и дополнительные данные секрета meta, полученного из удаленного хранилища.
+func (s *Service) unseal(meta vault.Meta) (vault.Meta, error) {
+	if !meta.IsSealed() {
+		return meta, nil
+	}
+	if s.key == nil {
+		return meta, vault.ErrSealedMeta
+	}
+	return meta.Unseal(s.key)
+}
+
+// ListSecrets возвращает список секретов пользователя из удаленного хранилища, удовлетворяющих фильтру filter.
+// Секреты отбираются на сервере. Если мета-данные шифруются перед отправкой, то метки и папка серверу недоступны,
+// поэтому на сервере секреты отбираются только по типу, а по меткам и папке - после расшифровки.
+func (s *Service) ListSecrets(ctx context.Context, filter vault.Filter) (vault.List, error) {
+	remote := filter
+	if s.seal {
+		remote = vault.Filter{Types: filter.Types}
+	}
+	list, err := s.client.ListSecrets(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range list {
+		if list[i], err = s.unseal(v); err != nil {
+			return nil, err
+		}
+	}
+	return list.Filter(filter), nil
+}
+
+// ListRevisions возвращает мета-данные предыдущих версий секрета id из удаленного хранилища, начиная с последней.
+func (s *Service) ListRevisions(ctx context.Context, id vault.MetaID) (vault.List, error) {
+	list, err := s.client.ListRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range list {
+		if list[i], err = s.unseal(v); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// ListExpiring возвращает секреты из удаленного хранилища, срок действия которых истекает в течение within или уже истек.
+func (s *Service) ListExpiring(ctx context.Context, within time.Duration) (vault.List, error) {
+	list, err := s.client.ListExpiring(ctx, within)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range list {
+		if list[i], err = s.unseal(v); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// GetSecretMetaByAlias возвращает мета-данные секрета с псевдонимом alias из удаленного хранилища.
+// Если псевдонимы шифруются, то секрет ищется по слепому индексу псевдонима.
+func (s *Service) GetSecretMetaByAlias(ctx context.Context, alias string) (*vault.Meta, error) {
+	if s.seal {
+		index, err := vault.AliasIndex(s.key, alias)
+		if err != nil {
+			return nil, err
+		}
+		alias = index
+	}
+	m, err := s.client.GetSecretMetaByAlias(ctx, alias)
+	if err != nil || m == nil {
+		return m, err
+	}
+	meta, err := s.unseal(*m)
+	if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
 // Pull забирает секрет с мета-данным meta из удаленного хранилища в локальное.
 func (s *Service) Pull(ctx context.Context, meta vault.Meta, force bool) (*vault.Meta, error) {
 	var newMeta *vault.Meta
 
+	meta, err := s.unseal(meta)
+	if err != nil {
+		return nil, err
+	}
 	m, err := s.storage.GetSecretMeta(ctx, meta.ID)
 	if err != nil {
 		return nil, err
 	}
 	if m != nil {
-		if m.Equal(meta) {
-			// данные секрета уже актуальны
-			return nil, vault.ErrNothingToUpdate
-		}
-		if !m.CanUpdated(meta) && !force {
-			// конфликт версий
-			return nil, vault.ErrConflictVersion
+		if err := checkUpdate(*m, meta, force); err != nil {
+			return nil, err
 		}
 	}
 
@@ -50,23 +189,65 @@ func (s *Service) Pull(ctx context.Context, meta vault.Meta, force bool) (*vault
 	data := vault.NewDataReader(r)
 	g.Go(func() error {
 		err := s.client.GetSecretData(ctx, meta.ID, w)
-		w.Close()
+		// ошибка получения передается читателю, иначе он примет неполные данные за конец потока
+		w.CloseWithError(err)
 		return err
 	})
+	if err := s.verifyData(meta, data); err != nil {
+		// прекращаем получение данных, которые не удастся расшифровать
+		r.CloseWithError(err)
+		g.Wait()
+		return nil, err
+	}
 	g.Go(func() error {
 		m, err := s.storage.PutSecret(ctx, meta, data)
 		newMeta = m
 		return err
 	})
 	if err := g.Wait(); err != nil {
-		newMeta = nil
+		return nil, err
 	}
 	return newMeta, nil
 }
 
+// checkUpdate проверяет, что секрет current можно заменить версией update. Если update старше current, то возвращается
+// vault.ErrOutdatedVersion, а если версии изменены независимо друг от друга, то vault.ErrConflictVersion.
+// При force заменяется любая версия, кроме совпадающей.
+func checkUpdate(current vault.Meta, update vault.Meta, force bool) error {
+	if current.Equal(update) {
+		// данные секрета уже актуальны
+		return vault.ErrNothingToUpdate
+	}
+	if force {
+		return nil
+	}
+	switch update.Compare(current) {
+	case vault.Older:
+		return vault.ErrOutdatedVersion
+	case vault.Concurrent:
+		// конфликт версий
+		return vault.ErrConflictVersion
+	}
+	return nil
+}
+
+// verifyData проверяет по заголовку данных data секрета meta, что они зашифрованы ключом хранилища.
+// Если ключ хранилища не задан, то проверка не выполняется.
+func (s *Service) verifyData(meta vault.Meta, data *vault.DataReader) error {
+	if s.key == nil {
+		return nil
+	}
+	header, err := data.Peek(crypto.HeaderSize)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	_, err = keeper.DecryptSecret(s.key, meta, bytes.NewReader(header))
+	return err
+}
+
 // PullAll забирает все секреты пользователя из удаленного хранилища в локальное.
 func (s *Service) PullAll(ctx context.Context, force bool) error {
-	list, err := s.client.ListSecrets(ctx)
+	list, err := s.client.ListSecrets(ctx, vault.Filter{})
 	if err != nil {
 		return err
 	}
@@ -75,7 +256,7 @@ func (s *Service) PullAll(ctx context.Context, force bool) error {
 			if errors.Is(err, vault.ErrNothingToUpdate) {
 				s.log.Debugf("%s %s", err, v)
 			}
-			if errors.Is(err, vault.ErrConflictVersion) {
+			if errors.Is(err, vault.ErrConflictVersion) || errors.Is(err, vault.ErrOutdatedVersion) || errors.Is(err, vault.ErrWrongSecret) {
 				s.log.Errorf("%s %s", err, v)
 			}
 			continue
@@ -89,20 +270,32 @@ func (s *Service) Push(ctx context.Context, meta vault.Meta, force bool) (*vault
 
 	m, _ := s.client.GetSecretMeta(ctx, meta.ID)
 	if m != nil {
-		if meta.Equal(*m) {
-			return nil, vault.ErrNothingToUpdate
-		}
-		if !m.CanUpdated(meta) && !force {
-			return nil, vault.ErrConflictVersion
+		if err := checkUpdate(*m, meta, force); err != nil {
+			return nil, err
 		}
 	}
 
+	remote := meta
+	if s.seal {
+		var err error
+		if remote, err = meta.Seal(s.key); err != nil {
+			return nil, err
+		}
+	}
 	data, err := s.storage.GetSecretData(ctx, meta.ID)
 	if err != nil {
 		return nil, err
 	}
 	defer data.Close()
-	return s.client.PutSecret(ctx, meta, data)
+	m, err = s.client.PutSecret(ctx, remote, data)
+	if err != nil {
+		return nil, err
+	}
+	newMeta, err := s.unseal(*m)
+	if err != nil {
+		return nil, err
+	}
+	return &newMeta, nil
 }
 
 // PushAll отправляет все секреты пользователя из локального хранилища в удаленное.
@@ -117,7 +310,7 @@ func (s *Service) PushAll(ctx context.Context, force bool) error {
 			if errors.Is(err, vault.ErrNothingToUpdate) {
 				s.log.Debugf("%s %s", err, v)
 			}
-			if errors.Is(err, vault.ErrConflictVersion) {
+			if errors.Is(err, vault.ErrConflictVersion) || errors.Is(err, vault.ErrOutdatedVersion) {
 				s.log.Errorf("%s %s", err, v)
 			}
 			continue
@@ -0,0 +1,494 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/k1nky/gophkeeper/internal/adapter/store"
+	"github.com/k1nky/gophkeeper/internal/crypto"
+	"github.com/k1nky/gophkeeper/internal/entity/vault"
+	log "github.com/k1nky/gophkeeper/internal/logger"
+	"github.com/k1nky/gophkeeper/internal/service/keeper"
+	"github.com/k1nky/gophkeeper/internal/service/sync/mock"
+	"github.com/k1nky/gophkeeper/internal/store/meta/bolt"
+	"github.com/k1nky/gophkeeper/internal/store/objects/filestore"
+)
+
+// fakeRemote удаленное хранилище секретов в памяти.
+type fakeRemote struct {
+	meta   map[vault.MetaID]vault.Meta
+	data   map[vault.MetaID][]byte
+	header *vault.Header
+}
+
+func newFakeRemote() *fakeRemote {
+	return &fakeRemote{
+		meta: make(map[vault.MetaID]vault.Meta),
+		data: make(map[vault.MetaID][]byte),
+	}
+}
+
+func (r *fakeRemote) ListSecrets(ctx context.Context, filter vault.Filter) (vault.List, error) {
+	list := make(vault.List, 0, len(r.meta))
+	for _, m := range r.meta {
+		// как и сервер, отбирает секреты по мета-данным в том виде, в котором они хранятся
+		if filter.Match(m) {
+			list = append(list, m)
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeRemote) ListRevisions(ctx context.Context, id vault.MetaID) (vault.List, error) {
+	return vault.List{}, nil
+}
+
+func (r *fakeRemote) ListExpiring(ctx context.Context, within time.Duration) (vault.List, error) {
+	return vault.List{}, nil
+}
+
+func (r *fakeRemote) GetSecretMeta(ctx context.Context, id vault.MetaID) (*vault.Meta, error) {
+	m, ok := r.meta[id]
+	if !ok {
+		return nil, nil
+	}
+	return &m, nil
+}
+
+func (r *fakeRemote) GetSecretMetaByAlias(ctx context.Context, alias string) (*vault.Meta, error) {
+	for _, m := range r.meta {
+		if m.Alias == alias {
+			return &m, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRemote) GetSecretData(ctx context.Context, id vault.MetaID, w io.Writer) error {
+	data, ok := r.data[id]
+	if !ok {
+		return vault.ErrObjectNotExists
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func (r *fakeRemote) PutSecret(ctx context.Context, meta vault.Meta, data io.Reader) (*vault.Meta, error) {
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+	r.meta[meta.ID] = meta
+	r.data[meta.ID] = b
+	return &meta, nil
+}
+
+func (r *fakeRemote) GetVaultHeader(ctx context.Context) (*vault.Header, error) {
+	return r.header, nil
+}
+
+func (r *fakeRemote) PutVaultHeader(ctx context.Context, h vault.Header) error {
+	if r.header != nil && !r.header.SameVault(h) && (h.Replaces == nil || !r.header.SameVault(*h.Replaces)) {
+		return vault.ErrVaultMismatch
+	}
+	published := h.Published()
+	r.header = &published
+	return nil
+}
+
+// testDevice устройство пользователя с локальным хранилищем секретов.
+type testDevice struct {
+	keeper *keeper.Service
+	sync   *Service
+	key    *crypto.Key
+}
+
+type syncServiceTestSuite struct {
+	suite.Suite
+	remote *fakeRemote
+}
+
+func TestSyncService(t *testing.T) {
+	suite.Run(t, new(syncServiceTestSuite))
+}
+
+func (suite *syncServiceTestSuite) SetupTest() {
+	suite.remote = newFakeRemote()
+}
+
+// newDevice возвращает устройство с новым локальным хранилищем, подключенное к удаленному хранилищу.
+func (suite *syncServiceTestSuite) newDevice() *testDevice {
+	root := suite.T().TempDir()
+	s := store.New(bolt.New(path.Join(root, "meta.db")), filestore.New(path.Join(root, "vault")))
+	if err := s.Open(context.TODO()); err != nil {
+		suite.FailNow(err.Error())
+	}
+	suite.T().Cleanup(func() { s.Close() })
+	k := keeper.New(s, &log.Blackhole{})
+	return &testDevice{
+		keeper: k,
+		sync:   New(suite.remote, k, &log.Blackhole{}),
+	}
+}
+
+// unlock открывает хранилище устройства d так же, как клиент перед синхронизацией.
+func (suite *syncServiceTestSuite) unlock(d *testDevice, join bool) {
+	suite.unlockWith(d, "secret", join)
+}
+
+// unlockWith открывает хранилище устройства d секретом secret.
+func (suite *syncServiceTestSuite) unlockWith(d *testDevice, secret string, join bool) {
+	params := suite.params()
+	var err error
+	if join {
+		suite.NoError(d.sync.SyncHeader(context.TODO()))
+	}
+	d.key, err = d.keeper.UnlockVault(context.TODO(), vault.Credentials{Secret: secret}, params)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	if join {
+		suite.NoError(d.sync.SyncHeader(context.TODO()))
+	}
+	d.sync.SetMetaKey(d.key, true)
+}
+
+// params возвращает параметры формирования ключа со случайной солью, у каждого устройства свои на случай создания
+// нового хранилища.
+func (suite *syncServiceTestSuite) params() crypto.KDFParams {
+	params := crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: make([]byte, crypto.SaltSize), Time: 1, Memory: 64, Threads: 1}
+	_, err := rand.Read(params.Salt)
+	suite.NoError(err)
+	return params
+}
+
+// put сохраняет в локальном хранилище устройства d текстовый секрет value с псевдонимом alias.
+func (suite *syncServiceTestSuite) put(d *testDevice, alias string, value string) vault.Meta {
+	return suite.putMeta(d, vault.Meta{ID: vault.NewMetaID(), Alias: alias, Type: vault.TypeText}, value)
+}
+
+// putMeta сохраняет в локальном хранилище устройства d секрет с мета-данными m и данными value.
+func (suite *syncServiceTestSuite) putMeta(d *testDevice, m vault.Meta, value string) vault.Meta {
+	key, err := crypto.NewDataKey()
+	suite.NoError(err)
+	m.WrappedKey, err = crypto.WrapKey(d.key, key)
+	suite.NoError(err)
+	enc, err := crypto.NewEncryptReader(key, bytes.NewBufferString(value))
+	suite.NoError(err)
+	suite.NoError(d.keeper.NewVersion(context.TODO(), &m))
+	stored, err := d.keeper.PutSecret(context.TODO(), m, vault.NewDataReader(enc))
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	return *stored
+}
+
+// read возвращает расшифрованные данные секрета id из локального хранилища устройства d.
+func (suite *syncServiceTestSuite) read(d *testDevice, id vault.MetaID) string {
+	m, err := d.keeper.GetSecretMeta(context.TODO(), id)
+	suite.NoError(err)
+	if m == nil {
+		suite.FailNow("secret not found")
+	}
+	data, err := d.keeper.GetSecretData(context.TODO(), id)
+	suite.NoError(err)
+	defer data.Close()
+	dec, err := keeper.DecryptSecret(d.key, *m, data)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	b, err := io.ReadAll(dec)
+	suite.NoError(err)
+	return string(b)
+}
+
+// header возвращает заголовок локального хранилища устройства d.
+func (suite *syncServiceTestSuite) header(d *testDevice) *vault.Header {
+	h, err := d.keeper.GetVaultHeader(context.TODO())
+	suite.NoError(err)
+	return h
+}
+
+func (suite *syncServiceTestSuite) TestPullBetweenVaults() {
+	a, b := suite.newDevice(), suite.newDevice()
+	suite.unlock(a, true)
+	secret := suite.put(a, "note", "from a")
+	suite.NoError(a.sync.PushAll(context.TODO(), false))
+
+	// второе устройство присоединяется к хранилищу, созданному на первом, и расшифровывает его секреты
+	suite.unlock(b, true)
+	suite.Equal(a.key, b.key)
+	remote, err := b.sync.GetSecretMetaByAlias(context.TODO(), "note")
+	suite.NoError(err)
+	suite.Equal(secret.ID, remote.ID)
+	_, err = b.sync.Pull(context.TODO(), *remote, false)
+	suite.NoError(err)
+	suite.Equal("from a", suite.read(b, secret.ID))
+
+	// и в обратную сторону
+	reply := suite.put(b, "reply", "from b")
+	_, err = b.sync.Push(context.TODO(), reply, false)
+	suite.NoError(err)
+	suite.NoError(a.sync.PullAll(context.TODO(), false))
+	suite.Equal("from b", suite.read(a, reply.ID))
+}
+
+func (suite *syncServiceTestSuite) TestPullWithoutJoin() {
+	a, b := suite.newDevice(), suite.newDevice()
+	suite.unlock(a, true)
+	secret := suite.put(a, "note", "from a")
+	suite.NoError(a.sync.PushAll(context.TODO(), false))
+
+	// хранилище, созданное без заголовка из удаленного хранилища, имеет другой ключ
+	suite.unlock(b, false)
+	b.sync.SetMetaKey(b.key, false)
+	_, err := b.sync.Pull(context.TODO(), suite.remote.meta[secret.ID], false)
+	suite.Error(err)
+	m, err := b.keeper.GetSecretMeta(context.TODO(), secret.ID)
+	suite.NoError(err)
+	suite.Nil(m)
+
+	// к другому хранилищу нельзя присоединить локальное хранилище с секретами
+	suite.put(b, "local", "from b")
+	suite.ErrorIs(b.sync.SyncHeader(context.TODO()), vault.ErrVaultMismatch)
+}
+
+func (suite *syncServiceTestSuite) TestSealedMetaBetweenVaults() {
+	a, b := suite.newDevice(), suite.newDevice()
+	suite.unlock(a, true)
+	secret := suite.putMeta(a, vault.Meta{
+		ID:     vault.NewMetaID(),
+		Alias:  "mail",
+		Extra:  "personal",
+		Type:   vault.TypeText,
+		Tags:   []string{"home"},
+		Folder: "web",
+	}, "value")
+	suite.NoError(a.sync.PushAll(context.TODO(), false))
+	// сервер видит только зашифрованные мета-данные и слепой индекс псевдонима
+	stored := suite.remote.meta[secret.ID]
+	suite.True(stored.IsSealed())
+	suite.NotEqual(secret.Alias, stored.Alias)
+	suite.Empty(stored.Extra)
+
+	// ключи шифрования мета-данных и индекса на всех устройствах одинаковые
+	suite.unlock(b, true)
+	list, err := b.sync.ListSecrets(context.TODO(), vault.Filter{})
+	suite.NoError(err)
+	suite.Len(list, 1)
+	suite.Equal(secret.Alias, list[0].Alias)
+	suite.Equal(secret.Extra, list[0].Extra)
+	suite.Equal(secret.Tags, list[0].Tags)
+	suite.Equal(secret.Folder, list[0].Folder)
+	// метки и папка зашифрованы, поэтому по ним секреты отбираются после расшифровки
+	list, err = b.sync.ListSecrets(context.TODO(), vault.Filter{Tags: []string{"home"}, Folder: "web", Types: []vault.SecretType{vault.TypeText}})
+	suite.NoError(err)
+	suite.Len(list, 1)
+	list, err = b.sync.ListSecrets(context.TODO(), vault.Filter{Tags: []string{"work"}})
+	suite.NoError(err)
+	suite.Empty(list)
+	m, err := b.sync.GetSecretMetaByAlias(context.TODO(), secret.Alias)
+	suite.NoError(err)
+	suite.Equal(secret.ID, m.ID)
+}
+
+func (suite *syncServiceTestSuite) TestRekeyBetweenVaults() {
+	a, b := suite.newDevice(), suite.newDevice()
+	suite.unlock(a, true)
+	secret := suite.putMeta(a, vault.Meta{ID: vault.NewMetaID(), Alias: "mail", Type: vault.TypeText}, "value")
+	suite.NoError(a.sync.PushAll(context.TODO(), false))
+	suite.unlock(b, true)
+	suite.NoError(b.sync.PullAll(context.TODO(), false))
+
+	// после смены ключа новый заголовок заменяет опубликованный, а секреты отправляются с новыми версиями
+	_, err := a.keeper.Rekey(context.TODO(), vault.Credentials{Secret: "secret"}, vault.Credentials{Secret: "new"}, suite.params())
+	suite.NoError(err)
+	suite.NoError(a.sync.SyncHeader(context.TODO()))
+	suite.unlockWith(a, "new", true)
+	suite.True(suite.remote.header.SameVault(*suite.header(a)))
+	suite.NoError(a.sync.PushAll(context.TODO(), false))
+	rekeyed := suite.remote.meta[secret.ID]
+	suite.Equal(vault.Newer, rekeyed.Compare(secret))
+	// мета-данные на сервере зашифрованы новым ключом
+	opened, err := rekeyed.Unseal(a.key)
+	suite.NoError(err)
+	suite.Equal("mail", opened.Alias)
+
+	// другое устройство не присоединяется к новому заголовку, пока не перейдет на новый секрет
+	suite.ErrorIs(b.sync.SyncHeader(context.TODO()), vault.ErrVaultRekeyed)
+	remote, err := b.sync.GetVaultHeader(context.TODO())
+	suite.NoError(err)
+	_, err = b.keeper.RejoinVault(context.TODO(), vault.Credentials{Secret: "secret"}, vault.Credentials{Secret: "new"}, *remote)
+	suite.NoError(err)
+	suite.unlockWith(b, "new", true)
+	suite.Equal(a.key, b.key)
+	suite.Equal("value", suite.read(b, secret.ID))
+	// версия, переведенная на новый ключ на первом устройстве, новее локальной
+	suite.NoError(b.sync.PullAll(context.TODO(), false))
+	m, err := b.keeper.GetSecretMeta(context.TODO(), secret.ID)
+	suite.NoError(err)
+	suite.True(m.Equal(rekeyed))
+	suite.Equal("value", suite.read(b, secret.ID))
+}
+
+// versionTestCase версии секрета в локальном и удаленном хранилищах.
+type versionTestCase struct {
+	name    string
+	current *vault.Meta
+	update  vault.Meta
+	force   bool
+	// ожидаемая ошибка, nil - секрет обновляется
+	err error
+}
+
+// versionTestCases возвращает варианты замены версии current версией update для секрета id.
+func versionTestCases(id vault.MetaID) []versionTestCase {
+	meta := func(revision int64, version vault.Version) *vault.Meta {
+		return &vault.Meta{ID: id, Alias: "alias", Type: vault.TypeText, Revision: revision, Version: version}
+	}
+	return []versionTestCase{
+		{name: "new", current: nil, update: *meta(10, vault.Version{"a": 1})},
+		{name: "equal", current: meta(10, vault.Version{"a": 1}), update: *meta(10, vault.Version{"a": 1}), err: vault.ErrNothingToUpdate},
+		{name: "equal with force", current: meta(10, vault.Version{"a": 1}), update: *meta(10, vault.Version{"a": 1}), force: true, err: vault.ErrNothingToUpdate},
+		{name: "newer", current: meta(10, vault.Version{"a": 1}), update: *meta(20, vault.Version{"a": 2})},
+		// номер версии устройства с отстающими часами не важен, если вектор версий новее
+		{name: "newer with smaller revision", current: meta(20, vault.Version{"a": 1}), update: *meta(10, vault.Version{"a": 1, "b": 1})},
+		{name: "older", current: meta(20, vault.Version{"a": 2}), update: *meta(10, vault.Version{"a": 1}), err: vault.ErrOutdatedVersion},
+		{name: "older with force", current: meta(20, vault.Version{"a": 2}), update: *meta(10, vault.Version{"a": 1}), force: true},
+		{name: "concurrent", current: meta(20, vault.Version{"a": 2}), update: *meta(30, vault.Version{"a": 1, "b": 1}), err: vault.ErrConflictVersion},
+		{name: "concurrent with force", current: meta(20, vault.Version{"a": 2}), update: *meta(30, vault.Version{"a": 1, "b": 1}), force: true},
+	}
+}
+
+func (suite *syncServiceTestSuite) TestPullVersions() {
+	for _, tt := range versionTestCases(vault.NewMetaID()) {
+		suite.Run(tt.name, func() {
+			ctrl := gomock.NewController(suite.T())
+			storage := mock.NewMockstorage(ctrl)
+			client := mock.NewMockclient(ctrl)
+			svc := New(client, storage, &log.Blackhole{})
+
+			storage.EXPECT().GetSecretMeta(gomock.Any(), tt.update.ID).Return(tt.current, nil)
+			if tt.err == nil {
+				client.EXPECT().GetSecretData(gomock.Any(), tt.update.ID, gomock.Any()).DoAndReturn(func(_ context.Context, _ vault.MetaID, w io.Writer) error {
+					_, err := w.Write([]byte("data"))
+					return err
+				})
+				storage.EXPECT().PutSecret(gomock.Any(), tt.update, gomock.Any()).DoAndReturn(func(_ context.Context, m vault.Meta, data *vault.DataReader) (*vault.Meta, error) {
+					b, err := io.ReadAll(data)
+					suite.NoError(err)
+					suite.Equal("data", string(b))
+					return &m, nil
+				})
+			}
+			got, err := svc.Pull(context.TODO(), tt.update, tt.force)
+			if tt.err != nil {
+				suite.ErrorIs(err, tt.err)
+				suite.Nil(got)
+				return
+			}
+			suite.NoError(err)
+			suite.Equal(tt.update, *got)
+		})
+	}
+}
+
+func (suite *syncServiceTestSuite) TestPushVersions() {
+	for _, tt := range versionTestCases(vault.NewMetaID()) {
+		suite.Run(tt.name, func() {
+			ctrl := gomock.NewController(suite.T())
+			storage := mock.NewMockstorage(ctrl)
+			client := mock.NewMockclient(ctrl)
+			svc := New(client, storage, &log.Blackhole{})
+
+			client.EXPECT().GetSecretMeta(gomock.Any(), tt.update.ID).Return(tt.current, nil)
+			if tt.err == nil {
+				storage.EXPECT().GetSecretData(gomock.Any(), tt.update.ID).Return(vault.NewDataReader(io.NopCloser(bytes.NewBufferString("data"))), nil)
+				client.EXPECT().PutSecret(gomock.Any(), tt.update, gomock.Any()).DoAndReturn(func(_ context.Context, m vault.Meta, r io.Reader) (*vault.Meta, error) {
+					b, err := io.ReadAll(r)
+					suite.NoError(err)
+					suite.Equal("data", string(b))
+					return &m, nil
+				})
+			}
+			got, err := svc.Push(context.TODO(), tt.update, tt.force)
+			if tt.err != nil {
+				suite.ErrorIs(err, tt.err)
+				suite.Nil(got)
+				return
+			}
+			suite.NoError(err)
+			suite.Equal(tt.update, *got)
+		})
+	}
+}
+
+func (suite *syncServiceTestSuite) TestPushSealed() {
+	ctrl := gomock.NewController(suite.T())
+	storage := mock.NewMockstorage(ctrl)
+	client := mock.NewMockclient(ctrl)
+	svc := New(client, storage, &log.Blackhole{})
+	key, err := crypto.DeriveKey("secret", crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1})
+	suite.NoError(err)
+	svc.SetMetaKey(key, true)
+	meta := vault.Meta{
+		ID:       vault.NewMetaID(),
+		Alias:    "alias",
+		Extra:    "extra",
+		Type:     vault.TypeText,
+		Revision: 10,
+		Version:  vault.Version{"a": 1},
+		Tags:     []string{"tag"},
+	}
+	index, err := vault.AliasIndex(key, meta.Alias)
+	suite.NoError(err)
+
+	client.EXPECT().GetSecretMeta(gomock.Any(), meta.ID).Return(nil, nil)
+	storage.EXPECT().GetSecretData(gomock.Any(), meta.ID).Return(vault.NewDataReader(io.NopCloser(bytes.NewBufferString("data"))), nil)
+	client.EXPECT().PutSecret(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m vault.Meta, r io.Reader) (*vault.Meta, error) {
+		// сервер получает только слепой индекс псевдонима и зашифрованные мета-данные, а версия не меняется
+		suite.True(m.IsSealed())
+		suite.Equal(index, m.Alias)
+		suite.Empty(m.Extra)
+		suite.Empty(m.Tags)
+		suite.Equal(meta.Revision, m.Revision)
+		suite.Equal(meta.Version, m.Version)
+		return &m, nil
+	})
+	got, err := svc.Push(context.TODO(), meta, false)
+	suite.NoError(err)
+	// в ответе мета-данные расшифрованы
+	suite.Equal(meta, *got)
+}
+
+func (suite *syncServiceTestSuite) TestListSecretsByFilter() {
+	ctrl := gomock.NewController(suite.T())
+	client := mock.NewMockclient(ctrl)
+	svc := New(client, mock.NewMockstorage(ctrl), &log.Blackhole{})
+	filter := vault.Filter{Tags: []string{"home"}, Folder: "web", Types: []vault.SecretType{vault.TypeText}}
+	expected := vault.List{{ID: vault.NewMetaID(), Type: vault.TypeText, Tags: []string{"home"}, Folder: "web"}}
+
+	// открытые мета-данные отбираются на сервере по всему фильтру
+	client.EXPECT().ListSecrets(gomock.Any(), filter).Return(expected, nil)
+	got, err := svc.ListSecrets(context.TODO(), filter)
+	suite.NoError(err)
+	suite.Equal(expected, got)
+
+	// у зашифрованных мета-данных сервер не видит метки и папку
+	key, err := crypto.DeriveKey("secret", crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Salt: []byte("salt"), Time: 1, Memory: 64, Threads: 1})
+	suite.NoError(err)
+	svc.SetMetaKey(key, true)
+	other := vault.Meta{ID: vault.NewMetaID(), Type: vault.TypeText, Tags: []string{"work"}}
+	client.EXPECT().ListSecrets(gomock.Any(), vault.Filter{Types: filter.Types}).Return(vault.List{expected[0], other}, nil)
+	got, err = svc.ListSecrets(context.TODO(), filter)
+	suite.NoError(err)
+	suite.Equal(expected, got)
+}
@@ -32,7 +32,7 @@ func (bs *BoltStorage) Open(ctx context.Context) (err error) {
 	}
 	err = bs.DB.Update(func(tx *bolt.Tx) error {
 		// создаем обязательные бакеты
-		for _, bucket := range []string{"users", "meta"} {
+		for _, bucket := range []string{"users", "meta", "vault", "history", "headers"} {
 			if _, err := tx.CreateBucketIfNotExists(tb(bucket)); err != nil {
 				return err
 			}
@@ -16,4 +16,6 @@ func openTestDB(root string) (*BoltStorage, error) {
 func TestBoltStorage(t *testing.T) {
 	suite.Run(t, new(usersTestSuite))
 	suite.Run(t, new(metaTestSuite))
+	suite.Run(t, new(headerTestSuite))
+	suite.Run(t, new(historyTestSuite))
 }
@@ -0,0 +1,90 @@
+package bolt
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k1nky/gophkeeper/internal/entity/user"
+
+	"github.com/k1nky/gophkeeper/internal/entity/vault"
+	bolt "go.etcd.io/bbolt"
+)
+
+// GetVaultHeader возвращает заголовок хранилища. Если заголовок еще не создан, то возвращается nil.
+func (bs *BoltStorage) GetVaultHeader(ctx context.Context) (*vault.Header, error) {
+	h := &vault.Header{}
+	err := bs.DB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(tb("vault"))
+		v := b.Get(tb("header"))
+		if v == nil {
+			h = nil
+			return nil
+		}
+		return deserialize(v, h)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// PutVaultHeader сохраняет заголовок хранилища h.
+func (bs *BoltStorage) PutVaultHeader(ctx context.Context, h vault.Header) error {
+	return bs.DB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(tb("vault"))
+		value, err := serialize(h)
+		if err != nil {
+			return err
+		}
+		return b.Put(tb("header"), value)
+	})
+}
+
+// GetDeviceID возвращает ИД устройства, на котором расположено хранилище. ИД создается при первом обращении.
+func (bs *BoltStorage) GetDeviceID(ctx context.Context) (string, error) {
+	var id string
+	err := bs.DB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(tb("vault"))
+		if v := b.Get(tb("device")); v != nil {
+			id = string(v)
+			return nil
+		}
+		id = vault.NewDeviceID()
+		return b.Put(tb("device"), tb(id))
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// GetUserVaultHeader возвращает заголовок хранилища пользователя userID, общий для всех его устройств.
+// Если заголовок еще не опубликован, то возвращается nil.
+func (bs *BoltStorage) GetUserVaultHeader(ctx context.Context, userID user.ID) (*vault.Header, error) {
+	h := &vault.Header{}
+	err := bs.DB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(tb("headers"))
+		v := b.Get(tb(fmt.Sprintf("%d", userID)))
+		if v == nil {
+			h = nil
+			return nil
+		}
+		return deserialize(v, h)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// PutUserVaultHeader сохраняет заголовок хранилища h пользователя userID.
+func (bs *BoltStorage) PutUserVaultHeader(ctx context.Context, userID user.ID, h vault.Header) error {
+	return bs.DB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(tb("headers"))
+		value, err := serialize(h)
+		if err != nil {
+			return err
+		}
+		return b.Put(tb(fmt.Sprintf("%d", userID)), value)
+	})
+}
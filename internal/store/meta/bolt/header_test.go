@@ -0,0 +1,95 @@
+package bolt
+
+import (
+	"context"
+
+	"github.com/k1nky/gophkeeper/internal/crypto"
+	"github.com/k1nky/gophkeeper/internal/entity/vault"
+	"github.com/stretchr/testify/suite"
+)
+
+type headerTestSuite struct {
+	suite.Suite
+	bs *BoltStorage
+}
+
+func (suite *headerTestSuite) SetupTest() {
+	var err error
+	rootDir := suite.T().TempDir()
+	if suite.bs, err = openTestDB(rootDir); err != nil {
+		suite.FailNow(err.Error())
+		return
+	}
+}
+
+func (suite *headerTestSuite) TestGetVaultHeaderNotExists() {
+	h, err := suite.bs.GetVaultHeader(context.TODO())
+	suite.NoError(err)
+	suite.Nil(h)
+}
+
+func (suite *headerTestSuite) TestPutVaultHeader() {
+	params, err := crypto.NewKDFParams(crypto.KDFArgon2id)
+	suite.NoError(err)
+	expected := vault.Header{KDF: params}
+	suite.NoError(suite.bs.PutVaultHeader(context.TODO(), expected))
+	got, err := suite.bs.GetVaultHeader(context.TODO())
+	suite.NoError(err)
+	suite.Equal(expected, *got)
+
+	// заголовок можно перезаписать, например, при смене параметров формирования ключа
+	params, err = crypto.NewKDFParams(crypto.KDFScrypt)
+	suite.NoError(err)
+	expected = vault.Header{KDF: params}
+	suite.NoError(suite.bs.PutVaultHeader(context.TODO(), expected))
+	got, err = suite.bs.GetVaultHeader(context.TODO())
+	suite.NoError(err)
+	suite.Equal(expected, *got)
+}
+
+func (suite *headerTestSuite) TestPutVaultHeaderWithPending() {
+	params, err := crypto.NewKDFParams(crypto.KDFArgon2id)
+	suite.NoError(err)
+	pending, err := crypto.NewKDFParams(crypto.KDFScrypt)
+	suite.NoError(err)
+	expected := vault.Header{KDF: params, Pending: &vault.Header{KDF: pending}}
+	suite.NoError(suite.bs.PutVaultHeader(context.TODO(), expected))
+	got, err := suite.bs.GetVaultHeader(context.TODO())
+	suite.NoError(err)
+	suite.Equal(expected, *got)
+
+	// после завершения смены ключа журнал не сохраняется
+	expected = vault.Header{KDF: pending}
+	suite.NoError(suite.bs.PutVaultHeader(context.TODO(), expected))
+	got, err = suite.bs.GetVaultHeader(context.TODO())
+	suite.NoError(err)
+	suite.Equal(expected, *got)
+}
+
+func (suite *headerTestSuite) TestPutUserVaultHeader() {
+	h, err := suite.bs.GetUserVaultHeader(context.TODO(), 1)
+	suite.NoError(err)
+	suite.Nil(h)
+
+	params, err := crypto.NewKDFParams(crypto.KDFArgon2id)
+	suite.NoError(err)
+	expected := vault.Header{KDF: params, Check: []byte("check")}
+	suite.NoError(suite.bs.PutUserVaultHeader(context.TODO(), 1, expected))
+	got, err := suite.bs.GetUserVaultHeader(context.TODO(), 1)
+	suite.NoError(err)
+	suite.Equal(expected, *got)
+	// заголовки хранятся отдельно для каждого пользователя
+	h, err = suite.bs.GetUserVaultHeader(context.TODO(), 2)
+	suite.NoError(err)
+	suite.Nil(h)
+}
+
+func (suite *headerTestSuite) TestGetDeviceID() {
+	id, err := suite.bs.GetDeviceID(context.TODO())
+	suite.NoError(err)
+	suite.NotEmpty(id)
+	// ИД устройства не меняется после создания
+	again, err := suite.bs.GetDeviceID(context.TODO())
+	suite.NoError(err)
+	suite.Equal(id, again)
+}
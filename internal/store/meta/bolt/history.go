@@ -0,0 +1,92 @@
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/k1nky/gophkeeper/internal/entity/user"
+	"github.com/k1nky/gophkeeper/internal/entity/vault"
+	bolt "go.etcd.io/bbolt"
+)
+
+// revisionKey возвращает ключ версии секрета. Ключи упорядочены по возрастанию версии.
+func revisionKey(revision int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(revision))
+	return key
+}
+
+// PutMetaRevision сохраняет мета-данные meta предыдущей версии секрета.
+func (bs *BoltStorage) PutMetaRevision(ctx context.Context, meta vault.Meta) error {
+	if len(meta.ID) == 0 {
+		return vault.ErrEmptyMetaID
+	}
+	return bs.DB.Update(func(tx *bolt.Tx) error {
+		hb := tx.Bucket(tb("history"))
+		// группируем версии по пользователям и секретам
+		uhb, err := hb.CreateBucketIfNotExists(tb(fmt.Sprintf("%d", meta.UserID)))
+		if err != nil {
+			return err
+		}
+		shb, err := uhb.CreateBucketIfNotExists([]byte(meta.ID))
+		if err != nil {
+			return err
+		}
+		value, err := serialize(meta)
+		if err != nil {
+			return err
+		}
+		return shb.Put(revisionKey(meta.Revision), value)
+	})
+}
+
+// ListMetaRevisions возвращает мета-данные предыдущих версий секрета metaID пользователя userID, начиная с последней.
+func (bs *BoltStorage) ListMetaRevisions(ctx context.Context, metaID vault.MetaID, userID user.ID) (vault.List, error) {
+	list := vault.List{}
+	err := bs.View(func(tx *bolt.Tx) error {
+		uhb := tx.Bucket(tb("history")).Bucket(tb(fmt.Sprintf("%d", userID)))
+		if uhb == nil {
+			return nil
+		}
+		shb := uhb.Bucket([]byte(metaID))
+		if shb == nil {
+			return nil
+		}
+		c := shb.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			m := vault.Meta{}
+			if err := deserialize(v, &m); err != nil {
+				return err
+			}
+			list = append(list, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// DeleteMetaRevision удаляет мета-данные предыдущей версии секрета.
+func (bs *BoltStorage) DeleteMetaRevision(ctx context.Context, meta vault.Meta) error {
+	return bs.DB.Update(func(tx *bolt.Tx) error {
+		uhb := tx.Bucket(tb("history")).Bucket(tb(fmt.Sprintf("%d", meta.UserID)))
+		if uhb == nil {
+			return nil
+		}
+		shb := uhb.Bucket([]byte(meta.ID))
+		if shb == nil {
+			return nil
+		}
+		if err := shb.Delete(revisionKey(meta.Revision)); err != nil {
+			return err
+		}
+		// не оставляем пустые бакеты удаленных секретов
+		if k, _ := shb.Cursor().First(); k == nil {
+			return uhb.DeleteBucket([]byte(meta.ID))
+		}
+		return nil
+	})
+}
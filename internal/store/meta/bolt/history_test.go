@@ -0,0 +1,70 @@
+package bolt
+
+import (
+	"context"
+
+	"github.com/k1nky/gophkeeper/internal/entity/vault"
+	"github.com/stretchr/testify/suite"
+)
+
+type historyTestSuite struct {
+	suite.Suite
+	bs *BoltStorage
+}
+
+func (suite *historyTestSuite) SetupTest() {
+	var err error
+	rootDir := suite.T().TempDir()
+	if suite.bs, err = openTestDB(rootDir); err != nil {
+		suite.FailNow(err.Error())
+		return
+	}
+}
+
+func (suite *historyTestSuite) TestPutMetaRevisionEmptyID() {
+	err := suite.bs.PutMetaRevision(context.TODO(), vault.Meta{Revision: 1})
+	suite.ErrorIs(err, vault.ErrEmptyMetaID)
+}
+
+func (suite *historyTestSuite) TestListMetaRevisionsNotExists() {
+	got, err := suite.bs.ListMetaRevisions(context.TODO(), vault.NewMetaID(), 1)
+	suite.NoError(err)
+	suite.Empty(got)
+}
+
+func (suite *historyTestSuite) TestListMetaRevisions() {
+	id := vault.NewMetaID()
+	revisions := []vault.Meta{
+		{ID: id, UserID: 1, Alias: "v1", Revision: 10, DataID: "1-10"},
+		{ID: id, UserID: 1, Alias: "v3", Revision: 30, DataID: "1-30"},
+		{ID: id, UserID: 1, Alias: "v2", Revision: 20, DataID: "1-20"},
+		// версии другого пользователя не должны попасть в список
+		{ID: id, UserID: 2, Alias: "other", Revision: 40, DataID: "2-40"},
+	}
+	for _, m := range revisions {
+		suite.NoError(suite.bs.PutMetaRevision(context.TODO(), m))
+	}
+	got, err := suite.bs.ListMetaRevisions(context.TODO(), id, 1)
+	suite.NoError(err)
+	suite.Equal(vault.List{revisions[1], revisions[2], revisions[0]}, got)
+}
+
+func (suite *historyTestSuite) TestDeleteMetaRevision() {
+	id := vault.NewMetaID()
+	first := vault.Meta{ID: id, UserID: 1, Revision: 10}
+	second := vault.Meta{ID: id, UserID: 1, Revision: 20}
+	suite.NoError(suite.bs.PutMetaRevision(context.TODO(), first))
+	suite.NoError(suite.bs.PutMetaRevision(context.TODO(), second))
+
+	suite.NoError(suite.bs.DeleteMetaRevision(context.TODO(), first))
+	got, err := suite.bs.ListMetaRevisions(context.TODO(), id, 1)
+	suite.NoError(err)
+	suite.Equal(vault.List{second}, got)
+
+	suite.NoError(suite.bs.DeleteMetaRevision(context.TODO(), second))
+	got, err = suite.bs.ListMetaRevisions(context.TODO(), id, 1)
+	suite.NoError(err)
+	suite.Empty(got)
+	// удаление отсутствующей версии не является ошибкой
+	suite.NoError(suite.bs.DeleteMetaRevision(context.TODO(), second))
+}
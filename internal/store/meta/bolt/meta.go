@@ -3,6 +3,7 @@ package bolt
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/k1nky/gophkeeper/internal/entity/user"
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
@@ -27,6 +28,9 @@ func (bs *BoltStorage) GetMetaByAlias(ctx context.Context, alias string, userID
 
 		c := umb.Cursor()
 		for k, v := c.First(); k != nil; k, v = c.Next() {
+			// gob не перезаписывает поля с нулевыми значениями, поэтому каждую запись
+			// десериализуем в новую структуру
+			*m = vault.Meta{}
 			if err := deserialize(v, m); err != nil {
 				return err
 			}
@@ -111,6 +115,42 @@ func (bs *BoltStorage) ListMetaByUser(ctx context.Context, userID user.ID) (vaul
 	return list, nil
 }
 
+// ListMetaByFilter возвращает список мета-данных секретов пользователя userID, удовлетворяющих фильтру filter.
+func (bs *BoltStorage) ListMetaByFilter(ctx context.Context, userID user.ID, filter vault.Filter) (vault.List, error) {
+	list, err := bs.ListMetaByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return list.Filter(filter), nil
+}
+
+// ListExpiredMeta возвращает мета-данные неудаленных секретов всех пользователей, срок действия которых
+// истек на момент now, но которые еще не помечены как истекшие.
+func (bs *BoltStorage) ListExpiredMeta(ctx context.Context, now time.Time) (vault.List, error) {
+	list := vault.List{}
+	err := bs.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(tb("meta"))
+		// мета-данные сгруппированы по пользователям, поэтому перебираем бакеты всех пользователей
+		return b.ForEachBucket(func(k []byte) error {
+			c := b.Bucket(k).Cursor()
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				m := vault.Meta{}
+				if err := deserialize(v, &m); err != nil {
+					return err
+				}
+				if !m.IsDeleted && !m.IsExpired && m.IsExpiredAt(now) {
+					list = append(list, m)
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
 func (bs *BoltStorage) putMeta(ctx context.Context, meta vault.Meta) (*vault.Meta, error) {
 	err := bs.DB.Update(func(tx *bolt.Tx) error {
 
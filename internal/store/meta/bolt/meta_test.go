@@ -2,6 +2,7 @@ package bolt
 
 import (
 	"context"
+	"time"
 
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
 	"github.com/stretchr/testify/suite"
@@ -148,6 +149,29 @@ func (suite *metaTestSuite) TestGetMetaByAlias() {
 	suite.Assert().Equal(m, got)
 }
 
+func (suite *metaTestSuite) TestGetMetaByAliasWithEmptyFields() {
+	ctx := context.TODO()
+	expected := vault.Meta{
+		UserID: 1,
+		Alias:  "alias",
+		ID:     "1_100",
+	}
+	_, err := suite.bs.NewMeta(ctx, expected)
+	suite.NoError(err)
+	// запись, которая при переборе идет раньше искомой, не должна влиять на результат
+	_, err = suite.bs.NewMeta(ctx, vault.Meta{
+		UserID:     1,
+		Alias:      "alias#2",
+		Extra:      "some extra",
+		ID:         "1_000",
+		WrappedKey: []byte("wrapped key"),
+	})
+	suite.NoError(err)
+	got, err := suite.bs.GetMetaByAlias(ctx, "alias", 1)
+	suite.NoError(err)
+	suite.Equal(expected, *got)
+}
+
 func (suite *metaTestSuite) TestListMetaByUser() {
 	expected := vault.List{
 		{
@@ -177,6 +201,36 @@ func (suite *metaTestSuite) TestListMetaByUser() {
 	suite.Assert().ElementsMatch(expected, list)
 }
 
+func (suite *metaTestSuite) TestListMetaByFilter() {
+	ctx := context.Background()
+	metas := []vault.Meta{
+		{UserID: 1, ID: "1_100", Alias: "bank", Type: vault.TypeCreditCard, Folder: "finance/banks", Tags: []string{"personal"}},
+		{UserID: 1, ID: "1_101", Alias: "broker", Type: vault.TypeLoginPassword, Folder: "finance", Tags: []string{"personal", "work"}},
+		{UserID: 1, ID: "1_102", Alias: "mail", Type: vault.TypeLoginPassword, Tags: []string{"work"}},
+		{UserID: 1, ID: "1_103", Alias: "financial", Type: vault.TypeText, Folder: "financial"},
+	}
+	for _, m := range metas {
+		_, err := suite.bs.NewMeta(ctx, m)
+		suite.NoError(err)
+	}
+	tests := []struct {
+		name     string
+		filter   vault.Filter
+		expected []vault.Meta
+	}{
+		{name: "empty", filter: vault.Filter{}, expected: metas},
+		{name: "folder with subfolders", filter: vault.Filter{Folder: "/finance/"}, expected: metas[:2]},
+		{name: "tags", filter: vault.Filter{Tags: []string{"Work", "personal"}}, expected: metas[1:2]},
+		{name: "types", filter: vault.Filter{Types: []vault.SecretType{vault.TypeLoginPassword}}, expected: metas[1:3]},
+		{name: "nothing", filter: vault.Filter{Folder: "finance", Tags: []string{"work"}, Types: []vault.SecretType{vault.TypeText}}, expected: []vault.Meta{}},
+	}
+	for _, tt := range tests {
+		list, err := suite.bs.ListMetaByFilter(ctx, 1, tt.filter)
+		suite.NoError(err, tt.name)
+		suite.ElementsMatch(tt.expected, list, tt.name)
+	}
+}
+
 func (suite *metaTestSuite) TestIsExist() {
 	ctx := context.Background()
 	_, err := suite.bs.NewMeta(ctx, vault.Meta{
@@ -200,3 +254,23 @@ func (suite *metaTestSuite) TestIsExist() {
 	suite.True(suite.bs.IsExist(ctx, vault.Meta{UserID: 1, ID: "1_101"}))
 	suite.True(suite.bs.IsExist(ctx, vault.Meta{UserID: 1, ID: "1_103", Alias: "alias#101"}))
 }
+
+func (suite *metaTestSuite) TestListExpiredMeta() {
+	ctx := context.Background()
+	now := time.Now()
+	metas := []vault.Meta{
+		{UserID: 1, ID: "1_100", ExpiresAt: now.Add(-time.Hour).Unix()},
+		{UserID: 1, ID: "1_101", ExpiresAt: now.Add(time.Hour).Unix()},
+		{UserID: 1, ID: "1_102", ExpiresAt: now.Add(-time.Hour).Unix(), IsExpired: true},
+		{UserID: 1, ID: "1_103", ExpiresAt: now.Add(-time.Hour).Unix(), IsDeleted: true},
+		{UserID: 1, ID: "1_104"},
+		{UserID: 2, ID: "2_100", ExpiresAt: now.Add(-time.Minute).Unix()},
+	}
+	for _, m := range metas {
+		_, err := suite.bs.NewMeta(ctx, m)
+		suite.NoError(err)
+	}
+	list, err := suite.bs.ListExpiredMeta(ctx, now)
+	suite.NoError(err)
+	suite.ElementsMatch([]vault.Meta{metas[0], metas[5]}, list)
+}
@@ -28,5 +28,6 @@ func shouldSkipDBTest(t *testing.T) bool {
 
 func TestAdapter(t *testing.T) {
 	suite.Run(t, new(usersTestSuite))
+	suite.Run(t, new(metaTestSuite))
 	// suite.Run(t, new(ordersTestSuite))
 }
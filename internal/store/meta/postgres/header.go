@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/k1nky/gophkeeper/internal/entity/user"
+	"github.com/k1nky/gophkeeper/internal/entity/vault"
+)
+
+// Возвращает заголовок хранилища пользователя userID. Nil - заголовок еще не опубликован
+func (ps *PostgresStorage) GetUserVaultHeader(ctx context.Context, userID user.ID) (*vault.Header, error) {
+	var value []byte
+
+	const query = `SELECT header FROM vault_headers WHERE user_id=$1`
+	row := ps.QueryRowContext(ctx, query, userID)
+	if err := row.Err(); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	if err := row.Scan(&value); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, NewExecutingQueryError(err)
+	}
+	h := &vault.Header{}
+	if err := json.Unmarshal(value, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Сохраняет заголовок хранилища h пользователя userID
+func (ps *PostgresStorage) PutUserVaultHeader(ctx context.Context, userID user.ID, h vault.Header) error {
+
+	const query = `
+		INSERT INTO vault_headers (user_id, header)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET header = EXCLUDED.header
+	`
+
+	value, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	if _, err := ps.ExecContext(ctx, query, userID, value); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	return nil
+}
@@ -2,7 +2,10 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/k1nky/gophkeeper/internal/entity/user"
 	"github.com/k1nky/gophkeeper/internal/entity/vault"
@@ -11,18 +14,34 @@ import (
 // user_id INT,
 // meta_unique_key VARCHAR(100) UNIQUE NOT NULL,
 // alias VARCHAR(100),
-// type secret_type,
+// type VARCHAR(32),
 // extra text,
+// wrapped_key bytea,
+// sealed bytea,
+// attributes jsonb,
+// tags text[],
+// folder text,
+// expires_at bigint,
+// is_expired boolean,
+// version jsonb,
 
 func (ps *PostgresStorage) NewMeta(ctx context.Context, m vault.Meta) (*vault.Meta, error) {
 
 	const query = `
-		INSERT INTO meta AS m (user_id, meta_unique_key, alias, type, extra)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO meta AS m (user_id, meta_unique_key, alias, type, extra, wrapped_key, sealed, attributes, tags, folder, expires_at, is_expired, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		RETURNING m.meta_id
 	`
 
-	row := ps.QueryRowContext(ctx, query, m.UserID, m.ID, m.Alias, m.Type, m.Extra)
+	attributes, err := json.Marshal(m.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	version, err := json.Marshal(m.Version)
+	if err != nil {
+		return nil, err
+	}
+	row := ps.QueryRowContext(ctx, query, m.UserID, m.ID, m.Alias, m.Type.String(), m.Extra, m.WrappedKey, m.Sealed, attributes, m.Tags, m.Folder, m.ExpiresAt, m.IsExpired, version)
 	if err := row.Err(); err != nil {
 		if ps.hasUniqueViolationError(err) {
 			return nil, fmt.Errorf("%s %w", m.ID, user.ErrDuplicateLogin)
@@ -51,3 +70,111 @@ func (ps *PostgresStorage) ListMetaByUser(ctx context.Context, userID user.ID) (
 	list := vault.List{}
 	return list, nil
 }
+
+func (ps *PostgresStorage) ListMetaByFilter(ctx context.Context, userID user.ID, filter vault.Filter) (vault.List, error) {
+	list, err := ps.ListMetaByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return list.Filter(filter), nil
+}
+
+func (ps *PostgresStorage) ListExpiredMeta(ctx context.Context, now time.Time) (vault.List, error) {
+	list := vault.List{}
+	return list, nil
+}
+
+// GetDeviceID возвращает пустой ИД устройства. Хранилище не привязано к устройству, а сервер, которому не нужен
+// ИД устройства, задает это явно (keeper.Service.SetDeviceID) независимо от хранилища мета-данных.
+func (ps *PostgresStorage) GetDeviceID(ctx context.Context) (string, error) {
+	return "", nil
+}
+
+func (ps *PostgresStorage) PutMetaRevision(ctx context.Context, m vault.Meta) error {
+
+	const query = `
+		INSERT INTO meta_history (user_id, meta_unique_key, revision, alias, type, extra, wrapped_key, sealed, attributes, tags, folder, data_id, version, expires_at, is_expired)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT (user_id, meta_unique_key, revision) DO UPDATE SET
+			alias = EXCLUDED.alias, type = EXCLUDED.type, extra = EXCLUDED.extra, wrapped_key = EXCLUDED.wrapped_key,
+			sealed = EXCLUDED.sealed, attributes = EXCLUDED.attributes, tags = EXCLUDED.tags, folder = EXCLUDED.folder,
+			data_id = EXCLUDED.data_id, version = EXCLUDED.version, expires_at = EXCLUDED.expires_at, is_expired = EXCLUDED.is_expired
+	`
+
+	attributes, err := json.Marshal(m.Attributes)
+	if err != nil {
+		return err
+	}
+	version, err := json.Marshal(m.Version)
+	if err != nil {
+		return err
+	}
+	if _, err := ps.ExecContext(ctx, query, m.UserID, m.ID, m.Revision, m.Alias, m.Type.String(), m.Extra, m.WrappedKey, m.Sealed, attributes, m.Tags, m.Folder, m.DataID, version, m.ExpiresAt, m.IsExpired); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	return nil
+}
+
+// Возвращает мета-данные предыдущих версий секрета metaID пользователя userID, начиная с последней
+func (ps *PostgresStorage) ListMetaRevisions(ctx context.Context, metaID vault.MetaID, userID user.ID) (vault.List, error) {
+
+	// метки читаются как json, так как массивы не поддерживаются database/sql
+	const query = `
+		SELECT revision, alias, type, extra, wrapped_key, sealed, attributes, to_json(tags), folder, data_id, version, expires_at, is_expired
+		FROM meta_history
+		WHERE user_id=$1 AND meta_unique_key=$2
+		ORDER BY revision DESC
+	`
+
+	rows, err := ps.QueryContext(ctx, query, userID, metaID)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	defer rows.Close()
+	list := vault.List{}
+	for rows.Next() {
+		var (
+			typeName                     string
+			alias, extra, folder, dataID sql.NullString
+			attributes, tags, version    []byte
+		)
+		m := vault.Meta{ID: metaID, UserID: userID}
+		if err := rows.Scan(&m.Revision, &alias, &typeName, &extra, &m.WrappedKey, &m.Sealed, &attributes, &tags, &folder, &dataID, &version, &m.ExpiresAt, &m.IsExpired); err != nil {
+			return nil, NewExecutingQueryError(err)
+		}
+		m.Alias, m.Extra, m.Folder, m.DataID = alias.String, extra.String, folder.String, dataID.String
+		if m.Type, err = vault.ParseSecretType(typeName); err != nil {
+			return nil, err
+		}
+		if err := unmarshalJSON(attributes, &m.Attributes); err != nil {
+			return nil, err
+		}
+		if err := unmarshalJSON(tags, &m.Tags); err != nil {
+			return nil, err
+		}
+		if err := unmarshalJSON(version, &m.Version); err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	return list, nil
+}
+
+// unmarshalJSON разбирает значение столбца json, пустое значение (NULL) пропускается.
+func unmarshalJSON(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (ps *PostgresStorage) DeleteMetaRevision(ctx context.Context, m vault.Meta) error {
+	const query = `DELETE FROM meta_history WHERE user_id=$1 AND meta_unique_key=$2 AND revision=$3`
+	if _, err := ps.ExecContext(ctx, query, m.UserID, m.ID, m.Revision); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	return nil
+}
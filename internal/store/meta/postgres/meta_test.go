@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/k1nky/gophkeeper/internal/entity/vault"
+	"github.com/stretchr/testify/suite"
+)
+
+type metaTestSuite struct {
+	suite.Suite
+	a *PostgresStorage
+}
+
+func (suite *metaTestSuite) SetupTest() {
+	if shouldSkipDBTest(suite.T()) {
+		return
+	}
+	var err error
+	if suite.a, err = openTestDB(); err != nil {
+		suite.FailNow(err.Error())
+		return
+	}
+	if _, err := suite.a.Exec(`
+		DELETE FROM users CASCADE;
+		INSERT INTO users(user_id, login, password)
+			VALUES (1, 'u1', 'p1');
+	`); err != nil {
+		suite.FailNow(err.Error())
+	}
+}
+
+func (suite *metaTestSuite) TestMetaRevisions() {
+	first := vault.Meta{
+		ID:         "id#1",
+		UserID:     1,
+		Alias:      "alias",
+		Type:       vault.TypeText,
+		Revision:   1,
+		Version:    vault.Version{"a": 1},
+		DataID:     "data#1",
+		Attributes: map[string]string{"key": "value"},
+		Tags:       []string{"work"},
+		Folder:     "web",
+		ExpiresAt:  1700000000,
+	}
+	second := first
+	second.Revision, second.Version, second.DataID, second.Tags = 2, vault.Version{"a": 2}, "data#2", nil
+	suite.NoError(suite.a.PutMetaRevision(context.TODO(), first))
+	suite.NoError(suite.a.PutMetaRevision(context.TODO(), second))
+
+	got, err := suite.a.ListMetaRevisions(context.TODO(), first.ID, first.UserID)
+	suite.NoError(err)
+	suite.Equal(vault.List{second, first}, got)
+
+	// повторная запись версии заменяет все ее мета-данные
+	updated := first
+	updated.WrappedKey, updated.Version, updated.Tags = []byte("key"), vault.Version{"a": 1, "b": 1}, []string{"home"}
+	updated.IsExpired = true
+	suite.NoError(suite.a.PutMetaRevision(context.TODO(), updated))
+	got, err = suite.a.ListMetaRevisions(context.TODO(), first.ID, first.UserID)
+	suite.NoError(err)
+	suite.Equal(vault.List{second, updated}, got)
+
+	suite.NoError(suite.a.DeleteMetaRevision(context.TODO(), second))
+	got, err = suite.a.ListMetaRevisions(context.TODO(), first.ID, first.UserID)
+	suite.NoError(err)
+	suite.Equal(vault.List{updated}, got)
+}
+
+func (suite *metaTestSuite) TestMetaRevisionsNotExists() {
+	got, err := suite.a.ListMetaRevisions(context.TODO(), "id#1000", 1)
+	suite.NoError(err)
+	suite.Empty(got)
+}
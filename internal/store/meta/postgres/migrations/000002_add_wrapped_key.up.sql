@@ -0,0 +1,2 @@
+-- ключ данных секрета, зашифрованный мастер-ключом хранилища
+ALTER TABLE meta ADD COLUMN IF NOT EXISTS wrapped_key bytea;
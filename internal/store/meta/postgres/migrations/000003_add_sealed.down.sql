@@ -0,0 +1 @@
+ALTER TABLE meta DROP COLUMN IF EXISTS sealed;
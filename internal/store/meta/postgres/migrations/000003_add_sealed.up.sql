@@ -0,0 +1,2 @@
+-- псевдоним и дополнительные данные, зашифрованные на клиенте
+ALTER TABLE meta ADD COLUMN IF NOT EXISTS sealed bytea;
@@ -0,0 +1,9 @@
+CREATE TYPE secret_type AS ENUM (
+   'TEXT',
+   'LOGIN_PASSWORD',
+   'CREDIT_CARD',
+   'FILE'
+);
+-- секреты новых типов нельзя представить в прежнем перечислении, поэтому они удаляются
+DELETE FROM meta WHERE type NOT IN ('TEXT', 'LOGIN_PASSWORD', 'CREDIT_CARD', 'FILE');
+ALTER TABLE meta ALTER COLUMN type TYPE secret_type USING type::secret_type;
@@ -0,0 +1,3 @@
+-- тип секрета хранится по имени из реестра типов, чтобы новые типы не требовали миграций
+ALTER TABLE meta ALTER COLUMN type TYPE VARCHAR(32) USING type::text;
+DROP TYPE IF EXISTS secret_type;
@@ -0,0 +1,2 @@
+-- неконфиденциальные атрибуты секрета, определяемые его типом
+ALTER TABLE meta ADD COLUMN IF NOT EXISTS attributes jsonb;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS meta_tags_idx;
+ALTER TABLE meta DROP COLUMN IF EXISTS folder;
+ALTER TABLE meta DROP COLUMN IF EXISTS tags;
@@ -0,0 +1,4 @@
+-- метки и папка секрета
+ALTER TABLE meta ADD COLUMN IF NOT EXISTS tags text[];
+ALTER TABLE meta ADD COLUMN IF NOT EXISTS folder text;
+CREATE INDEX IF NOT EXISTS meta_tags_idx ON meta USING GIN (tags);
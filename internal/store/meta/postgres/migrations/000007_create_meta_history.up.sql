@@ -0,0 +1,20 @@
+-- мета-данные предыдущих версий секретов
+CREATE TABLE IF NOT EXISTS meta_history (
+   user_id INT,
+   meta_unique_key VARCHAR(100) NOT NULL,
+   revision BIGINT NOT NULL,
+   alias VARCHAR(100),
+   type VARCHAR(32),
+   extra text,
+   wrapped_key bytea,
+   sealed bytea,
+   attributes jsonb,
+   tags text[],
+   folder text,
+   data_id text,
+   PRIMARY KEY (user_id, meta_unique_key, revision),
+   CONSTRAINT fk_user
+      FOREIGN KEY (user_id)
+      REFERENCES users(user_id)
+      ON DELETE CASCADE
+);
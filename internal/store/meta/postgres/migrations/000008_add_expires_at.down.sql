@@ -0,0 +1,5 @@
+ALTER TABLE meta_history DROP COLUMN IF EXISTS is_expired;
+ALTER TABLE meta_history DROP COLUMN IF EXISTS expires_at;
+DROP INDEX IF EXISTS meta_expires_at_idx;
+ALTER TABLE meta DROP COLUMN IF EXISTS is_expired;
+ALTER TABLE meta DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,6 @@
+-- срок действия секрета и метка истечения срока
+ALTER TABLE meta ADD COLUMN IF NOT EXISTS expires_at bigint NOT NULL DEFAULT 0;
+ALTER TABLE meta ADD COLUMN IF NOT EXISTS is_expired boolean NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS meta_expires_at_idx ON meta (expires_at) WHERE expires_at <> 0 AND NOT is_expired;
+ALTER TABLE meta_history ADD COLUMN IF NOT EXISTS expires_at bigint NOT NULL DEFAULT 0;
+ALTER TABLE meta_history ADD COLUMN IF NOT EXISTS is_expired boolean NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+ALTER TABLE meta_history DROP COLUMN IF EXISTS version;
+ALTER TABLE meta DROP COLUMN IF EXISTS version;
@@ -0,0 +1,3 @@
+-- вектор версий секрета
+ALTER TABLE meta ADD COLUMN IF NOT EXISTS version jsonb;
+ALTER TABLE meta_history ADD COLUMN IF NOT EXISTS version jsonb;
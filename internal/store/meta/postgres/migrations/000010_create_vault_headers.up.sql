@@ -0,0 +1,9 @@
+-- заголовки хранилищ пользователей, общие для всех их устройств
+CREATE TABLE IF NOT EXISTS vault_headers (
+   user_id INT PRIMARY KEY,
+   header jsonb NOT NULL,
+   CONSTRAINT fk_user
+      FOREIGN KEY (user_id)
+      REFERENCES users(user_id)
+      ON DELETE CASCADE
+);